@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"go.uber.org/zap"
+)
+
+// AccessGuard refuses service to users who are neither on the allowlist nor redeemed
+// the invite code. It is registered in a dispatcher group that runs before all command
+// handlers and ends update processing for strangers.
+type AccessGuard struct {
+	allowed    map[int64]bool
+	inviteCode string
+	db         AccessStore
+	logger     *zap.SugaredLogger
+}
+
+func NewAccessGuard(allowedIDs []int64, inviteCode string, db AccessStore, logger *zap.SugaredLogger) *AccessGuard {
+	allowed := make(map[int64]bool, len(allowedIDs))
+	for _, id := range allowedIDs {
+		allowed[id] = true
+	}
+	return &AccessGuard{
+		allowed:    allowed,
+		inviteCode: inviteCode,
+		db:         db,
+		logger:     logger,
+	}
+}
+
+// Enabled reports whether access control was configured at all
+func (g *AccessGuard) Enabled() bool {
+	return len(g.allowed) > 0 || g.inviteCode != ""
+}
+
+func (g *AccessGuard) Name() string {
+	return "access_guard"
+}
+
+// CheckUpdate matches every update sent by a user that isn't allowed to use the bot
+func (g *AccessGuard) CheckUpdate(b *gotgbot.Bot, ctx *ext.Context) bool {
+	user := ctx.EffectiveUser
+	if user == nil {
+		return false
+	}
+	return !g.isAllowed(ctx, user.Id)
+}
+
+func (g *AccessGuard) HandleUpdate(b *gotgbot.Bot, ctx *ext.Context) error {
+	userID := ctx.EffectiveUser.Id
+
+	// Let strangers in if they present the invite code via /start <code>
+	if msg := ctx.Message; msg != nil && g.inviteCode != "" {
+		fields := strings.Fields(msg.Text)
+		if len(fields) == 2 && strings.HasPrefix(fields[0], "/start") && subtle.ConstantTimeCompare([]byte(fields[1]), []byte(g.inviteCode)) == 1 {
+			reqCtx, cancel := newRequestContext(ctx)
+			defer cancel()
+
+			if err := g.db.AllowUser(reqCtx, userID); err != nil {
+				updateLogger(ctx, g.logger).Errorw("Failed to store allowed user", "user_id", userID, "error", err)
+				_, err := msg.Reply(b, "Failed to redeem invite code. Please try again later.", &gotgbot.SendMessageOpts{})
+				if err != nil {
+					return err
+				}
+				return ext.EndGroups
+			}
+
+			updateLogger(ctx, g.logger).Infow("User redeemed invite code", "user_id", userID)
+			_, err := msg.Reply(b, "Invite code accepted. Send /start to see the available commands.", &gotgbot.SendMessageOpts{})
+			if err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+	}
+
+	updateLogger(ctx, g.logger).Infow("Refused update from unauthorized user", "user_id", userID)
+
+	switch {
+	case ctx.Message != nil && ctx.EffectiveChat.Type == gotgbot.ChatTypePrivate:
+		_, err := ctx.Message.Reply(b, "Sorry, this is a private bot. Ask the operator for access.", &gotgbot.SendMessageOpts{})
+		if err != nil {
+			return err
+		}
+	case ctx.InlineQuery != nil:
+		_, err := b.AnswerInlineQuery(ctx.InlineQuery.Id, []gotgbot.InlineQueryResult{}, &gotgbot.AnswerInlineQueryOpts{
+			CacheTime:  300,
+			IsPersonal: true,
+		})
+		if err != nil {
+			return err
+		}
+	case ctx.CallbackQuery != nil:
+		_, err := ctx.CallbackQuery.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      "Sorry, this is a private bot.",
+			ShowAlert: true,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return ext.EndGroups
+}
+
+func (g *AccessGuard) isAllowed(ctx *ext.Context, userID int64) bool {
+	if g.allowed[userID] {
+		return true
+	}
+	if g.inviteCode == "" {
+		return false
+	}
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	allowed, err := g.db.IsUserAllowed(reqCtx, userID)
+	if err != nil {
+		updateLogger(ctx, g.logger).Errorw("Failed to check allowed user", "user_id", userID, "error", err)
+		return false
+	}
+	return allowed
+}
+
+// parseUserIDs parses a comma separated list of Telegram user IDs
+func parseUserIDs(s string) ([]int64, error) {
+	var ids []int64
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user ID %q: %w", part, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+)
+
+const activityUsage = "Usage: /activity <position id> [v3|v4]"
+
+// maxActivityEvents is how many of a position's latest events /activity lists
+const maxActivityEvents = 20
+
+func (h *BotHandlers) handleActivity(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received activity command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	args := ctx.Args()
+	if len(args) < 2 {
+		_, err := ctx.EffectiveMessage.Reply(b, activityUsage, &gotgbot.SendMessageOpts{})
+		return err
+	}
+	id, versions, ok := parsePositionArgs(args[1:])
+	if !ok {
+		_, err := ctx.EffectiveMessage.Reply(b, "Invalid position. "+activityUsage, &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	source, ok := h.uniswapClient.(uniswap.PositionEventSource)
+	if !ok {
+		_, err := ctx.EffectiveMessage.Reply(b, "Position activity is not supported by the configured data source.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	var pos *uniswap.Position
+	for _, version := range versions {
+		p, err := h.uniswapClient.GetPosition(reqCtx, version, id)
+		if errors.Is(err, uniswap.ErrPositionNotFound) {
+			continue
+		}
+		if err != nil {
+			h.log(ctx).Errorw("Failed to fetch position", "position_id", id.String(), "version", version, "error", err)
+			_, err := ctx.EffectiveMessage.Reply(b, "Failed to look up position. Please try again later.", &gotgbot.SendMessageOpts{})
+			return err
+		}
+		pos = p
+		break
+	}
+	if pos == nil {
+		_, err := ctx.EffectiveMessage.Reply(b, fmt.Sprintf("Position %s not found.", id.String()), &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	events, err := source.GetPositionEvents(reqCtx, pos.Version, pos.ID)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to fetch position events", "position_id", id.String(), "version", pos.Version, "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to fetch position activity. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	msg := formatActivity(*pos, events, h.userLocation(reqCtx, ctx.EffectiveUser.Id))
+	_, err = ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true},
+	})
+	return err
+}
+
+// formatActivity lists the latest of a position's events, oldest first, at times in loc
+func formatActivity(pos uniswap.Position, events []uniswap.PositionEvent, loc *time.Location) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s #%s activity\n\n", pos.Pair(), pos.ID.String())
+	if len(events) == 0 {
+		sb.WriteString("No activity recorded for this position.")
+		return sb.String()
+	}
+
+	if len(events) > maxActivityEvents {
+		fmt.Fprintf(&sb, "%d earlier events not shown\n", len(events)-maxActivityEvents)
+		events = events[len(events)-maxActivityEvents:]
+	}
+	for _, event := range events {
+		fmt.Fprintf(&sb, "%s %s\n", event.Timestamp.In(loc).Format("2006-01-02 15:04"), formatPositionEvent(pos, event))
+	}
+	if pos.Version == uniswap.VersionV4 {
+		sb.WriteString("\nDeposits, withdrawals and fee collections aren't recorded for V4 positions.")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// formatPositionEvent describes an event of pos, e.g. "Collected fees: 0.01 WETH, 20 USDC"
+func formatPositionEvent(pos uniswap.Position, event uniswap.PositionEvent) string {
+	amounts := func() string {
+		return fmt.Sprintf("%s %s, %s %s", formatTokenUnits(event.Amount0), pos.Token0.Symbol, formatTokenUnits(event.Amount1), pos.Token1.Symbol)
+	}
+	switch event.Type {
+	case uniswap.EventMint:
+		if event.Amount0 == nil || event.Amount1 == nil {
+			return "Minted to " + shortAddress(event.To.Hex())
+		}
+		return fmt.Sprintf("Minted to %s: %s", shortAddress(event.To.Hex()), amounts())
+	case uniswap.EventIncreaseLiquidity:
+		return "Added " + amounts()
+	case uniswap.EventDecreaseLiquidity:
+		return "Removed " + amounts()
+	case uniswap.EventCollect:
+		return "Collected fees: " + amounts()
+	case uniswap.EventTransfer:
+		return fmt.Sprintf("Transferred from %s to %s", shortAddress(event.From.Hex()), shortAddress(event.To.Hex()))
+	}
+	return string(event.Type)
+}
+
+// formatTokenUnits formats a token amount already adjusted by decimals to 6 significant digits,
+// without an exponent
+func formatTokenUnits(amount *big.Float) string {
+	if amount == nil {
+		return "0"
+	}
+	f, _ := amount.Float64()
+	f, _ = strconv.ParseFloat(strconv.FormatFloat(f, 'g', 6, 64), 64)
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
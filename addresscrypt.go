@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// encryptedAddressPrefix marks wallet addresses stored encrypted
+const encryptedAddressPrefix = "enc1:"
+
+// errAddressEncrypted is returned for encrypted addresses when no key was configured
+var errAddressEncrypted = errors.New("wallet address is encrypted, but DB_ENCRYPTION_KEY is not set")
+
+// addressCipher encrypts wallet addresses at rest, so a leaked database doesn't tie chats to on-chain
+// identities. Encryption is deterministic: the nonce is derived from the address, so equal addresses
+// encrypt equally and can still be looked up and joined on in SQL. A nil addressCipher stores
+// addresses in plain text.
+type addressCipher struct {
+	aead     cipher.AEAD
+	nonceKey []byte
+}
+
+func newAddressCipher(key []byte) (*addressCipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(deriveKey(key, "wallet address encryption"))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &addressCipher{aead: aead, nonceKey: deriveKey(key, "wallet address nonce")}, nil
+}
+
+// parseEncryptionKey decodes a 32 byte key given as 64 hex characters
+func parseEncryptionKey(s string) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("encryption key must be hex encoded: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes (64 hex characters), got %d bytes", len(key))
+	}
+	return key, nil
+}
+
+// deriveKey derives an independent subkey for each purpose from the configured key
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// seal returns the form of address to store
+func (c *addressCipher) seal(address string) string {
+	if c == nil || address == "" {
+		return address
+	}
+
+	mac := hmac.New(sha256.New, c.nonceKey)
+	mac.Write([]byte(address))
+	nonce := mac.Sum(nil)[:c.aead.NonceSize()]
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(address), nil)
+	return encryptedAddressPrefix + base64.RawURLEncoding.EncodeToString(sealed)
+}
+
+// sealPayload returns the form to store of other data about a wallet, such as its positions, which
+// would give the wallet away. Payloads aren't looked up, so they are encrypted with a random nonce.
+func (c *addressCipher) sealPayload(data string) (string, error) {
+	if c == nil {
+		return data, nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(data), nil)
+	return encryptedAddressPrefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// open returns the address or payload of a stored value. Values stored before encryption was enabled are
+// returned as is.
+func (c *addressCipher) open(stored string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, encryptedAddressPrefix)
+	if !ok {
+		return stored, nil
+	}
+	if c == nil {
+		return "", errAddressEncrypted
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	address, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt stored value, was DB_ENCRYPTION_KEY changed? %w", err)
+	}
+	return string(address), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// adminStatsDays is how many days /admin_stats covers
+const adminStatsDays = 7
+
+// handleAdminStats shows bot administrators how much the bot and the Graph API are used
+func (h *BotHandlers) handleAdminStats(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received admin_stats command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	if !h.admins[ctx.EffectiveUser.Id] {
+		_, err := ctx.EffectiveMessage.Reply(b, "Only the bot's administrators can see usage statistics.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	usage, err := h.usage.Usage(reqCtx, adminStatsDays)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get usage", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to get usage statistics. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+	if len(usage) == 0 {
+		_, err := ctx.EffectiveMessage.Reply(b, "No usage recorded yet.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	_, err = ctx.EffectiveMessage.Reply(b, formatAdminStats(usage), &gotgbot.SendMessageOpts{})
+	return err
+}
+
+// formatAdminStats lists the usage of each day, newest first, and details the newest day
+func formatAdminStats(usage []DailyUsage) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Usage over the last %d days (UTC):\n\n", adminStatsDays))
+
+	var graphRequests int64
+	for _, u := range usage {
+		commands, requests := usageTotal(u, usageKindCommand), usageTotal(u, usageKindGraphRequest)
+		graphRequests += requests
+		sb.WriteString(fmt.Sprintf("%s: %d users, %d commands, %d Graph requests\n", u.Day, u.ActiveUsers, commands, requests))
+	}
+
+	latest := usage[0]
+	for _, section := range []struct{ kind, title, prefix string }{
+		{usageKindCommand, "Commands", "/"},
+		{usageKindGraphRequest, "Graph requests", ""},
+	} {
+		var counts []string
+		for _, c := range latest.Counters {
+			if c.Kind == section.kind {
+				counts = append(counts, fmt.Sprintf("%s%s %d", section.prefix, c.Name, c.Count))
+			}
+		}
+		if len(counts) > 0 {
+			sb.WriteString(fmt.Sprintf("\n%s on %s: %s\n", section.title, latest.Day, strings.Join(counts, ", ")))
+		}
+	}
+
+	// Extrapolate from the days with data, which is what Graph API quotas are planned by
+	perDay := graphRequests / int64(len(usage))
+	sb.WriteString(fmt.Sprintf("\nAbout %d Graph requests per day, %d per 30 days.", perDay, perDay*30))
+	return sb.String()
+}
+
+// usageTotal sums a day's counters of one kind
+func usageTotal(u DailyUsage, kind string) int64 {
+	var total int64
+	for _, c := range u.Counters {
+		if c.Kind == kind {
+			total += c.Count
+		}
+	}
+	return total
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+)
+
+// defaultAlertCooldown is the cooldown of new alert rules
+const defaultAlertCooldown = 6 * time.Hour
+
+const alertsUsage = `Usage:
+/alerts - list your alert rules
+/alerts add range <id> [v3|v4] - alert when a position goes out of range
+/alerts add fees <id> <usd> [v3|v4] - alert when a position's collected fees reach an amount
+/alerts set <rule> threshold <usd>
+/alerts set <rule> cooldown <duration, e.g. 30m or 12h>
+/alerts on|off <rule>
+/alerts delete <rule>`
+
+func (h *BotHandlers) handleAlerts(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received alerts command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	args := ctx.Args()
+	if len(args) < 2 {
+		return h.listAlertRules(reqCtx, b, ctx)
+	}
+
+	// Only administrators may change a group chat's alerts
+	if allowed, err := h.requireChatAdmin(b, ctx); !allowed {
+		return err
+	}
+
+	var msg string
+	switch strings.ToLower(args[1]) {
+	case "add":
+		msg = h.addAlertRule(reqCtx, ctx.EffectiveChat.Id, args[2:])
+	case "set", "on", "off":
+		msg = h.editAlertRule(reqCtx, ctx.EffectiveChat.Id, ctx.EffectiveUser.Id, strings.ToLower(args[1]), args[2:])
+	case "delete", "rm":
+		msg = h.deleteAlertRule(reqCtx, ctx.EffectiveChat.Id, args[2:])
+	default:
+		msg = alertsUsage
+	}
+
+	_, err := ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
+	return err
+}
+
+func (h *BotHandlers) listAlertRules(reqCtx context.Context, b *gotgbot.Bot, ctx *ext.Context) error {
+	rules, err := h.db.GetAlertRules(reqCtx, ctx.EffectiveChat.Id)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get alert rules", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to retrieve alert rules. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	if len(rules) == 0 {
+		_, err := ctx.EffectiveMessage.Reply(b, "You have no alert rules.\n\n"+alertsUsage, &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Your alert rules:\n")
+	for _, rule := range rules {
+		sb.WriteString(formatAlertRule(rule))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\nChange them with /alerts set, on, off or delete.")
+
+	_, err = ctx.EffectiveMessage.Reply(b, sb.String(), &gotgbot.SendMessageOpts{})
+	return err
+}
+
+// addAlertRule creates a rule from "<type> <id> [usd] [v3|v4]" and returns the reply
+func (h *BotHandlers) addAlertRule(ctx context.Context, chatID int64, args []string) string {
+	if len(args) < 2 {
+		return alertsUsage
+	}
+
+	rule := AlertRule{ChatID: chatID, Type: AlertType(strings.ToLower(args[0])), Cooldown: defaultAlertCooldown, Enabled: true}
+	positionArgs := args[1:]
+	switch rule.Type {
+	case AlertOutOfRange:
+	case AlertFees:
+		if len(args) < 3 {
+			return alertsUsage
+		}
+		threshold, ok := parseUSDArg(args[2])
+		if !ok {
+			return "Please provide a positive USD amount: /alerts add fees <id> <usd> [v3|v4]"
+		}
+		rule.Threshold = threshold
+		positionArgs = append([]string{args[1]}, args[3:]...)
+	default:
+		return alertsUsage
+	}
+
+	id, versions, ok := parsePositionArgs(positionArgs)
+	if !ok {
+		return alertsUsage
+	}
+	// Without an explicit version the rule watches the V3 position
+	rule.Target = uniswap.PositionKey(uniswap.Position{ID: id, Version: versions[0]})
+
+	ruleID, err := h.db.CreateAlertRule(ctx, rule)
+	if err != nil {
+		requestLogger(ctx, h.logger).Errorw("Failed to create alert rule", "error", err)
+		return "Failed to save the alert rule. Please try again later."
+	}
+	rule.ID = ruleID
+
+	return fmt.Sprintf("Alert rule added:\n%s\n\nRules only apply to positions of your tracked wallets and to tracked positions.", formatAlertRule(rule))
+}
+
+// editAlertRule applies "set <rule> threshold|cooldown <value>", "on <rule>" or "off <rule>" and returns the reply
+func (h *BotHandlers) editAlertRule(ctx context.Context, chatID, userID int64, action string, args []string) string {
+	if len(args) < 1 || (action == "set" && len(args) < 3) {
+		return alertsUsage
+	}
+
+	rule, msg := h.lookupAlertRule(ctx, chatID, args[0])
+	if msg != "" {
+		return msg
+	}
+
+	switch action {
+	case "on":
+		rule.Enabled = true
+	case "off":
+		rule.Enabled = false
+	case "set":
+		switch strings.ToLower(args[1]) {
+		case "threshold":
+			threshold, ok := parseUSDArg(args[2])
+			if !ok {
+				return "Please provide a positive USD amount: /alerts set <rule> threshold <usd>"
+			}
+			rule.Threshold = threshold
+		case "cooldown":
+			cooldown, err := time.ParseDuration(args[2])
+			if err != nil || cooldown < 0 {
+				return "Please provide a duration such as 30m or 12h: /alerts set <rule> cooldown <duration>"
+			}
+			entitlements, err := h.db.GetEntitlements(ctx, userID)
+			if err != nil {
+				requestLogger(ctx, h.logger).Errorw("Failed to get entitlements", "error", err)
+				return "Failed to save the alert rule. Please try again later."
+			}
+			if cooldown < entitlements.MinAlertCooldown {
+				return fmt.Sprintf("Your tier allows cooldowns of %s or more.", entitlements.MinAlertCooldown)
+			}
+			rule.Cooldown = cooldown
+		default:
+			return alertsUsage
+		}
+	}
+
+	if _, err := h.db.UpdateAlertRule(ctx, rule); err != nil {
+		requestLogger(ctx, h.logger).Errorw("Failed to update alert rule", "rule_id", rule.ID, "error", err)
+		return "Failed to save the alert rule. Please try again later."
+	}
+	return "Alert rule updated:\n" + formatAlertRule(rule)
+}
+
+func (h *BotHandlers) deleteAlertRule(ctx context.Context, chatID int64, args []string) string {
+	if len(args) < 1 {
+		return alertsUsage
+	}
+
+	rule, msg := h.lookupAlertRule(ctx, chatID, args[0])
+	if msg != "" {
+		return msg
+	}
+
+	if _, err := h.db.DeleteAlertRule(ctx, chatID, rule.ID); err != nil {
+		requestLogger(ctx, h.logger).Errorw("Failed to delete alert rule", "rule_id", rule.ID, "error", err)
+		return "Failed to delete the alert rule. Please try again later."
+	}
+	return fmt.Sprintf("Alert rule #%d deleted.", rule.ID)
+}
+
+// lookupAlertRule returns the chat's rule with the given "#n" or "n" ID. If there is none it returns a message for the user instead.
+func (h *BotHandlers) lookupAlertRule(ctx context.Context, chatID int64, arg string) (AlertRule, string) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil {
+		return AlertRule{}, alertsUsage
+	}
+
+	rule, found, err := h.db.GetAlertRule(ctx, chatID, id)
+	if err != nil {
+		requestLogger(ctx, h.logger).Errorw("Failed to get alert rule", "rule_id", id, "error", err)
+		return AlertRule{}, "Failed to retrieve the alert rule. Please try again later."
+	}
+	if !found {
+		return AlertRule{}, fmt.Sprintf("Alert rule #%d not found, see /alerts.", id)
+	}
+	return rule, ""
+}
+
+func formatAlertRule(rule AlertRule) string {
+	var condition string
+	switch rule.Type {
+	case AlertOutOfRange:
+		condition = fmt.Sprintf("%s out of range", rule.Target)
+	case AlertFees:
+		condition = fmt.Sprintf("%s fees reach %s", rule.Target, formatUSD(rule.Threshold, true))
+	default:
+		condition = fmt.Sprintf("%s %s", rule.Type, rule.Target)
+	}
+
+	state := "on"
+	if !rule.Enabled {
+		state = "off"
+	}
+	return fmt.Sprintf("#%d %s, every %s at most (%s)", rule.ID, condition, rule.Cooldown, state)
+}
+
+// parseUSDArg parses a positive USD amount, optionally prefixed with "$"
+func parseUSDArg(arg string) (float64, bool) {
+	value, err := strconv.ParseFloat(strings.TrimPrefix(arg, "$"), 64)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+	return value, true
+}
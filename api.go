@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"github.com/korjavin/uniswapfetcher/uniswap/filter"
+	"go.uber.org/zap"
+)
+
+// apiPathPrefix is where the REST API is served
+const apiPathPrefix = "/api/v1/"
+
+// minAPIKeyLength is the length API keys must have at least, so they can't be guessed
+const minAPIKeyLength = 16
+
+// APIServer serves the positions and pools the bot fetches as JSON to other services presenting
+// one of the configured API keys
+type APIServer struct {
+	uniswapClient uniswap.Client
+	keys          apiKeySet
+	logger        *zap.SugaredLogger
+}
+
+func NewAPIServer(uniswapClient uniswap.Client, apiKeys []string, logger *zap.SugaredLogger) *APIServer {
+	return &APIServer{uniswapClient: uniswapClient, keys: newAPIKeySet(apiKeys), logger: logger}
+}
+
+// apiKeySet holds the SHA-256 of each API key, so every key compares in constant time
+type apiKeySet [][sha256.Size]byte
+
+func newAPIKeySet(apiKeys []string) apiKeySet {
+	keys := make(apiKeySet, 0, len(apiKeys))
+	for _, key := range apiKeys {
+		keys = append(keys, sha256.Sum256([]byte(key)))
+	}
+	return keys
+}
+
+// valid reports whether key is one of the API keys
+func (s apiKeySet) valid(key string) bool {
+	if key == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(key))
+	valid := false
+	for _, k := range s {
+		if subtle.ConstantTimeCompare(sum[:], k[:]) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// Register adds the API endpoints to mux. They are documented in openapi.yaml.
+func (s *APIServer) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET "+apiPathPrefix+"openapi.yaml", handleOpenAPISpec)
+	mux.Handle("GET "+apiPathPrefix+"wallets/{address}/positions", s.authenticate(s.handleWalletPositions))
+	mux.Handle("GET "+apiPathPrefix+"pools/{id}", s.authenticate(s.handlePool))
+}
+
+// authenticate accepts requests with an API key in an "Authorization: Bearer" or X-API-Key header
+func (s *APIServer) authenticate(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			key = r.Header.Get("X-API-Key")
+		}
+		if !s.keys.valid(key) {
+			writeAPIError(w, http.StatusUnauthorized, "missing or invalid API key")
+			return
+		}
+		next(w, r)
+	})
+}
+
+type apiToken struct {
+	Address  string `json:"address"`
+	Symbol   string `json:"symbol"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// apiPosition is a position in the API. Token amounts are raw integer amounts and, like other
+// big numbers, strings so clients don't lose precision parsing them.
+type apiPosition struct {
+	ID             string   `json:"id"`
+	ChainID        uint64   `json:"chainId"`
+	Version        string   `json:"version"`
+	Owner          string   `json:"owner"`
+	Pool           string   `json:"pool,omitempty"`
+	Token0         apiToken `json:"token0"`
+	Token1         apiToken `json:"token1"`
+	FeeTier        uint32   `json:"feeTier"`
+	TickLower      int      `json:"tickLower"`
+	TickUpper      int      `json:"tickUpper"`
+	Liquidity      string   `json:"liquidity,omitempty"`
+	Amount0        string   `json:"amount0"`
+	Amount1        string   `json:"amount1"`
+	UnclaimedFees0 string   `json:"unclaimedFees0"`
+	UnclaimedFees1 string   `json:"unclaimedFees1"`
+	PriceLower     string   `json:"priceLower,omitempty"`
+	PriceUpper     string   `json:"priceUpper,omitempty"`
+	CurrentPrice   string   `json:"currentPrice,omitempty"`
+	InRange        bool     `json:"inRange"`
+}
+
+type apiPositionsResponse struct {
+	Wallet    string        `json:"wallet"`
+	Positions []apiPosition `json:"positions"`
+}
+
+type apiPool struct {
+	ID          string   `json:"id"`
+	Version     string   `json:"version"`
+	Token0      apiToken `json:"token0"`
+	Token1      apiToken `json:"token1"`
+	FeeTier     uint32   `json:"feeTier"`
+	Liquidity   string   `json:"liquidity"`
+	SqrtPrice   string   `json:"sqrtPrice"`
+	Tick        int      `json:"tick"`
+	Token0Price string   `json:"token0Price"`
+	Token1Price string   `json:"token1Price"`
+	TVLUSD      float64  `json:"tvlUSD"`
+	VolumeUSD   float64  `json:"volumeUSD"`
+	FeesUSD     float64  `json:"feesUSD"`
+}
+
+// handleWalletPositions lists a wallet's positions. ?version=v3 or v4 limits them to one version.
+func (s *APIServer) handleWalletPositions(w http.ResponseWriter, r *http.Request) {
+	address := r.PathValue("address")
+	req, err := parsePositionRequest(address, r.URL.Query().Get("version"))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filters, err := parsePositionFilters(r.URL.Query())
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+	defer cancel()
+
+	positions, err := s.uniswapClient.GetPositions(ctx, req)
+	if err != nil && !errors.Is(err, uniswap.ErrWalletHasNoPositions) && !errors.Is(err, uniswap.ErrPartialPositions) {
+		s.logger.Errorw("Failed to fetch positions", "wallet", address, "error", err)
+		switch {
+		case errors.Is(err, uniswap.ErrRateLimited):
+			writeAPIError(w, http.StatusServiceUnavailable, "rate limited by The Graph, try again later")
+		case errors.Is(err, uniswap.ErrSubgraphStale):
+			writeAPIError(w, http.StatusServiceUnavailable, "the subgraph is behind the chain, try again later")
+		default:
+			writeAPIError(w, http.StatusBadGateway, "failed to fetch positions")
+		}
+		return
+	}
+
+	if !filters.empty() {
+		var prices map[common.Address]float64
+		if filters.needsPrices() {
+			prices = priceTokens(ctx, s.uniswapClient, positions, s.logger)
+		}
+		positions = filter.Apply(positions, filters.filter(prices))
+	}
+
+	s.writeJSON(w, newAPIPositionsResponse(req, positions))
+}
+
+// handlePool shows a pool by the ID parsePoolID accepts
+func (s *APIServer) handlePool(w http.ResponseWriter, r *http.Request) {
+	source, ok := s.uniswapClient.(uniswap.PoolSource)
+	if !ok {
+		writeAPIError(w, http.StatusNotImplemented, "pools are not available")
+		return
+	}
+
+	id := r.PathValue("id")
+	version, err := parsePoolID(id)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+	defer cancel()
+
+	pool, err := source.GetPool(ctx, version, id)
+	if errors.Is(err, uniswap.ErrPoolNotFound) {
+		writeAPIError(w, http.StatusNotFound, "pool not found")
+		return
+	}
+	if err != nil {
+		s.logger.Errorw("Failed to fetch pool", "pool", id, "version", version, "error", err)
+		writeAPIError(w, http.StatusBadGateway, "failed to fetch pool")
+		return
+	}
+	s.writeJSON(w, newAPIPool(*pool))
+}
+
+// parsePositionRequest builds the request for a wallet's positions, limited to version if that
+// is v3 or v4
+func parsePositionRequest(address, version string) (uniswap.PositionRequest, error) {
+	if !common.IsHexAddress(address) {
+		return uniswap.PositionRequest{}, errors.New("invalid wallet address")
+	}
+	req := uniswap.PositionRequest{WalletAddress: common.HexToAddress(address), IncludeV3: true, IncludeV4: true, IncludeClosed: true}
+	switch strings.ToLower(version) {
+	case "":
+	case "v3":
+		req.IncludeV4 = false
+	case "v4":
+		req.IncludeV3 = false
+	default:
+		return uniswap.PositionRequest{}, errors.New("version must be v3 or v4")
+	}
+	return req, nil
+}
+
+// parsePoolID returns the version of the pool id identifies: V3 pools are identified by their
+// 20 byte address, V4 pools by their 32 byte ID
+func parsePoolID(id string) (uniswap.PositionVersion, error) {
+	switch {
+	case common.IsHexAddress(id):
+		return uniswap.VersionV3, nil
+	case len(id) == 2+2*common.HashLength && strings.HasPrefix(id, "0x") && isHex(id[2:]):
+		return uniswap.VersionV4, nil
+	default:
+		return "", errors.New("invalid pool ID, expected a V3 pool address or a V4 pool ID")
+	}
+}
+
+// newAPIPositionsResponse lists positions of the wallet the request was for
+func newAPIPositionsResponse(req uniswap.PositionRequest, positions []uniswap.Position) apiPositionsResponse {
+	resp := apiPositionsResponse{Wallet: req.WalletAddress.Hex(), Positions: make([]apiPosition, 0, len(positions))}
+	for _, pos := range positions {
+		resp.Positions = append(resp.Positions, newAPIPosition(pos))
+	}
+	return resp
+}
+
+func newAPIToken(t uniswap.Token) apiToken {
+	return apiToken{Address: t.Address.Hex(), Symbol: t.Symbol, Decimals: t.Decimals}
+}
+
+func newAPIPosition(pos uniswap.Position) apiPosition {
+	return apiPosition{
+		ID:             bigIntString(pos.ID),
+		ChainID:        uint64(pos.Chain()),
+		Version:        string(pos.Version),
+		Owner:          pos.Owner.Hex(),
+		Token0:         newAPIToken(pos.Token0),
+		Token1:         newAPIToken(pos.Token1),
+		FeeTier:        pos.FeeTier,
+		TickLower:      pos.TickLower,
+		TickUpper:      pos.TickUpper,
+		Liquidity:      bigIntString(pos.Liquidity),
+		Amount0:        bigIntString(pos.Amount0),
+		Amount1:        bigIntString(pos.Amount1),
+		UnclaimedFees0: bigIntString(pos.UnclaimedFees0),
+		UnclaimedFees1: bigIntString(pos.UnclaimedFees1),
+		PriceLower:     bigFloatString(pos.PriceLower),
+		PriceUpper:     bigFloatString(pos.PriceUpper),
+		CurrentPrice:   bigFloatString(pos.CurrentPrice),
+		InRange:        pos.InRange(),
+		Pool:           pos.PoolKey(),
+	}
+}
+
+func newAPIPool(pool uniswap.Pool) apiPool {
+	return apiPool{
+		ID:          pool.ID,
+		Version:     string(pool.Version),
+		Token0:      newAPIToken(pool.Token0),
+		Token1:      newAPIToken(pool.Token1),
+		FeeTier:     pool.FeeTier,
+		Liquidity:   bigIntString(pool.Liquidity),
+		SqrtPrice:   bigIntString(pool.SqrtPrice),
+		Tick:        pool.Tick,
+		Token0Price: bigFloatString(pool.Token0Price),
+		Token1Price: bigFloatString(pool.Token1Price),
+		TVLUSD:      pool.TVLUSD,
+		VolumeUSD:   pool.VolumeUSD,
+		FeesUSD:     pool.FeesUSD,
+	}
+}
+
+// bigFloatString formats x in decimal notation, or returns "" if x is unknown
+func bigFloatString(x *big.Float) string {
+	if x == nil {
+		return ""
+	}
+	return x.Text('f', -1)
+}
+
+// isHex reports whether s consists of hex digits only
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *APIServer) writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Warnw("Failed to write API response", "error", err)
+	}
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func writeAPIError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiError{Error: msg})
+}
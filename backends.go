@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"go.uber.org/zap"
+)
+
+// Backend names selectable in BACKENDS
+const (
+	backendSubgraph = "subgraph"
+	backendFixtures = "fixtures"
+)
+
+// backend is a source of positions the bot can be configured to fetch from
+type backend interface {
+	uniswap.Client
+	uniswap.HealthChecker
+}
+
+// backendDeps is what backends are created from
+type backendDeps struct {
+	cfg    Config
+	logger *zap.SugaredLogger
+	// subgraph is the client querying The Graph, which also serves ENS names, prices and swaps
+	subgraph *uniswap.APIClient
+}
+
+// backendFactory creates a backend from the bot's configuration
+type backendFactory func(deps backendDeps) (backend, error)
+
+// backendFactories are the backends that can be named in BACKENDS
+var backendFactories = make(map[string]backendFactory)
+
+// registerBackend makes the backend factory creates selectable in BACKENDS as name. A new data
+// source registers itself from an init function in its own file, without changes to main.
+func registerBackend(name string, factory backendFactory) {
+	if _, ok := backendFactories[name]; ok {
+		panic("backend registered twice: " + name)
+	}
+	backendFactories[name] = factory
+}
+
+// backendNames returns the names of the registered backends, sorted
+func backendNames() []string {
+	names := make([]string, 0, len(backendFactories))
+	for name := range backendFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	registerBackend(backendSubgraph, func(deps backendDeps) (backend, error) {
+		return deps.subgraph, nil
+	})
+	registerBackend(backendFixtures, func(deps backendDeps) (backend, error) {
+		if deps.cfg.FixturesDir == "" {
+			return nil, errors.New("FIXTURES_DIR is required")
+		}
+		return uniswap.NewFixtureClient(deps.logger.Named(backendFixtures), deps.cfg.FixturesDir)
+	})
+}
+
+// newBackend creates the backends named, in order. With more than one, each request goes to
+// the first and only falls back to the next if it fails.
+func newBackend(names []string, deps backendDeps) (backend, error) {
+	backends := make([]backend, 0, len(names))
+	for _, name := range names {
+		factory, ok := backendFactories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown backend %q", name)
+		}
+		b, err := factory(deps)
+		if err != nil {
+			return nil, fmt.Errorf("backend %s: %w", name, err)
+		}
+		backends = append(backends, b)
+	}
+	if len(backends) == 1 {
+		return backends[0], nil
+	}
+	return &fallbackBackend{names: names, backends: backends, logger: deps.logger.Named("backends")}, nil
+}
+
+// fallbackBackend asks its backends in order until one answers
+type fallbackBackend struct {
+	names    []string
+	backends []backend
+	logger   *zap.SugaredLogger
+}
+
+// GetPositions asks the next backend only if one fails. A wallet without positions in one has
+// none, rather than a failure. If one only fetches some versions, the next is asked for all of
+// them, and the partial positions are returned if no backend fetches more.
+func (f *fallbackBackend) GetPositions(ctx context.Context, req uniswap.PositionRequest) ([]uniswap.Position, error) {
+	var errs []error
+	var partial []uniswap.Position
+	var partialErr error
+	for i, b := range f.backends {
+		positions, err := b.GetPositions(ctx, req)
+		if err == nil || errors.Is(err, uniswap.ErrWalletHasNoPositions) {
+			return positions, err
+		}
+		requestLogger(ctx, f.logger).Warnw("Backend failed to get positions, falling back", "backend", f.names[i], "error", err)
+		if errors.Is(err, uniswap.ErrPartialPositions) && partialErr == nil {
+			partial, partialErr = positions, fmt.Errorf("%s: %w", f.names[i], err)
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", f.names[i], err))
+	}
+	if partialErr != nil {
+		return partial, partialErr
+	}
+	return nil, errors.Join(errs...)
+}
+
+// GetPosition asks the next backend only if one fails. A position one doesn't know is not
+// found, rather than a failure.
+func (f *fallbackBackend) GetPosition(ctx context.Context, version uniswap.PositionVersion, id *big.Int) (*uniswap.Position, error) {
+	var errs []error
+	for i, b := range f.backends {
+		position, err := b.GetPosition(ctx, version, id)
+		if err == nil || errors.Is(err, uniswap.ErrPositionNotFound) {
+			return position, err
+		}
+		requestLogger(ctx, f.logger).Warnw("Backend failed to get position, falling back", "backend", f.names[i], "error", err)
+		errs = append(errs, fmt.Errorf("%s: %w", f.names[i], err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// CheckHealth reports the backends healthy as long as one of them is, since requests fall back to it
+func (f *fallbackBackend) CheckHealth(ctx context.Context) error {
+	var errs []error
+	for i, b := range f.backends {
+		err := b.CheckHealth(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", f.names[i], err))
+	}
+	return errors.Join(errs...)
+}
+
+func (f *fallbackBackend) Close() {
+	for _, b := range f.backends {
+		b.Close()
+	}
+}
+
+// validateBackends checks that names are registered backends, each named once
+func validateBackends(names []string) error {
+	if len(names) == 0 {
+		return errors.New("BACKENDS must name at least one backend")
+	}
+	var errs []error
+	for i, name := range names {
+		if _, ok := backendFactories[name]; !ok {
+			errs = append(errs, fmt.Errorf("BACKENDS: unknown backend %q, available are %v", name, backendNames()))
+		}
+		if slices.Index(names, name) != i {
+			errs = append(errs, fmt.Errorf("BACKENDS: %s is listed twice", name))
+		}
+	}
+	return errors.Join(errs...)
+}
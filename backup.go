@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// handleBackup sends a snapshot of the whole database to a bot administrator
+func (h *BotHandlers) handleBackup(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received backup command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	if !h.admins[ctx.EffectiveUser.Id] {
+		_, err := ctx.EffectiveMessage.Reply(b, "Only the bot's administrators can create backups.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+	// Backups hold every chat's data, so they must not end up in a group
+	if ctx.EffectiveChat.Type != gotgbot.ChatTypePrivate {
+		_, err := ctx.EffectiveMessage.Reply(b, "Please ask for backups in a private chat with the bot.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "uniswapfetcher-backup")
+	if err != nil {
+		h.log(ctx).Errorw("Failed to create backup directory", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to create backup. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, fmt.Sprintf("uniswapfetcher-%s.db", time.Now().UTC().Format("20060102-150405")))
+	if err := h.db.Backup(reqCtx, path); err != nil {
+		h.log(ctx).Errorw("Failed to back up database", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to create backup. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to open backup", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to create backup. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+	defer file.Close()
+
+	h.log(ctx).Infow("Sending database backup", "user_id", ctx.EffectiveUser.Id, "file", filepath.Base(path))
+	_, err = b.SendDocument(ctx.EffectiveChat.Id, gotgbot.NamedFile{
+		File:     file,
+		FileName: filepath.Base(path),
+	}, &gotgbot.SendDocumentOpts{
+		Caption:         "Database backup. To restore it, start the bot with RESTORE_FROM set to this file.",
+		ReplyParameters: &gotgbot.ReplyParameters{MessageId: ctx.EffectiveMessage.MessageId},
+	})
+	return err
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"go.uber.org/zap"
+)
+
+// chatBotGroup is the dispatcher group of the handler recording which bot each chat talks to.
+// It runs after the correlation ID is assigned and before the access guard.
+const chatBotGroup = -2
+
+// defaultTelegramTimeout bounds a single Bot API request unless TELEGRAM_TIMEOUT says otherwise
+const defaultTelegramTimeout = 60 * time.Second
+
+// botSet is the bots the process serves with the same handlers and storage, e.g. a production
+// bot and a beta bot. The first is the primary bot.
+type botSet struct {
+	bots []*gotgbot.Bot
+	byID map[int64]*gotgbot.Bot
+}
+
+func newBotSet(bots []*gotgbot.Bot) *botSet {
+	s := &botSet{bots: bots, byID: make(map[int64]*gotgbot.Bot, len(bots))}
+	for _, bot := range bots {
+		s.byID[bot.Id] = bot
+	}
+	return s
+}
+
+// Primary returns the bot chats are served by unless they talked to another one
+func (s *botSet) Primary() *gotgbot.Bot {
+	return s.bots[0]
+}
+
+// Get returns the bot with ID id, or the primary bot if the process doesn't serve it (anymore)
+func (s *botSet) Get(id int64) *gotgbot.Bot {
+	if bot, ok := s.byID[id]; ok {
+		return bot
+	}
+	return s.Primary()
+}
+
+// chatBotTracker records which bot each chat last talked to, so notifications reach the chat
+// through the bot it uses. Only changes are written, which the tracker keeps in memory to tell.
+type chatBotTracker struct {
+	db     ChatBotStore
+	logger *zap.SugaredLogger
+
+	mu    sync.Mutex
+	known map[int64]int64
+}
+
+func newChatBotTracker(db ChatBotStore, logger *zap.SugaredLogger) *chatBotTracker {
+	return &chatBotTracker{db: db, logger: logger, known: make(map[int64]int64)}
+}
+
+func (t *chatBotTracker) Name() string {
+	return "chat_bot"
+}
+
+func (t *chatBotTracker) CheckUpdate(b *gotgbot.Bot, ctx *ext.Context) bool {
+	return ctx.EffectiveChat != nil
+}
+
+func (t *chatBotTracker) HandleUpdate(b *gotgbot.Bot, ctx *ext.Context) error {
+	chatID := ctx.EffectiveChat.Id
+	t.mu.Lock()
+	known, ok := t.known[chatID]
+	t.mu.Unlock()
+	if ok && known == b.Id {
+		return ext.ContinueGroups
+	}
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+	if err := t.db.SetChatBot(reqCtx, chatID, b.Id); err != nil {
+		updateLogger(ctx, t.logger).Warnw("Failed to record the chat's bot", "chat_id", chatID, "bot", b.Username, "error", err)
+		return ext.ContinueGroups
+	}
+	t.mu.Lock()
+	t.known[chatID] = b.Id
+	t.mu.Unlock()
+	return ext.ContinueGroups
+}
+
+// newBots creates a bot for each token, each sending through its own client and rate limits.
+// Bot API requests time out after timeout.
+func newBots(tokens []string, dryRun bool, timeout time.Duration, logger *zap.SugaredLogger) ([]*gotgbot.Bot, error) {
+	bots := make([]*gotgbot.Bot, 0, len(tokens))
+	for _, token := range tokens {
+		requestOpts := &gotgbot.RequestOpts{Timeout: timeout}
+		botOpts := &gotgbot.BotOpts{
+			BotClient:   &gotgbot.BaseBotClient{DefaultRequestOpts: requestOpts},
+			RequestOpts: requestOpts,
+		}
+		if dryRun {
+			// Log messages instead of sending them, so nobody hears from a bot under test
+			botOpts.BotClient = newDryRunBotClient(botOpts.BotClient, logger.Named("dryrun"))
+		}
+		// Keep everything sent through the bot within Telegram's rate limits
+		botOpts.BotClient = newSendLimiter(botOpts.BotClient, logger.Named("telegram"))
+		bot, err := gotgbot.NewBot(token, botOpts)
+		if err != nil {
+			return nil, err
+		}
+		bots = append(bots, bot)
+	}
+	return bots, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// The build the bot was made from, set when building, e.g.
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse HEAD) -X main.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Without them, the commit and date are taken from what the Go toolchain records about the
+// repository built in, if anything.
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// BuildInfo describes the build of the running bot, so bug reports can be tied to it
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+	// Modified is set when the build had uncommitted changes, as recorded by the Go toolchain
+	Modified bool `json:"modified,omitempty"`
+}
+
+// currentBuildInfo returns the build info set when building, completed from the Go toolchain's
+func currentBuildInfo() BuildInfo {
+	info := BuildInfo{Version: version, Commit: commit, BuildDate: buildDate, GoVersion: runtime.Version()}
+	goInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range goInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildDate == "" {
+				info.BuildDate = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+// String formats the build info for people, e.g. "v1.2.0 (commit 1a2b3c4d, built 2024-05-01T10:00:00Z, go1.23.4)"
+func (i BuildInfo) String() string {
+	s := i.Version + " ("
+	if i.Commit != "" {
+		commit := i.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		s += "commit " + commit
+		if i.Modified {
+			s += " with local changes"
+		}
+		s += ", "
+	}
+	if i.BuildDate != "" {
+		s += "built " + i.BuildDate + ", "
+	}
+	return s + i.GoVersion + ")"
+}
+
+func (h *BotHandlers) handleVersion(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received version command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	_, err := ctx.EffectiveMessage.Reply(b, fmt.Sprintf("Version %s\n\nPlease include this when reporting a bug.", currentBuildInfo()), &gotgbot.SendMessageOpts{})
+	return err
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+const (
+	// maxImportFileSize is the largest text/CSV file accepted by /add_wallet
+	maxImportFileSize = 256 * 1024
+	// maxImportAddresses caps how many wallets a single /add_wallet can add
+	maxImportAddresses = 100
+)
+
+// importResult is the outcome of adding a single address of a bulk import
+type importResult struct {
+	input  string
+	reason string // empty on success
+}
+
+// addWallets validates and adds every address, reporting the outcome per address in a single reply
+func (h *BotHandlers) addWallets(reqCtx context.Context, b *gotgbot.Bot, ctx *ext.Context, inputs []string, version string) error {
+	if len(inputs) > maxImportAddresses {
+		msg := fmt.Sprintf("You can add at most %d wallets at once, got %d.", maxImportAddresses, len(inputs))
+		_, err := ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	existing, err := h.db.GetChatWallets(reqCtx, ctx.EffectiveChat.Id)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get wallets", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to add wallets. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+	tracked := make(map[string]bool, len(existing))
+	for _, wallet := range existing {
+		tracked[wallet.WalletAddress] = true
+	}
+
+	// Either every wallet that can be added is, or none if saving fails midway
+	var added, failed []importResult
+	err = h.db.WithTx(reqCtx, func(tx Store) error {
+		for _, input := range inputs {
+			address, err := parseWalletAddress(input)
+			if err != nil {
+				failed = append(failed, importResult{input: input, reason: "not a valid address"})
+				continue
+			}
+
+			normalizedAddress := address.Hex()
+			if tracked[normalizedAddress] {
+				failed = append(failed, importResult{input: input, reason: "already tracked"})
+				continue
+			}
+
+			ok, err := tx.AddWallet(reqCtx, ctx.EffectiveChat.Id, ctx.EffectiveUser.Id, normalizedAddress, version)
+			var limitErr *WalletLimitError
+			if errors.As(err, &limitErr) {
+				failed = append(failed, importResult{input: input, reason: fmt.Sprintf("limit of %d wallets reached", limitErr.Limit)})
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to add wallet %s: %w", normalizedAddress, err)
+			}
+			tracked[normalizedAddress] = true
+			if !ok {
+				failed = append(failed, importResult{input: input, reason: "already tracked"})
+				continue
+			}
+			added = append(added, importResult{input: normalizedAddress})
+		}
+		return nil
+	})
+	if err != nil {
+		h.log(ctx).Errorw("Failed to import wallets", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to add wallets, none were added. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	h.log(ctx).Infow("Imported wallets", "chat_id", ctx.EffectiveChat.Id, "added", len(added), "failed", len(failed))
+
+	_, err = ctx.EffectiveMessage.Reply(b, formatImportResults(added, failed), &gotgbot.SendMessageOpts{})
+	return err
+}
+
+func formatImportResults(added, failed []importResult) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Added %d of %d wallets.\n", len(added), len(added)+len(failed)))
+
+	for _, result := range added {
+		sb.WriteString(fmt.Sprintf("\n✓ %s", result.input))
+	}
+	for _, result := range failed {
+		sb.WriteString(fmt.Sprintf("\n✗ %s: %s", result.input, result.reason))
+	}
+	return sb.String()
+}
+
+// importDocument returns the attached text/CSV file of the command message, or of the message it replies to
+func importDocument(msg *gotgbot.Message) *gotgbot.Document {
+	if msg.Document != nil {
+		return msg.Document
+	}
+	if msg.ReplyToMessage != nil {
+		return msg.ReplyToMessage.Document
+	}
+	return nil
+}
+
+// downloadImportFile fetches an uploaded file and extracts every address-looking cell from it
+func downloadImportFile(b *gotgbot.Bot, doc *gotgbot.Document) ([]string, error) {
+	if doc.FileSize > maxImportFileSize {
+		return nil, fmt.Errorf("file is %d bytes, the limit is %d", doc.FileSize, maxImportFileSize)
+	}
+
+	file, err := b.GetFile(doc.FileId, &gotgbot.GetFileOpts{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.URL(b, nil), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code downloading file: %d", resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxImportFileSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return parseImportText(string(content)), nil
+}
+
+// parseImportText splits text exported from a spreadsheet into cells and keeps the ones that look
+// like addresses, so headers, labels and other columns are skipped.
+func parseImportText(text string) []string {
+	cells := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\t' || r == '\n' || r == '\r' || r == ' '
+	})
+
+	var addresses []string
+	for _, cell := range cells {
+		cell = strings.Trim(cell, `"'`)
+		if strings.HasPrefix(strings.ToLower(cell), "0x") {
+			addresses = append(addresses, cell)
+		}
+	}
+	return addresses
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"time"
+)
+
+// Chart geometry in pixels
+const (
+	chartWidth   = 800
+	chartHeight  = 400
+	chartPadding = 20
+)
+
+var (
+	chartBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	chartGrid       = color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+	chartLine       = color.RGBA{0xff, 0x00, 0x7a, 0xff}
+	chartFill       = color.RGBA{0xff, 0xd6, 0xea, 0xff}
+)
+
+// chartPoint is a value at a point in time
+type chartPoint struct {
+	at    time.Time
+	value float64
+}
+
+// renderStepChart draws points between from and to as a filled step line, holding each value
+// until the next point. Axis labels are left to the caption, as rendering text would pull in fonts.
+func renderStepChart(points []chartPoint, from, to time.Time) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	for y := 0; y < chartHeight; y++ {
+		for x := 0; x < chartWidth; x++ {
+			img.Set(x, y, chartBackground)
+		}
+	}
+
+	left, right := chartPadding, chartWidth-chartPadding
+	top, bottom := chartPadding, chartHeight-chartPadding
+
+	// Horizontal grid lines at quarters of the value range
+	for i := 0; i <= 4; i++ {
+		y := top + (bottom-top)*i/4
+		for x := left; x <= right; x++ {
+			img.Set(x, y, chartGrid)
+		}
+	}
+
+	maxValue := 0.0
+	for _, p := range points {
+		if p.value > maxValue {
+			maxValue = p.value
+		}
+	}
+
+	span := to.Sub(from)
+	valueAt := func(x int) float64 {
+		at := from.Add(time.Duration(float64(span) * float64(x-left) / float64(right-left)))
+		value := 0.0
+		for _, p := range points {
+			if p.at.After(at) {
+				break
+			}
+			value = p.value
+		}
+		return value
+	}
+	yFor := func(value float64) int {
+		if maxValue <= 0 {
+			return bottom
+		}
+		return bottom - int(value/maxValue*float64(bottom-top))
+	}
+
+	prevY := yFor(valueAt(left))
+	for x := left; x <= right; x++ {
+		y := yFor(valueAt(x))
+		for fy := y + 1; fy < bottom; fy++ {
+			img.Set(x, fy, chartFill)
+		}
+
+		// Connect steps with a vertical segment, drawn two pixels wide for legibility
+		lo, hi := prevY, y
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		for ly := lo; ly <= hi; ly++ {
+			img.Set(x, ly, chartLine)
+			img.Set(x, ly+1, chartLine)
+		}
+		prevY = y
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
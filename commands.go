@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+)
+
+// Command categories, in the order /help lists them
+const (
+	categoryTracking  = "Tracking"
+	categoryAlerts    = "Alerts"
+	categoryAnalytics = "Analytics"
+	categorySettings  = "Settings"
+)
+
+var commandCategories = []string{categoryTracking, categoryAlerts, categoryAnalytics, categorySettings}
+
+// botCommand describes a command for registration, /help and the BotFather command list
+type botCommand struct {
+	name        string
+	category    string
+	usage       string // arguments, e.g. "<address> [v3|v4]"
+	description string
+	example     string // optional full example invocation
+	aliases     []string
+	// handler is nil for commands registered elsewhere, e.g. by the onboarding conversation
+	handler handlers.Response
+}
+
+// commands is the registry of every command the bot understands. New commands only need
+// an entry here to be registered, listed in /help and synced to BotFather.
+func (h *BotHandlers) commands() []botCommand {
+	return []botCommand{
+		{name: "start", category: categorySettings, description: "Show the welcome message"},
+		{name: "setup", category: categorySettings, description: "Guided setup"},
+		{name: "cancel", category: categorySettings, description: "Abort the guided setup"},
+		{name: "help", category: categorySettings, description: "Show this help", handler: h.handleHelp},
+		{name: "version", category: categorySettings, description: "Show the bot's version, for bug reports", handler: h.handleVersion},
+		{name: "settings", category: categorySettings, description: "Show and change settings", handler: h.handleSettings},
+		{name: "preferences", category: categorySettings, usage: "[name value]", description: "Show and change your personal preferences", example: "/preferences timezone Europe/Berlin", aliases: []string{"prefs"}, handler: h.handlePreferences},
+		{name: "backup", category: categorySettings, description: "Get a database backup (bot administrators only)", handler: h.handleBackup},
+		{name: "admin_stats", category: categorySettings, description: "Show usage statistics (bot administrators only)", handler: h.handleAdminStats},
+		{name: "reload_registry", category: categorySettings, description: "Reload the chains and known tokens (bot administrators only)", handler: h.handleReloadRegistry},
+		{name: "set_tier", category: categorySettings, usage: "<user id> <free|premium>", description: "Change a user's tier (bot administrators only)", handler: h.handleSetTier},
+		{name: "delete_me", category: categorySettings, description: "Delete all data stored about you", handler: h.handleDeleteMe},
+
+		{name: "add_wallet", category: categoryTracking, usage: "<address>... [v3|v4]", description: "Add wallets to track (or upload a CSV)", example: "/add_wallet 0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045", aliases: []string{"add"}, handler: h.handleAddWallet},
+		{name: "remove_wallet", category: categoryTracking, usage: "<address>", description: "Remove wallet", aliases: []string{"rm"}, handler: h.handleRemoveWallet},
+		{name: "list_wallets", category: categoryTracking, description: "Show tracked wallets", aliases: []string{"ls", "wallets"}, handler: h.handleListWallets},
+		{name: "label", category: categoryTracking, usage: "<address> [label]", description: "Name a wallet", example: "/label 0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045 Treasury", handler: h.handleLabel},
+		{name: "track_position", category: categoryTracking, usage: "<id> [v3|v4]", description: "Follow a single position", example: "/track_position 12345 v3", aliases: []string{"track"}, handler: h.handleTrackPosition},
+		{name: "untrack_position", category: categoryTracking, usage: "<id> [v3|v4]", description: "Stop following a position", aliases: []string{"untrack"}, handler: h.handleUntrackPosition},
+		{name: "status", category: categoryTracking, usage: "[address|ENS]", description: "Show positions status", example: "/status vitalik.eth", handler: h.handleStatus},
+		{name: "share", category: categoryTracking, usage: "[address]", description: "Get a read-only link to a wallet's positions", handler: h.handleShare},
+		{name: "unshare", category: categoryTracking, usage: "[address]", description: "Revoke share links", handler: h.handleUnshare},
+
+		{name: "alerts", category: categoryAlerts, usage: "[add|set|on|off|delete]", description: "Manage alerts on single positions", example: "/alerts add fees 12345 500", handler: h.handleAlerts},
+		{name: "swap_alerts", category: categoryAlerts, usage: "<usd|off>", description: "Alert on large swaps in your pools", example: "/swap_alerts 100000", handler: h.handleSwapAlerts},
+
+		{name: "fees", category: categoryAnalytics, usage: "[filters]", description: "Show collected fees per position", example: "/fees in_range token=WETH $1000", handler: h.handleFees},
+		{name: "dashboard", category: categoryAnalytics, description: "Open the dashboard Mini App", handler: h.handleDashboard},
+		{name: "compare", category: categoryAnalytics, usage: "<a> <b>", description: "Compare two wallets or positions", example: "/compare 12345 67890", handler: h.handleCompare},
+		{name: "chart_fees", category: categoryAnalytics, usage: "<id> [30|90]", description: "Chart the fees a position earned", example: "/chart_fees 12345 90", handler: h.handleChartFees},
+		{name: "pools", category: categoryAnalytics, description: "Show your pools' volume, fees and APR", handler: h.handlePools},
+		{name: "position", category: categoryAnalytics, usage: "<id> [v3|v4] [image]", description: "Show a position, with its NFT image", example: "/position 12345 image", handler: h.handlePosition},
+		{name: "activity", category: categoryAnalytics, usage: "<id> [v3|v4]", description: "Show a position's history", example: "/activity 12345", handler: h.handleActivity},
+	}
+}
+
+func (h *BotHandlers) handleHelp(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received help command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	_, err := ctx.EffectiveMessage.Reply(b, formatHelp(h.commands(), true), &gotgbot.SendMessageOpts{})
+	return err
+}
+
+// formatHelp lists the commands grouped by category, optionally with their examples
+func formatHelp(commands []botCommand, examples bool) string {
+	var sb strings.Builder
+	for i, category := range commandCategories {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(category + "\n")
+		for _, c := range commands {
+			if c.category != category {
+				continue
+			}
+			sb.WriteString("/" + c.name)
+			if c.usage != "" {
+				sb.WriteString(" " + c.usage)
+			}
+			sb.WriteString(" - " + c.description)
+			for _, alias := range c.aliases {
+				sb.WriteString(", also /" + alias)
+			}
+			sb.WriteString("\n")
+			if examples && c.example != "" {
+				sb.WriteString(fmt.Sprintf("   e.g. %s\n", c.example))
+			}
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// syncBotCommands publishes the command registry as the bot's command list, which Telegram
+// shows in the command menu and as suggestions when typing "/".
+func (h *BotHandlers) syncBotCommands(b *gotgbot.Bot) error {
+	var commands []gotgbot.BotCommand
+	for _, c := range h.commands() {
+		description := c.description
+		if c.usage != "" {
+			description += " " + c.usage
+		}
+		commands = append(commands, gotgbot.BotCommand{Command: c.name, Description: description})
+	}
+
+	_, err := b.SetMyCommands(commands, &gotgbot.SetMyCommandsOpts{})
+	return err
+}
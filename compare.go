@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+)
+
+const compareUsage = "Usage: /compare <address> <address> or /compare <position id> <position id> [v3|v4]"
+
+// compareColumn holds the figures shown for one side of a comparison
+type compareColumn struct {
+	label     string
+	positions []uniswap.Position
+	prices    map[common.Address]float64
+	accruals  map[string]feeAccrual
+}
+
+func (h *BotHandlers) handleCompare(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received compare command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	args := ctx.Args()
+	if len(args) < 3 {
+		_, err := ctx.EffectiveMessage.Reply(b, compareUsage, &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	statusMsg, err := ctx.EffectiveMessage.Reply(b, "Fetching positions...", &gotgbot.SendMessageOpts{})
+	if err != nil {
+		return err
+	}
+
+	bgCtx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	var columns [2]*compareColumn
+	var msg string
+	if strings.HasPrefix(args[1], "0x") {
+		columns, msg = h.compareWallets(bgCtx, ctx.EffectiveChat.Id, args[1], args[2])
+	} else {
+		columns, msg = h.comparePositions(bgCtx, args[1:])
+	}
+
+	opts := &gotgbot.EditMessageTextOpts{}
+	if msg == "" {
+		for _, column := range columns {
+			column.prices = priceTokens(bgCtx, h.uniswapClient, column.positions, h.logger)
+			column.accruals = fetchFeeAccrual(bgCtx, h.db, h.logger, column.positions, time.Now().Add(-aprWindow))
+		}
+		msg = formatComparison(columns)
+		opts.ParseMode = gotgbot.ParseModeHTML
+	}
+
+	_, _, err = statusMsg.EditText(b, msg, opts)
+	return err
+}
+
+// compareWallets fetches the positions of both wallets. On failure it returns a message for the user instead.
+func (h *BotHandlers) compareWallets(ctx context.Context, chatID int64, a, b string) ([2]*compareColumn, string) {
+	var columns [2]*compareColumn
+
+	settings, err := h.db.GetChatSettings(ctx, chatID)
+	if err != nil {
+		requestLogger(ctx, h.logger).Errorw("Failed to get chat settings", "error", err)
+		settings = DefaultChatSettings
+	}
+
+	for i, input := range []string{a, b} {
+		wallet, err := parseWalletAddress(input)
+		if err != nil {
+			return columns, err.Error()
+		}
+
+		lookupCtx, cancel := newLookupContext(ctx)
+		positions, err := h.uniswapClient.GetPositions(lookupCtx, uniswap.PositionRequest{
+			WalletAddress: wallet,
+			IncludeV3:     settings.IncludeV3,
+			IncludeV4:     settings.IncludeV4,
+			IncludeClosed: true,
+		})
+		cancel()
+		if err != nil && !errors.Is(err, uniswap.ErrWalletHasNoPositions) && !errors.Is(err, uniswap.ErrPartialPositions) {
+			requestLogger(ctx, h.logger).Errorw("Failed to fetch positions", "wallet", wallet.Hex(), "error", err)
+			return columns, fetchErrorMessage(err)
+		}
+		columns[i] = &compareColumn{label: shortAddress(wallet.Hex()), positions: positions}
+	}
+	return columns, ""
+}
+
+// comparePositions fetches both positions given as "<id> <id> [v3|v4]". On failure it returns a message for the user instead.
+func (h *BotHandlers) comparePositions(ctx context.Context, args []string) ([2]*compareColumn, string) {
+	var columns [2]*compareColumn
+
+	for i, idArg := range args[:2] {
+		positionArgs := append([]string{idArg}, args[2:]...)
+		id, versions, ok := parsePositionArgs(positionArgs)
+		if !ok {
+			return columns, compareUsage
+		}
+
+		var pos *uniswap.Position
+		for _, version := range versions {
+			p, err := h.uniswapClient.GetPosition(ctx, version, id)
+			if errors.Is(err, uniswap.ErrPositionNotFound) {
+				continue
+			}
+			if err != nil {
+				requestLogger(ctx, h.logger).Errorw("Failed to fetch position", "position_id", id.String(), "version", version, "error", err)
+				return columns, "Failed to look up position. Please try again later."
+			}
+			pos = p
+			break
+		}
+		if pos == nil {
+			return columns, fmt.Sprintf("Position %s not found.", id.String())
+		}
+		columns[i] = &compareColumn{label: "#" + pos.ID.String(), positions: []uniswap.Position{*pos}}
+	}
+	return columns, ""
+}
+
+// valueUSD returns the USD value of the liquidity and of the fees collected by the column's positions.
+// It returns false if any token could not be priced.
+func (c *compareColumn) valueUSD() (value, fees, apr float64, ok bool) {
+	var annualized, annualizedValue float64
+	for _, pos := range c.positions {
+		price0, ok0 := c.prices[pos.Token0.Address]
+		price1, ok1 := c.prices[pos.Token1.Address]
+		if !ok0 || !ok1 {
+			return 0, 0, 0, false
+		}
+
+		posValue := uniswap.TokenAmountUSD(pos.Amount0, pos.Token0, price0) + uniswap.TokenAmountUSD(pos.Amount1, pos.Token1, price1)
+		value += posValue
+		fees += uniswap.TokenAmountUSD(pos.UnclaimedFees0, pos.Token0, price0) + uniswap.TokenAmountUSD(pos.UnclaimedFees1, pos.Token1, price1)
+
+		var accrual *feeAccrual
+		if a, ok := c.accruals[uniswap.PositionKey(pos)]; ok {
+			accrual = &a
+		}
+		if posFees, ok := annualizedFeesUSD(pos, c.prices, accrual); ok {
+			annualized += posFees
+			annualizedValue += posValue
+		}
+	}
+
+	apr = math.NaN()
+	if annualizedValue > 0 {
+		apr = annualized / annualizedValue * 100
+	}
+	return value, fees, apr, true
+}
+
+// rangeWidth returns the average width of the positions' price ranges as a percentage of the lower bound
+func (c *compareColumn) rangeWidth() float64 {
+	var total float64
+	var n int
+	for _, pos := range c.positions {
+		if pos.Width() <= 0 {
+			continue
+		}
+		total += (math.Pow(1.0001, float64(pos.Width())) - 1) * 100
+		n++
+	}
+	if n == 0 {
+		return math.NaN()
+	}
+	return total / float64(n)
+}
+
+// inRange counts the positions whose range currently contains the pool price
+func (c *compareColumn) inRange() int {
+	var n int
+	for _, pos := range c.positions {
+		if pos.InRange() {
+			n++
+		}
+	}
+	return n
+}
+
+// formatComparison renders both columns side by side as a monospaced HTML table
+func formatComparison(columns [2]*compareColumn) string {
+	rows := [][3]string{{"", columns[0].label, columns[1].label}}
+	add := func(name string, value func(c *compareColumn) string) {
+		rows = append(rows, [3]string{name, value(columns[0]), value(columns[1])})
+	}
+
+	if len(columns[0].positions) != 1 || len(columns[1].positions) != 1 {
+		add("Positions", func(c *compareColumn) string { return fmt.Sprintf("%d", len(c.positions)) })
+	} else {
+		add("Pair", func(c *compareColumn) string { return c.positions[0].Pair() })
+	}
+	add("Value", func(c *compareColumn) string {
+		value, _, _, ok := c.valueUSD()
+		return formatUSD(value, ok)
+	})
+	add("Fees", func(c *compareColumn) string {
+		_, fees, _, ok := c.valueUSD()
+		return formatUSD(fees, ok)
+	})
+	add("APR", func(c *compareColumn) string {
+		_, _, apr, ok := c.valueUSD()
+		if !ok || math.IsNaN(apr) {
+			return "n/a"
+		}
+		return fmt.Sprintf("%.1f%%", apr)
+	})
+	add("Range", func(c *compareColumn) string {
+		width := c.rangeWidth()
+		if math.IsNaN(width) {
+			return "n/a"
+		}
+		return fmt.Sprintf("%.1f%%", width)
+	})
+	add("In range", func(c *compareColumn) string {
+		return fmt.Sprintf("%d/%d", c.inRange(), len(c.positions))
+	})
+
+	var widths [3]int
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := len([]rune(cell)); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<pre>")
+	for _, row := range rows {
+		line := fmt.Sprintf("%-*s  %*s  %*s", widths[0], row[0], widths[1], row[1], widths[2], row[2])
+		sb.WriteString(html.EscapeString(strings.TrimRight(line, " ")))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("</pre>")
+	sb.WriteString("Fees are those collected so far. APR annualizes the fees collected over the last 7 days, or over a position's age until the bot has watched it for a while. In range shows the current price only.")
+	return sb.String()
+}
+
+func formatUSD(value float64, ok bool) string {
+	if !ok {
+		return "n/a"
+	}
+	return fmt.Sprintf("$%.2f", value)
+}
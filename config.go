@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileEnv names the environment variable pointing at an optional YAML configuration file
+const configFileEnv = "CONFIG_FILE"
+
+// Config is the bot's configuration. It is read from the YAML file named by CONFIG_FILE, if any,
+// then from Vault if VAULT_SECRET_PATH is set, and from environment variables, which take
+// precedence over both. The YAML keys are the lower case environment variable names, e.g.
+// telegram_token for TELEGRAM_TOKEN. Credentials can also be read from files, see secretSettings.
+type Config struct {
+	LogLevel  string `yaml:"log_level"`
+	LogFormat string `yaml:"log_format"`
+
+	TelegramToken string `yaml:"telegram_token"`
+	GraphAPIKey   string `yaml:"graph_api_key"`
+
+	// ExtraTelegramTokens are the tokens of further bots served alongside the primary one, e.g. a beta bot
+	ExtraTelegramTokens []string `yaml:"extra_telegram_tokens"`
+
+	DBPath            string `yaml:"db_path"`
+	RestoreFrom       string `yaml:"restore_from"`
+	MaxWalletsPerUser int    `yaml:"max_wallets_per_user"`
+	// DBEncryptionKey is the hex encoded key wallet addresses are encrypted with, empty to store them in plain text
+	DBEncryptionKey string `yaml:"db_encryption_key"`
+
+	AdminUserIDs   []int64 `yaml:"admin_user_ids"`
+	AllowedUserIDs []int64 `yaml:"allowed_user_ids"`
+	InviteCode     string  `yaml:"invite_code"`
+
+	FetchConcurrency int           `yaml:"fetch_concurrency"`
+	MonitorInterval  time.Duration `yaml:"monitor_interval"`
+
+	// SubgraphTimeout bounds a single query to The Graph
+	SubgraphTimeout time.Duration `yaml:"subgraph_timeout"`
+	// TelegramTimeout bounds a single Bot API request
+	TelegramTimeout time.Duration `yaml:"telegram_timeout"`
+	// CommandTimeout bounds all the work done for a command, see requestTimeout
+	CommandTimeout time.Duration `yaml:"command_timeout"`
+	// LookupTimeout bounds fetching one wallet or position within a command, see lookupTimeout
+	LookupTimeout time.Duration `yaml:"lookup_timeout"`
+	// RPCTimeout bounds a single call to the Ethereum node at EthRPCURL
+	RPCTimeout time.Duration `yaml:"rpc_timeout"`
+
+	WebhookURL     string `yaml:"webhook_url"`
+	WebhookSecret  string `yaml:"webhook_secret"`
+	PublicURL      string `yaml:"public_url"`
+	HTTPListenAddr string `yaml:"http_listen_addr"`
+	CertFile       string `yaml:"webhook_cert_file"`
+	KeyFile        string `yaml:"webhook_key_file"`
+	MetricsToken   string `yaml:"metrics_token"`
+	// APIKeys are the keys accepted by the REST API, which is disabled without any
+	APIKeys []string `yaml:"api_keys"`
+	// GRPCListenAddr is where the gRPC service is served, which also takes one of APIKeys
+	GRPCListenAddr string `yaml:"grpc_listen_addr"`
+
+	// EthRPCURL is the JSON-RPC endpoint of an Ethereum node position NFTs are read from, empty to not read them
+	EthRPCURL string `yaml:"eth_rpc_url"`
+	// TokenBlocklists are the files and URLs of the honeypot tokens positions are flagged for holding
+	TokenBlocklists []string `yaml:"token_blocklists"`
+	// EtherscanAPIKey lets unlisted tokens whose contracts aren't verified on Etherscan be flagged
+	EtherscanAPIKey string `yaml:"etherscan_api_key"`
+
+	// DryRun logs the messages the bot would send instead of sending them
+	DryRun bool `yaml:"dry_run"`
+	// FixturesDir holds positions to serve instead of fetching them, see uniswap.FixtureClient
+	FixturesDir string `yaml:"fixtures_dir"`
+	// Backends name the sources positions are fetched from, each falling back to the next
+	Backends []string `yaml:"backends"`
+	// RegistryFile is the YAML file configuring chains and known tokens at runtime, see RegistryLoader
+	RegistryFile string `yaml:"registry_file"`
+	// TokenLists are the files and URLs of Uniswap token lists whose tokens are known, see RegistryLoader
+	TokenLists []string `yaml:"token_lists"`
+	// SkipSelfTest starts the bot without checking Telegram and The Graph accept its credentials
+	SkipSelfTest bool `yaml:"skip_self_test"`
+
+	// SentryDSN is the Sentry project errors are reported to, empty to not report them
+	SentryDSN string `yaml:"sentry_dsn"`
+	// OTLPEndpoint is where traces are exported to over OTLP/HTTP, empty to not trace
+	OTLPEndpoint string `yaml:"otel_exporter_otlp_endpoint"`
+	// VaultSecretPath is the Vault KV secret credentials are read from, see Config.loadVault
+	VaultSecretPath string `yaml:"vault_secret_path"`
+
+	// RedisURL points at the Redis server replicas of the bot coordinate through, empty for a single replica
+	RedisURL string `yaml:"redis_url"`
+
+	HealthListenAddr string `yaml:"health_listen_addr"`
+	PprofListenAddr  string `yaml:"pprof_listen_addr"`
+}
+
+// defaultConfig returns the configuration used for everything neither the file nor the environment sets
+func defaultConfig() Config {
+	return Config{
+		LogLevel:          "info",
+		LogFormat:         logFormatConsole,
+		DBPath:            defaultDBPath,
+		MaxWalletsPerUser: defaultMaxWallets,
+		FetchConcurrency:  defaultFetchConcurrency,
+		MonitorInterval:   defaultMonitorInterval,
+		SubgraphTimeout:   uniswap.DefaultQueryTimeout,
+		TelegramTimeout:   defaultTelegramTimeout,
+		CommandTimeout:    defaultRequestTimeout,
+		LookupTimeout:     defaultLookupTimeout,
+		RPCTimeout:        uniswap.DefaultRPCTimeout,
+		HTTPListenAddr:    ":8080",
+	}
+}
+
+// LoadConfig reads the configuration and validates it, reporting every problem at once
+func LoadConfig() (Config, error) {
+	cfg := defaultConfig()
+	if path := os.Getenv(configFileEnv); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+	if path := os.Getenv("VAULT_SECRET_PATH"); path != "" {
+		cfg.VaultSecretPath = path
+	}
+	if cfg.VaultSecretPath != "" {
+		if err := cfg.loadVault(cfg.VaultSecretPath); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.loadSecretFiles(); err != nil {
+		return Config{}, err
+	}
+	if len(cfg.Backends) == 0 {
+		// Fixtures used to replace The Graph just by being configured
+		cfg.Backends = []string{backendSubgraph}
+		if cfg.FixturesDir != "" {
+			cfg.Backends = []string{backendFixtures}
+		}
+	}
+	if cfg.PublicURL == "" {
+		// Share links point at the bot's HTTP server, which is public at the webhook URL unless configured otherwise
+		cfg.PublicURL = cfg.WebhookURL
+	}
+	if err := cfg.validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", configFileEnv, err)
+	}
+
+	// Reject unknown keys, they are most likely typos
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv overrides the configuration with the environment variables that are set and not empty
+func (c *Config) loadEnv() error {
+	var errs []error
+	str := func(name string, dst *string) {
+		if v := os.Getenv(name); v != "" {
+			*dst = v
+		}
+	}
+	integer := func(name string, dst *int) {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be a whole number, got %q", name, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	duration := func(name string, dst *time.Duration) {
+		if v := os.Getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be a duration such as 10m, got %q", name, v))
+				return
+			}
+			*dst = d
+		}
+	}
+	boolean := func(name string, dst *bool) {
+		if v := os.Getenv(name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be true or false, got %q", name, v))
+				return
+			}
+			*dst = b
+		}
+	}
+	list := func(name string, dst *[]string) {
+		if v := os.Getenv(name); v != "" {
+			*dst = nil
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*dst = append(*dst, item)
+				}
+			}
+		}
+	}
+	userIDs := func(name string, dst *[]int64) {
+		if v := os.Getenv(name); v != "" {
+			ids, err := parseUserIDs(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				return
+			}
+			*dst = ids
+		}
+	}
+
+	str("LOG_LEVEL", &c.LogLevel)
+	str("LOG_FORMAT", &c.LogFormat)
+	str("TELEGRAM_TOKEN", &c.TelegramToken)
+	list("EXTRA_TELEGRAM_TOKENS", &c.ExtraTelegramTokens)
+	str("GRAPH_API_KEY", &c.GraphAPIKey)
+	str("DB_PATH", &c.DBPath)
+	str("RESTORE_FROM", &c.RestoreFrom)
+	integer("MAX_WALLETS_PER_USER", &c.MaxWalletsPerUser)
+	str("DB_ENCRYPTION_KEY", &c.DBEncryptionKey)
+	userIDs("ADMIN_USER_IDS", &c.AdminUserIDs)
+	userIDs("ALLOWED_USER_IDS", &c.AllowedUserIDs)
+	str("INVITE_CODE", &c.InviteCode)
+	integer("FETCH_CONCURRENCY", &c.FetchConcurrency)
+	duration("MONITOR_INTERVAL", &c.MonitorInterval)
+	duration("SUBGRAPH_TIMEOUT", &c.SubgraphTimeout)
+	duration("TELEGRAM_TIMEOUT", &c.TelegramTimeout)
+	duration("COMMAND_TIMEOUT", &c.CommandTimeout)
+	duration("LOOKUP_TIMEOUT", &c.LookupTimeout)
+	duration("RPC_TIMEOUT", &c.RPCTimeout)
+	str("WEBHOOK_URL", &c.WebhookURL)
+	str("WEBHOOK_SECRET", &c.WebhookSecret)
+	str("PUBLIC_URL", &c.PublicURL)
+	// WEBHOOK_LISTEN_ADDR is the old name of HTTP_LISTEN_ADDR
+	str("WEBHOOK_LISTEN_ADDR", &c.HTTPListenAddr)
+	str("HTTP_LISTEN_ADDR", &c.HTTPListenAddr)
+	str("WEBHOOK_CERT_FILE", &c.CertFile)
+	str("WEBHOOK_KEY_FILE", &c.KeyFile)
+	str("METRICS_TOKEN", &c.MetricsToken)
+	list("API_KEYS", &c.APIKeys)
+	str("GRPC_LISTEN_ADDR", &c.GRPCListenAddr)
+	str("ETH_RPC_URL", &c.EthRPCURL)
+	list("TOKEN_BLOCKLISTS", &c.TokenBlocklists)
+	str("ETHERSCAN_API_KEY", &c.EtherscanAPIKey)
+	boolean("DRY_RUN", &c.DryRun)
+	str("FIXTURES_DIR", &c.FixturesDir)
+	list("BACKENDS", &c.Backends)
+	str("REGISTRY_FILE", &c.RegistryFile)
+	list("TOKEN_LISTS", &c.TokenLists)
+	boolean("SKIP_SELF_TEST", &c.SkipSelfTest)
+	str("SENTRY_DSN", &c.SentryDSN)
+	str("OTEL_EXPORTER_OTLP_ENDPOINT", &c.OTLPEndpoint)
+	str("REDIS_URL", &c.RedisURL)
+	str("HEALTH_LISTEN_ADDR", &c.HealthListenAddr)
+	str("PPROF_LISTEN_ADDR", &c.PprofListenAddr)
+	return errors.Join(errs...)
+}
+
+func (c Config) validate() error {
+	var errs []error
+	if _, err := zapcore.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %q", c.LogLevel))
+	}
+	if c.LogFormat != logFormatJSON && c.LogFormat != logFormatConsole {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT must be %s or %s, got %q", logFormatJSON, logFormatConsole, c.LogFormat))
+	}
+	if c.TelegramToken == "" {
+		errs = append(errs, errors.New("TELEGRAM_TOKEN is required"))
+	}
+	for i, token := range c.ExtraTelegramTokens {
+		if token == c.TelegramToken || slices.Index(c.ExtraTelegramTokens, token) != i {
+			errs = append(errs, errors.New("EXTRA_TELEGRAM_TOKENS must not repeat a token"))
+			break
+		}
+	}
+	if err := validateBackends(c.Backends); err != nil {
+		errs = append(errs, err)
+	}
+	if c.GraphAPIKey == "" && slices.Contains(c.Backends, backendSubgraph) {
+		errs = append(errs, errors.New("GRAPH_API_KEY is required with the subgraph backend"))
+	}
+	if c.FixturesDir == "" && slices.Contains(c.Backends, backendFixtures) {
+		errs = append(errs, errors.New("FIXTURES_DIR is required with the fixtures backend"))
+	}
+	if c.DBPath == "" {
+		errs = append(errs, errors.New("DB_PATH must not be empty"))
+	}
+	if c.RestoreFrom != "" && c.DBPath == memoryDBPath {
+		errs = append(errs, errors.New("RESTORE_FROM can't be used with an in-memory database"))
+	}
+	if c.MaxWalletsPerUser < 0 {
+		errs = append(errs, fmt.Errorf("MAX_WALLETS_PER_USER must be 0 or more, got %d", c.MaxWalletsPerUser))
+	}
+	if c.DBEncryptionKey != "" {
+		if _, err := parseEncryptionKey(c.DBEncryptionKey); err != nil {
+			errs = append(errs, fmt.Errorf("DB_ENCRYPTION_KEY: %w", err))
+		}
+	}
+	if c.FetchConcurrency < 1 {
+		errs = append(errs, fmt.Errorf("FETCH_CONCURRENCY must be 1 or more, got %d", c.FetchConcurrency))
+	}
+	if c.MonitorInterval < 0 {
+		errs = append(errs, fmt.Errorf("MONITOR_INTERVAL must not be negative, got %s", c.MonitorInterval))
+	}
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"SUBGRAPH_TIMEOUT", c.SubgraphTimeout},
+		{"TELEGRAM_TIMEOUT", c.TelegramTimeout},
+		{"COMMAND_TIMEOUT", c.CommandTimeout},
+		{"LOOKUP_TIMEOUT", c.LookupTimeout},
+		{"RPC_TIMEOUT", c.RPCTimeout},
+	} {
+		if timeout.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %s", timeout.name, timeout.value))
+		}
+	}
+	for i, key := range c.APIKeys {
+		if len(key) < minAPIKeyLength {
+			errs = append(errs, fmt.Errorf("API_KEYS: key %d is shorter than %d characters", i+1, minAPIKeyLength))
+		}
+	}
+	if c.EthRPCURL != "" {
+		if u, err := url.Parse(c.EthRPCURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, errors.New("ETH_RPC_URL must be an http:// or https:// URL"))
+		}
+	}
+	if c.OTLPEndpoint != "" {
+		if u, err := url.Parse(c.OTLPEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT must be an http:// or https:// URL, got %q", c.OTLPEndpoint))
+		}
+	}
+	if c.RedisURL != "" {
+		if _, err := redis.ParseURL(c.RedisURL); err != nil {
+			errs = append(errs, fmt.Errorf("REDIS_URL: %w", err))
+		}
+	}
+	if c.GRPCListenAddr != "" && len(c.APIKeys) == 0 {
+		errs = append(errs, errors.New("GRPC_LISTEN_ADDR requires API_KEYS"))
+	}
+	if c.WebhookURL != "" {
+		if err := c.Webhook().validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := c.HTTPServer().validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// EncryptionKey returns the decoded DBEncryptionKey, or nil if wallet addresses aren't encrypted
+func (c Config) EncryptionKey() []byte {
+	if c.DBEncryptionKey == "" {
+		return nil
+	}
+	// Validated when loading
+	key, _ := parseEncryptionKey(c.DBEncryptionKey)
+	return key
+}
+
+// Webhook returns the webhook configuration, which is in use if WebhookURL is set
+func (c Config) Webhook() WebhookConfig {
+	return WebhookConfig{URL: c.WebhookURL, Secret: c.WebhookSecret}
+}
+
+// HTTPServer returns the configuration of the bot's HTTP server
+func (c Config) HTTPServer() HTTPServerConfig {
+	return HTTPServerConfig{ListenAddr: c.HTTPListenAddr, CertFile: c.CertFile, KeyFile: c.KeyFile}
+}
+
+// HTTPServerEnabled reports whether anything is served on the bot's HTTP server
+func (c Config) HTTPServerEnabled() bool {
+	return c.WebhookURL != "" || c.PublicURL != "" || c.MetricsToken != "" || len(c.APIKeys) > 0
+}
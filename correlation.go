@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"go.uber.org/zap"
+)
+
+// correlationGroup is the dispatcher group of the handler assigning correlation IDs. It runs
+// before every other group, including the access guard's.
+const correlationGroup = -3
+
+// correlationIDKey is where an update's correlation ID is kept in its ext.Context data
+const correlationIDKey = "correlation_id"
+
+// correlationHandler gives every update a correlation ID, which the log lines written and the
+// requests made to The Graph for the update carry, so the work done for it can be told apart
+// from the work done for others in the logs
+type correlationHandler struct{}
+
+func (correlationHandler) Name() string {
+	return "correlation"
+}
+
+func (correlationHandler) CheckUpdate(b *gotgbot.Bot, ctx *ext.Context) bool {
+	return true
+}
+
+func (correlationHandler) HandleUpdate(b *gotgbot.Bot, ctx *ext.Context) error {
+	if ctx.Data == nil {
+		ctx.Data = make(map[string]any)
+	}
+	ctx.Data[correlationIDKey] = uniswap.NewCorrelationID()
+	return ext.ContinueGroups
+}
+
+// updateCorrelationID returns the correlation ID of update, or "" if it has none
+func updateCorrelationID(update *ext.Context) string {
+	if update == nil {
+		return ""
+	}
+	id, _ := update.Data[correlationIDKey].(string)
+	return id
+}
+
+// updateLogger returns logger annotated with the correlation ID of update
+func updateLogger(update *ext.Context, logger *zap.SugaredLogger) *zap.SugaredLogger {
+	if id := updateCorrelationID(update); id != "" {
+		return logger.With("correlation_id", id)
+	}
+	return logger
+}
+
+// requestLogger returns logger annotated with the correlation ID of the update or job run ctx
+// does work for
+func requestLogger(ctx context.Context, logger *zap.SugaredLogger) *zap.SugaredLogger {
+	return uniswap.LoggerWithCorrelationID(ctx, logger)
+}
@@ -1,27 +1,590 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type Database struct {
 	db *sql.DB
+	// conn runs the queries: db, or the transaction of a Database passed to a WithTx callback
+	conn dbConn
+
+	// addresses encrypts the stored wallet addresses, it is nil unless encryption was enabled
+	addresses *addressCipher
+
+	// maxWallets caps the wallets free tier users may add across all chats, 0 means no limit
+	maxWallets int
+}
+
+// dbConn is what Database runs its queries on, either a *sql.DB or a *sql.Tx
+type dbConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// WithTx runs fn with a Store whose methods all run in a single transaction, which is committed if fn
+// returns nil and rolled back otherwise. Multi-step operations use it so they never apply halfway.
+func (d *Database) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	return d.transact(ctx, func(conn dbConn) error {
+		tx := *d
+		tx.conn = conn
+		return fn(&tx)
+	})
+}
+
+// transact runs fn in a transaction. Within WithTx it uses a savepoint of the surrounding transaction
+// instead, so that a failing step only undoes its own changes and the caller decides about the rest.
+func (d *Database) transact(ctx context.Context, fn func(tx dbConn) error) error {
+	if tx, ok := d.conn.(*sql.Tx); ok {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT step"); err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			// Rolling back to a savepoint keeps it, so it has to be released either way
+			if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO step"); rollbackErr != nil {
+				return errors.Join(err, rollbackErr)
+			}
+			if _, releaseErr := tx.ExecContext(ctx, "RELEASE step"); releaseErr != nil {
+				return errors.Join(err, releaseErr)
+			}
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "RELEASE step")
+		return err
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanRows reads every row with scan and closes rows
+func scanRows[T any](rows *sql.Rows, scan func(rowScanner) (T, error)) ([]T, error) {
+	defer rows.Close()
+
+	var items []T
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// chatWalletColumns are the user_wallets columns scanChatWallet reads
+const chatWalletColumns = "chat_id, user_id, wallet_address, version, label, chain, added_at"
+
+func (d *Database) scanChatWallet(row rowScanner) (ChatWallet, error) {
+	var w ChatWallet
+	if err := row.Scan(&w.ChatID, &w.UserID, &w.WalletAddress, &w.Version, &w.Label, &w.Chain, &w.AddedAt); err != nil {
+		return ChatWallet{}, err
+	}
+	var err error
+	w.WalletAddress, err = d.addresses.open(w.WalletAddress)
+	return w, err
+}
+
+// trackedPositionColumns are the tracked_positions columns scanTrackedPosition reads
+const trackedPositionColumns = "chat_id, user_id, position_id, version, added_at"
+
+func scanTrackedPosition(row rowScanner) (ChatTrackedPosition, error) {
+	var p ChatTrackedPosition
+	err := row.Scan(&p.ChatID, &p.UserID, &p.PositionID, &p.Version, &p.AddedAt)
+	return p, err
+}
+
+// snapshotColumns are the position_snapshots columns scanSnapshot reads
+const snapshotColumns = "position_id, version, wallet_address, liquidity, amount0, amount1, fees0, fees1, price, taken_at"
+
+func (d *Database) scanSnapshot(row rowScanner) (PositionSnapshot, error) {
+	var s PositionSnapshot
+	if err := row.Scan(&s.PositionID, &s.Version, &s.WalletAddress, &s.Liquidity, &s.Amount0, &s.Amount1, &s.Fees0, &s.Fees1, &s.Price, &s.TakenAt); err != nil {
+		return PositionSnapshot{}, err
+	}
+	var err error
+	s.WalletAddress, err = d.addresses.open(s.WalletAddress)
+	return s, err
+}
+
+// notificationColumns are the notifications columns scanNotification reads
+const notificationColumns = "id, chat_id, text, attempts, next_attempt_at, last_error, created_at"
+
+func scanNotification(row rowScanner) (Notification, error) {
+	var n Notification
+	if err := row.Scan(&n.ID, &n.ChatID, &n.Text, &n.Attempts, &n.NextAttemptAt, &n.LastError, &n.CreatedAt); err != nil {
+		return Notification{}, err
+	}
+	return n, nil
+}
+
+// alertRuleColumns are the alert_rules columns scanAlertRule reads
+const alertRuleColumns = "id, chat_id, type, target, threshold, cooldown_seconds, enabled, last_fired_at, created_at"
+
+func scanAlertRule(row rowScanner) (AlertRule, error) {
+	var rule AlertRule
+	var cooldown int64
+	var lastFired sql.NullTime
+	if err := row.Scan(&rule.ID, &rule.ChatID, &rule.Type, &rule.Target, &rule.Threshold, &cooldown, &rule.Enabled, &lastFired, &rule.CreatedAt); err != nil {
+		return AlertRule{}, err
+	}
+	rule.Cooldown = time.Duration(cooldown) * time.Second
+	rule.LastFiredAt = lastFired.Time
+	return rule, nil
+}
+
+// defaultMaxWallets is how many wallets free tier users may add across all chats unless MAX_WALLETS_PER_USER says otherwise
+const defaultMaxWallets = 20
+
+// WalletLimitError is returned when the user adding a wallet already added as many, in all chats, as they are allowed
+type WalletLimitError struct {
+	Limit int
+}
+
+func (e *WalletLimitError) Error() string {
+	return fmt.Sprintf("user already added the maximum of %d wallets", e.Limit)
+}
+
+// ChatSettings holds a chat's preferences
+type ChatSettings struct {
+	IncludeV3     bool
+	IncludeV4     bool
+	AlertsEnabled bool
+	// SwapAlertUSD is the minimum USD size of swaps in the chat's pools to alert about, 0 disables swap alerts
+	SwapAlertUSD float64
+	DisplayMode  DisplayMode
+	// QuickActions shows the persistent reply keyboard with the most used commands
+	QuickActions bool
+}
+
+// DisplayMode controls how densely positions are listed
+type DisplayMode string
+
+const (
+	// DisplayDetailed shows a multi-line block per position
+	DisplayDetailed DisplayMode = "detailed"
+	// DisplayCompact shows a single line per position, for big portfolios
+	DisplayCompact DisplayMode = "compact"
+)
+
+// DefaultChatSettings are used for chats that never went through onboarding
+var DefaultChatSettings = ChatSettings{
+	IncludeV3:     true,
+	IncludeV4:     true,
+	AlertsEnabled: true,
+	DisplayMode:   DisplayDetailed,
+}
+
+// UserSettings holds a user's personal preferences, which follow them across chats
+type UserSettings struct {
+	Language string
+	// Timezone is an IANA time zone name such as "Europe/Berlin"
+	Timezone string
+	Currency string
+	Chains   []string
+	// DisplayMode and the versions apply where no chat is involved, such as inline mode
+	DisplayMode DisplayMode
+	IncludeV3   bool
+	IncludeV4   bool
+}
+
+// DefaultUserSettings are used for users that never changed their preferences
+var DefaultUserSettings = UserSettings{
+	Language:    "en",
+	Timezone:    "UTC",
+	Currency:    "USD",
+	Chains:      []string{uniswap.ChainEthereum},
+	DisplayMode: DisplayDetailed,
+	IncludeV3:   true,
+	IncludeV4:   true,
+}
+
+// Location returns the user's time zone, or UTC if it is unknown
+func (s UserSettings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// ChatWallet is a wallet tracked in a particular chat
+type ChatWallet struct {
+	ChatID int64
+	// UserID is the user who added the wallet to the chat, 0 if unknown
+	UserID        int64
+	WalletAddress string
+	// Version restricts lookups to a single Uniswap version, empty follows the chat settings
+	Version string
+	// Label is the chat's name for the wallet, empty if none was set
+	Label   string
+	Chain   string
+	AddedAt time.Time
+}
+
+// DisplayName returns the wallet's label followed by its address, or just the address if it has no label
+func (w ChatWallet) DisplayName() string {
+	if w.Label == "" {
+		return w.WalletAddress
+	}
+	return fmt.Sprintf("%s (%s)", w.Label, w.WalletAddress)
+}
+
+// TrackedWallet is a wallet tracked by at least one chat
+type TrackedWallet struct {
+	WalletAddress string
+	ChatIDs       []int64
+}
+
+// TrackedPosition is a single position a chat follows independently of its wallets
+type TrackedPosition struct {
+	PositionID string
+	Version    string
+	// UserID is the user who started tracking the position in the chat, 0 if unknown
+	UserID  int64
+	AddedAt time.Time
+}
+
+// ShareLink grants read-only access to a wallet's positions to anyone holding the token
+type ShareLink struct {
+	Token string
+	ChatWallet
+}
+
+// PositionSnapshot is the state of a position at one refresh. Token amounts are raw integers in base 10,
+// empty when unknown.
+type PositionSnapshot struct {
+	PositionID    string
+	Version       string
+	WalletAddress string
+	Liquidity     string
+	Amount0       string
+	Amount1       string
+	Fees0         string
+	Fees1         string
+	// Price is the pool price of token0 in token1
+	Price   string
+	TakenAt time.Time
+}
+
+// FeeLedgerEntry is the fees a position accrued between two refreshes, as raw integers in base 10
+type FeeLedgerEntry struct {
+	PositionID    string
+	Version       string
+	WalletAddress string
+	Fees0         string
+	Fees1         string
+	From          time.Time
+	To            time.Time
+}
+
+// UsageCounter is how often something was used on one day, e.g. a command or a subgraph
+type UsageCounter struct {
+	// Day is the UTC date, formatted as YYYY-MM-DD
+	Day   string
+	Kind  string
+	Name  string
+	Count int64
+}
+
+// DailyUsage is the usage of the bot on one day
+type DailyUsage struct {
+	Day         string
+	ActiveUsers int
+	Counters    []UsageCounter
+}
+
+// AlertType is the condition an alert rule watches for
+type AlertType string
+
+const (
+	// AlertOutOfRange fires while the target position's range doesn't contain the pool price
+	AlertOutOfRange AlertType = "range"
+	// AlertFees fires once the fees collected by the target position are worth at least the threshold in USD
+	AlertFees AlertType = "fees"
+)
+
+// AlertRule is a chat's alert on a single position
+type AlertRule struct {
+	ID     int64
+	ChatID int64
+	Type   AlertType
+	// Target is the key of the watched position, e.g. "V3:12345"
+	Target    string
+	Threshold float64
+	// Cooldown is the minimum time between two alerts of the rule
+	Cooldown    time.Duration
+	Enabled     bool
+	LastFiredAt time.Time
+	CreatedAt   time.Time
+}
+
+// AlertDelivery is an entry of the alert delivery ledger: an alert sent because a rule's condition
+// started to hold for a position, or the condition no longer holding after such an alert
+type AlertDelivery struct {
+	RuleID int64
+	// Position is the key of the position, e.g. "V3:12345"
+	Position  string
+	Triggered bool
+	At        time.Time
+}
+
+// Notification is a message queued for delivery to a chat
+type Notification struct {
+	ID     int64
+	ChatID int64
+	Text   string
+	// Attempts counts the failed attempts to deliver the notification so far
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+}
+
+// ChatTrackedPosition is a position tracked in a particular chat
+type ChatTrackedPosition struct {
+	ChatID int64
+	TrackedPosition
 }
 
-func initDB() (*Database, error) {
-	db, err := sql.Open("sqlite3", "./data.db")
+// defaultDBPath is where the database lives unless DB_PATH says otherwise
+const defaultDBPath = "./data.db"
+
+// sqliteOptions configures every connection: write-ahead logging so reads don't block on writes,
+// a busy timeout so concurrent writers wait for each other instead of failing with "database is
+// locked", and immediate transactions so a transaction takes the write lock up front rather than
+// deadlocking when upgrading from a read lock.
+const sqliteOptions = "?_journal_mode=WAL&_busy_timeout=5000&_synchronous=NORMAL&_txlock=immediate"
+
+// maxDBConns bounds the connection pool, SQLite only ever allows one writer anyway
+const maxDBConns = 4
+
+// memoryDBPath as the database path keeps the whole database in memory, e.g. for tests. Nothing
+// is persisted and the data is gone once the database is closed.
+const memoryDBPath = ":memory:"
+
+// newMemoryDB returns an empty in-memory database with every table and migration in place, so
+// stores and handlers can be tested without touching the filesystem
+func newMemoryDB() (*Database, error) {
+	return initDB(memoryDBPath)
+}
+
+// initDB opens the SQLite database at path, creating its directory and tables as needed
+func initDB(path string) (*Database, error) {
+	if dir := filepath.Dir(path); dir != "." && path != memoryDBPath {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite3", path+sqliteOptions)
 	if err != nil {
 		return nil, err
 	}
 
+	// Handlers, the monitor and the HTTP server all share the pool. WAL lets readers proceed while
+	// one connection writes, and the busy timeout makes writers queue instead of failing.
+	conns := maxDBConns
+	if path == memoryDBPath {
+		// Each connection to :memory: opens a database of its own, so all queries must share one
+		conns = 1
+	}
+	db.SetMaxOpenConns(conns)
+	db.SetMaxIdleConns(conns)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	// Create tables if they don't exist
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS user_wallets (
-			user_id INTEGER,
+			chat_id INTEGER,
+			user_id INTEGER NOT NULL DEFAULT 0,
 			wallet_address TEXT,
+			version TEXT NOT NULL DEFAULT '',
+			label TEXT NOT NULL DEFAULT '',
+			chain TEXT NOT NULL DEFAULT 'ethereum',
+			added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (chat_id, wallet_address)
+		);
+		CREATE INDEX IF NOT EXISTS user_wallets_address ON user_wallets (wallet_address, chat_id);
+		CREATE TABLE IF NOT EXISTS tracked_positions (
+			chat_id INTEGER,
+			user_id INTEGER NOT NULL DEFAULT 0,
+			position_id TEXT,
+			version TEXT,
 			added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			PRIMARY KEY (user_id, wallet_address)
+			PRIMARY KEY (chat_id, position_id, version)
+		);
+		CREATE TABLE IF NOT EXISTS chat_settings (
+			chat_id INTEGER PRIMARY KEY,
+			include_v3 BOOLEAN NOT NULL DEFAULT 1,
+			include_v4 BOOLEAN NOT NULL DEFAULT 1,
+			alerts_enabled BOOLEAN NOT NULL DEFAULT 1,
+			swap_alert_usd REAL NOT NULL DEFAULT 0,
+			display_mode TEXT NOT NULL DEFAULT 'detailed',
+			quick_actions BOOLEAN NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE IF NOT EXISTS user_settings (
+			user_id INTEGER PRIMARY KEY,
+			language TEXT NOT NULL DEFAULT 'en',
+			timezone TEXT NOT NULL DEFAULT 'UTC',
+			currency TEXT NOT NULL DEFAULT 'USD',
+			chains TEXT NOT NULL DEFAULT 'ethereum',
+			display_mode TEXT NOT NULL DEFAULT 'detailed',
+			include_v3 BOOLEAN NOT NULL DEFAULT 1,
+			include_v4 BOOLEAN NOT NULL DEFAULT 1,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE IF NOT EXISTS users (
+			user_id INTEGER PRIMARY KEY,
+			tier TEXT NOT NULL DEFAULT 'free',
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE IF NOT EXISTS allowed_users (
+			user_id INTEGER PRIMARY KEY,
+			added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE IF NOT EXISTS share_links (
+			token TEXT PRIMARY KEY,
+			chat_id INTEGER NOT NULL,
+			wallet_address TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE IF NOT EXISTS position_snapshots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			position_id TEXT NOT NULL,
+			version TEXT NOT NULL,
+			wallet_address TEXT NOT NULL,
+			liquidity TEXT NOT NULL DEFAULT '',
+			amount0 TEXT NOT NULL DEFAULT '',
+			amount1 TEXT NOT NULL DEFAULT '',
+			fees0 TEXT NOT NULL DEFAULT '',
+			fees1 TEXT NOT NULL DEFAULT '',
+			price TEXT NOT NULL DEFAULT '',
+			taken_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS position_snapshots_position ON position_snapshots (position_id, version, taken_at);
+		CREATE TABLE IF NOT EXISTS fee_ledger (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			position_id TEXT NOT NULL,
+			version TEXT NOT NULL,
+			wallet_address TEXT NOT NULL,
+			fees0 TEXT NOT NULL,
+			fees1 TEXT NOT NULL,
+			period_start TIMESTAMP NOT NULL,
+			period_end TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS fee_ledger_position ON fee_ledger (position_id, version, period_end);
+		CREATE TABLE IF NOT EXISTS position_cache (
+			wallet_address TEXT PRIMARY KEY,
+			positions TEXT NOT NULL,
+			fetched_at TIMESTAMP NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS last_seen_positions (
+			wallet_address TEXT PRIMARY KEY,
+			positions TEXT NOT NULL,
+			fetched_at TIMESTAMP NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS last_seen_tracked_positions (
+			position_id TEXT NOT NULL,
+			version TEXT NOT NULL,
+			position TEXT NOT NULL,
+			seen_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (position_id, version)
+		);
+		CREATE TABLE IF NOT EXISTS monitor_cursors (
+			name TEXT PRIMARY KEY,
+			at TIMESTAMP NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS alert_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			chat_id INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			target TEXT NOT NULL,
+			threshold REAL NOT NULL DEFAULT 0,
+			cooldown_seconds INTEGER NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT 1,
+			last_fired_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS alert_rules_chat ON alert_rules (chat_id);
+		CREATE TABLE IF NOT EXISTS alert_deliveries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			rule_id INTEGER NOT NULL,
+			position TEXT NOT NULL,
+			triggered BOOLEAN NOT NULL,
+			recorded_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS alert_deliveries_rule ON alert_deliveries (rule_id, position);
+		CREATE TABLE IF NOT EXISTS notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			chat_id INTEGER NOT NULL,
+			text TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL,
+			last_error TEXT NOT NULL DEFAULT '',
+			dead_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS notifications_due ON notifications (dead_at, next_attempt_at);
+		CREATE INDEX IF NOT EXISTS notifications_chat ON notifications (chat_id);
+		CREATE TABLE IF NOT EXISTS chat_bots (
+			chat_id INTEGER PRIMARY KEY,
+			bot_id INTEGER NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS tokens (
+			chain TEXT NOT NULL,
+			address TEXT NOT NULL,
+			symbol TEXT NOT NULL,
+			decimals INTEGER NOT NULL,
+			logo_uri TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (chain, address)
+		);
+		CREATE TABLE IF NOT EXISTS usage_users (
+			day TEXT NOT NULL,
+			user_id INTEGER NOT NULL,
+			PRIMARY KEY (day, user_id)
+		);
+		CREATE TABLE IF NOT EXISTS usage_counters (
+			day TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			name TEXT NOT NULL,
+			count INTEGER NOT NULL,
+			PRIMARY KEY (day, kind, name)
 		);
 	`)
 
@@ -29,42 +592,1286 @@ func initDB() (*Database, error) {
 		return nil, err
 	}
 
-	return &Database{db: db}, nil
+	if err := migrateDB(db); err != nil {
+		return nil, err
+	}
+
+	return &Database{db: db, conn: db}, nil
+}
+
+// sqliteHeader starts every SQLite database file
+const sqliteHeader = "SQLite format 3\x00"
+
+// restoreDB replaces the database at path with the backup at backupPath, before the database is opened.
+// The replaced database is kept next to it with a ".before-restore" suffix.
+func restoreDB(path, backupPath string) error {
+	if path == memoryDBPath {
+		return errors.New("an in-memory database can't be restored from a backup")
+	}
+
+	backup, err := os.Open(backupPath)
+	if err != nil {
+		return err
+	}
+	defer backup.Close()
+
+	header := make([]byte, len(sqliteHeader))
+	if _, err := io.ReadFull(backup, header); err != nil || string(header) != sqliteHeader {
+		return fmt.Errorf("%s is not a SQLite database", backupPath)
+	}
+	if _, err := backup.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	// Copy next to the database first so a failed copy leaves it untouched
+	tmpPath := path + ".restoring"
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+	if _, err := io.Copy(tmp, backup); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// The WAL and shared memory files belong to the replaced database. Those of an earlier
+	// restore must go, or SQLite could apply them to the wrong database.
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		kept := path + ".before-restore" + suffix
+		if err := os.Remove(kept); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err := os.Rename(path+suffix, kept); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// migrateDB upgrades tables created by older versions of the bot.
+func migrateDB(db *sql.DB) error {
+	// Wallets and tracked positions used to be keyed by user_id. In private chats the
+	// chat ID equals the user ID, so renaming the column keeps existing rows valid.
+	// Tables without chat_id are from before the rename, later ones have both columns.
+	for _, table := range []string{"user_wallets", "tracked_positions"} {
+		renamed, err := columnExists(db, table, "chat_id")
+		if err != nil {
+			return err
+		}
+		if !renamed {
+			if _, err := db.Exec("ALTER TABLE " + table + " RENAME COLUMN user_id TO chat_id"); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Columns added to existing tables later on
+	added := []struct{ table, column, definition string }{
+		{"user_wallets", "version", "TEXT NOT NULL DEFAULT ''"},
+		{"user_wallets", "label", "TEXT NOT NULL DEFAULT ''"},
+		{"user_wallets", "chain", "TEXT NOT NULL DEFAULT 'ethereum'"},
+		{"user_wallets", "user_id", "INTEGER NOT NULL DEFAULT 0"},
+		{"tracked_positions", "user_id", "INTEGER NOT NULL DEFAULT 0"},
+		{"chat_settings", "swap_alert_usd", "REAL NOT NULL DEFAULT 0"},
+		{"chat_settings", "display_mode", "TEXT NOT NULL DEFAULT 'detailed'"},
+		{"chat_settings", "quick_actions", "BOOLEAN NOT NULL DEFAULT 0"},
+	}
+	for _, c := range added {
+		exists, err := columnExists(db, c.table, c.column)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := db.Exec("ALTER TABLE " + c.table + " ADD COLUMN " + c.column + " " + c.definition); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Rows from before wallets and positions were attributed to users belong to the chat's user in
+	// private chats, whose ID is the chat's. Group rows stay unattributed.
+	for _, table := range []string{"user_wallets", "tracked_positions"} {
+		if _, err := db.Exec("UPDATE " + table + " SET user_id = chat_id WHERE user_id = 0 AND chat_id > 0"); err != nil {
+			return err
+		}
+	}
+
+	// Indexes on columns added above, which older tables only have once migrated. Wallets are
+	// counted by user for the wallet limit.
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS user_wallets_user ON user_wallets (user_id)"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func columnExists(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// SetWalletLimit caps the number of wallets free tier users may add across all chats, 0 removes the limit
+func (d *Database) SetWalletLimit(limit int) {
+	d.maxWallets = limit
+}
+
+// AddWallet starts tracking a wallet in a chat. An empty version queries the versions enabled in the chat settings.
+// It reports false if the chat already tracks the wallet, which is left unchanged, and returns a *WalletLimitError
+// if the user already added as many wallets to any chats as their tier allows.
+func (d *Database) AddWallet(ctx context.Context, chatID, userID int64, walletAddress, version string) (bool, error) {
+	entitlements, err := d.GetEntitlements(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	var added bool
+	err = d.transact(ctx, func(tx dbConn) error {
+		res, err := tx.ExecContext(ctx,
+			"INSERT OR IGNORE INTO user_wallets (chat_id, user_id, wallet_address, version) VALUES (?, ?, ?, ?)",
+			chatID, userID, d.addresses.seal(walletAddress), version,
+		)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+
+		if limit := entitlements.MaxWallets; limit > 0 {
+			var count int
+			// Counted in all chats, so the limit can't be dodged by adding wallets to more chats,
+			// and other members' wallets don't count against a user in a group
+			err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM user_wallets WHERE user_id = ?", userID).Scan(&count)
+			if err != nil {
+				return err
+			}
+			if count > limit {
+				return &WalletLimitError{Limit: limit}
+			}
+		}
+		added = true
+		return nil
+	})
+	return added && err == nil, err
+}
+
+// GetUserTier returns a user's subscription tier, TierFree unless it was changed
+func (d *Database) GetUserTier(ctx context.Context, userID int64) (Tier, error) {
+	var tier Tier
+	err := d.conn.QueryRowContext(ctx, "SELECT tier FROM users WHERE user_id = ?", userID).Scan(&tier)
+	if err == sql.ErrNoRows {
+		return TierFree, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, ok := tierEntitlements[tier]; !ok {
+		return "", fmt.Errorf("unknown tier %q", tier)
+	}
+	return tier, nil
 }
 
-func (d *Database) AddWallet(userID int64, walletAddress string) error {
-	_, err := d.db.Exec(
-		"INSERT INTO user_wallets (user_id, wallet_address) VALUES (?, ?)",
-		userID, walletAddress,
+// SetUserTier changes a user's subscription tier
+func (d *Database) SetUserTier(ctx context.Context, userID int64, tier Tier) error {
+	_, err := d.conn.ExecContext(ctx, `
+		INSERT INTO users (user_id, tier) VALUES (?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET tier = excluded.tier, updated_at = CURRENT_TIMESTAMP`,
+		userID, tier,
 	)
 	return err
 }
 
-func (d *Database) RemoveWallet(userID int64, walletAddress string) error {
-	_, err := d.db.Exec(
-		"DELETE FROM user_wallets WHERE user_id = ? AND wallet_address = ?",
-		userID, walletAddress,
+// GetEntitlements returns what a user's tier allows them
+func (d *Database) GetEntitlements(ctx context.Context, userID int64) (Entitlements, error) {
+	tier, err := d.GetUserTier(ctx, userID)
+	if err != nil {
+		return Entitlements{}, err
+	}
+	entitlements := tierEntitlements[tier]
+	if tier == TierFree {
+		entitlements.MaxWallets = d.maxWallets
+	}
+	return entitlements, nil
+}
+
+func (d *Database) RemoveWallet(ctx context.Context, chatID int64, walletAddress string) error {
+	_, err := d.conn.ExecContext(ctx,
+		"DELETE FROM user_wallets WHERE chat_id = ? AND wallet_address = ?",
+		chatID, d.addresses.seal(walletAddress),
 	)
 	return err
 }
 
-func (d *Database) GetWallets(userID int64) ([]string, error) {
-	rows, err := d.db.Query(
-		"SELECT wallet_address FROM user_wallets WHERE user_id = ?",
-		userID,
+// GetChatWallets returns the chat's wallets along with their version restrictions and labels
+func (d *Database) GetChatWallets(ctx context.Context, chatID int64) ([]ChatWallet, error) {
+	rows, err := d.conn.QueryContext(ctx,
+		"SELECT "+chatWalletColumns+" FROM user_wallets WHERE chat_id = ?",
+		chatID,
 	)
 	if err != nil {
 		return nil, err
 	}
+	return scanRows(rows, d.scanChatWallet)
+}
+
+// GetAllTrackedWallets returns every wallet tracked by any chat once, along with the chats tracking it
+func (d *Database) GetAllTrackedWallets(ctx context.Context) ([]TrackedWallet, error) {
+	// Ordered by the user_wallets_address index, so each wallet's rows are adjacent
+	rows, err := d.conn.QueryContext(ctx, "SELECT wallet_address, chat_id FROM user_wallets ORDER BY wallet_address, chat_id")
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
-	var wallets []string
+	var wallets []TrackedWallet
+	var last string
 	for rows.Next() {
-		var wallet string
-		if err := rows.Scan(&wallet); err != nil {
+		var stored string
+		var chatID int64
+		if err := rows.Scan(&stored, &chatID); err != nil {
 			return nil, err
 		}
-		wallets = append(wallets, wallet)
+		if len(wallets) == 0 || stored != last {
+			address, err := d.addresses.open(stored)
+			if err != nil {
+				return nil, err
+			}
+			wallets = append(wallets, TrackedWallet{WalletAddress: address})
+			last = stored
+		}
+		w := &wallets[len(wallets)-1]
+		w.ChatIDs = append(w.ChatIDs, chatID)
+	}
+	return wallets, rows.Err()
+}
+
+// SetWalletLabel names one of the chat's wallets, an empty label removes the name.
+// It returns false if the chat doesn't track the wallet.
+func (d *Database) SetWalletLabel(ctx context.Context, chatID int64, walletAddress, label string) (bool, error) {
+	res, err := d.conn.ExecContext(ctx,
+		"UPDATE user_wallets SET label = ? WHERE chat_id = ? AND wallet_address = ?",
+		label, chatID, d.addresses.seal(walletAddress),
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetWalletLabel returns the chat's label for a wallet, or "" if it has none
+func (d *Database) GetWalletLabel(ctx context.Context, chatID int64, walletAddress string) (string, error) {
+	var label string
+	err := d.conn.QueryRowContext(ctx,
+		"SELECT label FROM user_wallets WHERE chat_id = ? AND wallet_address = ?",
+		chatID, d.addresses.seal(walletAddress),
+	).Scan(&label)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return label, err
+}
+
+// ListAllTrackedPositions returns every individually tracked position of every chat
+func (d *Database) ListAllTrackedPositions(ctx context.Context) ([]ChatTrackedPosition, error) {
+	rows, err := d.conn.QueryContext(ctx, "SELECT "+trackedPositionColumns+" FROM tracked_positions ORDER BY chat_id")
+	if err != nil {
+		return nil, err
+	}
+	return scanRows(rows, scanTrackedPosition)
+}
+
+// TrackPosition makes a chat track a single position, on behalf of the user who asked
+func (d *Database) TrackPosition(ctx context.Context, chatID, userID int64, positionID, version string) error {
+	_, err := d.conn.ExecContext(ctx,
+		"INSERT OR IGNORE INTO tracked_positions (chat_id, user_id, position_id, version) VALUES (?, ?, ?, ?)",
+		chatID, userID, positionID, version,
+	)
+	return err
+}
+
+// UntrackPosition stops tracking a position. An empty version removes the position for all versions.
+func (d *Database) UntrackPosition(ctx context.Context, chatID int64, positionID, version string) (bool, error) {
+	var removed bool
+	err := d.transact(ctx, func(tx dbConn) error {
+		var res sql.Result
+		var err error
+		if version == "" {
+			res, err = tx.ExecContext(ctx,
+				"DELETE FROM tracked_positions WHERE chat_id = ? AND position_id = ?",
+				chatID, positionID,
+			)
+		} else {
+			res, err = tx.ExecContext(ctx,
+				"DELETE FROM tracked_positions WHERE chat_id = ? AND position_id = ? AND version = ?",
+				chatID, positionID, version,
+			)
+		}
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		removed = n > 0
+
+		// Once no chat tracks the position, forget its baseline, or tracking it again would report
+		// everything that changed in between
+		_, err = tx.ExecContext(ctx, `DELETE FROM last_seen_tracked_positions WHERE position_id = ?
+			AND (position_id, version) NOT IN (SELECT position_id, version FROM tracked_positions)`,
+			positionID,
+		)
+		return err
+	})
+	return removed, err
+}
+
+func (d *Database) GetTrackedPositions(ctx context.Context, chatID int64) ([]TrackedPosition, error) {
+	rows, err := d.conn.QueryContext(ctx,
+		"SELECT "+trackedPositionColumns+" FROM tracked_positions WHERE chat_id = ? ORDER BY added_at",
+		chatID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	tracked, err := scanRows(rows, scanTrackedPosition)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]TrackedPosition, len(tracked))
+	for i, p := range tracked {
+		positions[i] = p.TrackedPosition
+	}
+	return positions, nil
+}
+
+// AllowUser grants a user access to the bot when access control is enabled
+func (d *Database) AllowUser(ctx context.Context, userID int64) error {
+	_, err := d.conn.ExecContext(ctx,
+		"INSERT OR IGNORE INTO allowed_users (user_id) VALUES (?)",
+		userID,
+	)
+	return err
+}
+
+func (d *Database) IsUserAllowed(ctx context.Context, userID int64) (bool, error) {
+	var exists bool
+	err := d.conn.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM allowed_users WHERE user_id = ?)",
+		userID,
+	).Scan(&exists)
+	return exists, err
+}
+
+// GetChatSettings returns the chat's settings, or DefaultChatSettings if none were saved
+func (d *Database) GetChatSettings(ctx context.Context, chatID int64) (ChatSettings, error) {
+	settings := DefaultChatSettings
+	err := d.conn.QueryRowContext(ctx,
+		"SELECT include_v3, include_v4, alerts_enabled, swap_alert_usd, display_mode, quick_actions FROM chat_settings WHERE chat_id = ?",
+		chatID,
+	).Scan(&settings.IncludeV3, &settings.IncludeV4, &settings.AlertsEnabled, &settings.SwapAlertUSD, &settings.DisplayMode, &settings.QuickActions)
+	if err == sql.ErrNoRows {
+		return DefaultChatSettings, nil
+	}
+	return settings, err
+}
+
+func (d *Database) SaveChatSettings(ctx context.Context, chatID int64, settings ChatSettings) error {
+	_, err := d.conn.ExecContext(ctx, `
+		INSERT INTO chat_settings (chat_id, include_v3, include_v4, alerts_enabled, swap_alert_usd, display_mode, quick_actions) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (chat_id) DO UPDATE SET
+			include_v3 = excluded.include_v3,
+			include_v4 = excluded.include_v4,
+			alerts_enabled = excluded.alerts_enabled,
+			swap_alert_usd = excluded.swap_alert_usd,
+			display_mode = excluded.display_mode,
+			quick_actions = excluded.quick_actions,
+			updated_at = CURRENT_TIMESTAMP`,
+		chatID, settings.IncludeV3, settings.IncludeV4, settings.AlertsEnabled, settings.SwapAlertUSD, settings.DisplayMode, settings.QuickActions,
+	)
+	return err
+}
+
+// GetUserSettings returns the user's settings, or DefaultUserSettings if none were saved
+func (d *Database) GetUserSettings(ctx context.Context, userID int64) (UserSettings, error) {
+	settings := DefaultUserSettings
+	var chains string
+	err := d.conn.QueryRowContext(ctx,
+		"SELECT language, timezone, currency, chains, display_mode, include_v3, include_v4 FROM user_settings WHERE user_id = ?",
+		userID,
+	).Scan(&settings.Language, &settings.Timezone, &settings.Currency, &chains, &settings.DisplayMode, &settings.IncludeV3, &settings.IncludeV4)
+	if err == sql.ErrNoRows {
+		return DefaultUserSettings, nil
+	}
+	if err != nil {
+		return DefaultUserSettings, err
+	}
+	settings.Chains = strings.Split(chains, ",")
+	return settings, nil
+}
+
+func (d *Database) SaveUserSettings(ctx context.Context, userID int64, settings UserSettings) error {
+	_, err := d.conn.ExecContext(ctx, `
+		INSERT INTO user_settings (user_id, language, timezone, currency, chains, display_mode, include_v3, include_v4) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			language = excluded.language,
+			timezone = excluded.timezone,
+			currency = excluded.currency,
+			chains = excluded.chains,
+			display_mode = excluded.display_mode,
+			include_v3 = excluded.include_v3,
+			include_v4 = excluded.include_v4,
+			updated_at = CURRENT_TIMESTAMP`,
+		userID, settings.Language, settings.Timezone, settings.Currency, strings.Join(settings.Chains, ","), settings.DisplayMode, settings.IncludeV3, settings.IncludeV4,
+	)
+	return err
+}
+
+// SetChatBot records that the chat last talked to the bot with ID botID
+func (d *Database) SetChatBot(ctx context.Context, chatID, botID int64) error {
+	_, err := d.conn.ExecContext(ctx,
+		`INSERT INTO chat_bots (chat_id, bot_id, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (chat_id) DO UPDATE SET bot_id = excluded.bot_id, updated_at = excluded.updated_at`,
+		chatID, botID, time.Now(),
+	)
+	return err
+}
+
+// GetChatBot returns the ID of the bot the chat last talked to, and false if none was recorded
+func (d *Database) GetChatBot(ctx context.Context, chatID int64) (int64, bool, error) {
+	var botID int64
+	err := d.conn.QueryRowContext(ctx,
+		"SELECT bot_id FROM chat_bots WHERE chat_id = ?",
+		chatID,
+	).Scan(&botID)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return botID, true, nil
+}
+
+// Ping runs a trivial query, failing if the database can't be read, e.g. because it is locked
+func (d *Database) Ping(ctx context.Context) error {
+	var one int
+	return d.conn.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
+// Backup writes a consistent copy of the database to path, which must not exist yet
+func (d *Database) Backup(ctx context.Context, path string) error {
+	_, err := d.db.ExecContext(ctx, "VACUUM INTO ?", path)
+	return err
+}
+
+// walletDataTables hold data about wallets rather than chats, keyed by a wallet_address column
+var walletDataTables = []string{"position_snapshots", "fee_ledger", "position_cache", "last_seen_positions"}
+
+// encryptedAddressColumns are the tables whose wallet_address column is encrypted
+var encryptedAddressColumns = append([]string{"user_wallets", "share_links"}, walletDataTables...)
+
+// encryptedPositionColumns are the columns holding positions as JSON, which name their owners, and
+// are encrypted
+var encryptedPositionColumns = []struct{ table, column string }{
+	{"position_cache", "positions"},
+	{"last_seen_positions", "positions"},
+	{"last_seen_tracked_positions", "position"},
+}
+
+// EncryptAddresses makes the database store wallet addresses, and the positions stored by wallet,
+// encrypted with key from now on, and encrypts those stored in plain text so far
+func (d *Database) EncryptAddresses(ctx context.Context, key []byte) error {
+	addresses, err := newAddressCipher(key)
+	if err != nil {
+		return err
+	}
+
+	err = d.transact(ctx, func(tx dbConn) error {
+		for _, table := range encryptedAddressColumns {
+			rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT DISTINCT wallet_address FROM %s WHERE wallet_address NOT LIKE '%s%%'", table, encryptedAddressPrefix))
+			if err != nil {
+				return err
+			}
+			var plain []string
+			for rows.Next() {
+				var address string
+				if err := rows.Scan(&address); err != nil {
+					rows.Close()
+					return err
+				}
+				plain = append(plain, address)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for _, address := range plain {
+				_, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET wallet_address = ? WHERE wallet_address = ?", table), addresses.seal(address), address)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, c := range encryptedPositionColumns {
+			rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT rowid, %s FROM %s WHERE %s NOT LIKE '%s%%'", c.column, c.table, c.column, encryptedAddressPrefix))
+			if err != nil {
+				return err
+			}
+			plain := make(map[int64]string)
+			for rows.Next() {
+				var rowID int64
+				var positions string
+				if err := rows.Scan(&rowID, &positions); err != nil {
+					rows.Close()
+					return err
+				}
+				plain[rowID] = positions
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for rowID, positions := range plain {
+				sealed, err := addresses.sealPayload(positions)
+				if err != nil {
+					return err
+				}
+				if _, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s = ? WHERE rowid = ?", c.table, c.column), sealed, rowID); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.addresses = addresses
+	return nil
+}
+
+// DeleteUser removes everything stored about a user: their preferences, invite admission and activity, and
+// the wallets, positions, settings, share links and alert rules of their private chat. Snapshots, fee
+// ledger entries and cached positions are removed unless another chat still tracks the wallet or position
+// they belong to. Wallets and positions the user added to group chats stay, no longer attributed to them.
+func (d *Database) DeleteUser(ctx context.Context, userID int64) error {
+	// Wallet and position data goes too, unless another chat still tracks the wallet or position
+	var statements []string
+	for _, table := range walletDataTables {
+		statements = append(statements, fmt.Sprintf(`DELETE FROM %s
+			WHERE wallet_address IN (SELECT wallet_address FROM user_wallets WHERE chat_id = ?1)
+			AND wallet_address NOT IN (SELECT wallet_address FROM user_wallets WHERE chat_id != ?1)`, table))
+	}
+	for _, table := range []string{"position_snapshots", "fee_ledger"} {
+		statements = append(statements, fmt.Sprintf(`DELETE FROM %s
+			WHERE (position_id, version) IN (SELECT position_id, version FROM tracked_positions WHERE chat_id = ?1)
+			AND (position_id, version) NOT IN (SELECT position_id, version FROM tracked_positions WHERE chat_id != ?1)
+			AND wallet_address NOT IN (SELECT wallet_address FROM user_wallets WHERE chat_id != ?1)`, table))
+	}
+	statements = append(statements, `DELETE FROM last_seen_tracked_positions
+		WHERE (position_id, version) IN (SELECT position_id, version FROM tracked_positions WHERE chat_id = ?1)
+		AND (position_id, version) NOT IN (SELECT position_id, version FROM tracked_positions WHERE chat_id != ?1)`)
+
+	// A private chat's ID is the user's ID
+	statements = append(statements,
+		"DELETE FROM user_wallets WHERE chat_id = ?1",
+		"DELETE FROM tracked_positions WHERE chat_id = ?1",
+		"DELETE FROM chat_settings WHERE chat_id = ?1",
+		"DELETE FROM share_links WHERE chat_id = ?1",
+		"DELETE FROM alert_deliveries WHERE rule_id IN (SELECT id FROM alert_rules WHERE chat_id = ?1)",
+		"DELETE FROM alert_rules WHERE chat_id = ?1",
+		"DELETE FROM notifications WHERE chat_id = ?1",
+		"DELETE FROM chat_bots WHERE chat_id = ?1",
+		"DELETE FROM user_settings WHERE user_id = ?1",
+		"DELETE FROM allowed_users WHERE user_id = ?1",
+		"DELETE FROM usage_users WHERE user_id = ?1",
+		"DELETE FROM users WHERE user_id = ?1",
+		// Group chats keep what the user added, without attributing it to them
+		"UPDATE user_wallets SET user_id = 0 WHERE user_id = ?1",
+		"UPDATE tracked_positions SET user_id = 0 WHERE user_id = ?1",
+	)
+	return d.transact(ctx, func(tx dbConn) error {
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, statement, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CreateShareLink stores a new share link for a chat's wallet
+func (d *Database) CreateShareLink(ctx context.Context, link ShareLink) error {
+	_, err := d.conn.ExecContext(ctx,
+		"INSERT INTO share_links (token, chat_id, wallet_address) VALUES (?, ?, ?)",
+		link.Token, link.ChatID, d.addresses.seal(link.WalletAddress),
+	)
+	return err
+}
+
+// GetShareLinkForWallet returns the token of an existing share link for the chat's wallet, or "" if there is none
+func (d *Database) GetShareLinkForWallet(ctx context.Context, chatID int64, walletAddress string) (string, error) {
+	var token string
+	err := d.conn.QueryRowContext(ctx,
+		"SELECT token FROM share_links WHERE chat_id = ? AND wallet_address = ? LIMIT 1",
+		chatID, d.addresses.seal(walletAddress),
+	).Scan(&token)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return token, err
+}
+
+// GetShareLink looks a share link up by its token. It returns false if the token is unknown or was revoked.
+func (d *Database) GetShareLink(ctx context.Context, token string) (ShareLink, bool, error) {
+	link := ShareLink{Token: token}
+	err := d.conn.QueryRowContext(ctx,
+		"SELECT chat_id, wallet_address FROM share_links WHERE token = ?",
+		token,
+	).Scan(&link.ChatID, &link.WalletAddress)
+	if err == sql.ErrNoRows {
+		return ShareLink{}, false, nil
+	}
+	if err != nil {
+		return ShareLink{}, false, err
+	}
+	if link.WalletAddress, err = d.addresses.open(link.WalletAddress); err != nil {
+		return ShareLink{}, false, err
+	}
+	return link, true, nil
+}
+
+// DeleteShareLinks revokes the chat's share links for a wallet, or all of them if walletAddress is empty.
+// It returns the number of revoked links.
+func (d *Database) DeleteShareLinks(ctx context.Context, chatID int64, walletAddress string) (int64, error) {
+	var res sql.Result
+	var err error
+	if walletAddress == "" {
+		res, err = d.conn.ExecContext(ctx,
+			"DELETE FROM share_links WHERE chat_id = ?",
+			chatID,
+		)
+	} else {
+		res, err = d.conn.ExecContext(ctx,
+			"DELETE FROM share_links WHERE chat_id = ? AND wallet_address = ?",
+			chatID, d.addresses.seal(walletAddress),
+		)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// SaveSnapshots stores the snapshots of one refresh
+func (d *Database) SaveSnapshots(ctx context.Context, snapshots []PositionSnapshot) error {
+	return d.transact(ctx, func(tx dbConn) error {
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO position_snapshots (position_id, version, wallet_address, liquidity, amount0, amount1, fees0, fees1, price, taken_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, s := range snapshots {
+			if _, err := stmt.ExecContext(ctx, s.PositionID, s.Version, d.addresses.seal(s.WalletAddress), s.Liquidity, s.Amount0, s.Amount1, s.Fees0, s.Fees1, s.Price, s.TakenAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetSnapshots returns a position's snapshots taken at or after since, oldest first
+func (d *Database) GetSnapshots(ctx context.Context, positionID, version string, since time.Time) ([]PositionSnapshot, error) {
+	rows, err := d.conn.QueryContext(ctx, `
+		SELECT `+snapshotColumns+` FROM position_snapshots
+		WHERE position_id = ? AND version = ? AND taken_at >= ?
+		ORDER BY taken_at`,
+		positionID, version, since.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanRows(rows, d.scanSnapshot)
+}
+
+// GetLatestSnapshots returns the most recent snapshot of every position
+func (d *Database) GetLatestSnapshots(ctx context.Context) ([]PositionSnapshot, error) {
+	// The joined columns are coalesced by USING, so the column names are unambiguous
+	rows, err := d.conn.QueryContext(ctx, `
+		SELECT `+snapshotColumns+`
+		FROM position_snapshots
+		JOIN (SELECT position_id, version, MAX(taken_at) AS taken_at FROM position_snapshots GROUP BY position_id, version) latest
+		USING (position_id, version, taken_at)`)
+	if err != nil {
+		return nil, err
+	}
+	return scanRows(rows, d.scanSnapshot)
+}
+
+// SaveFeeLedger appends the fee accrual observed at one refresh
+func (d *Database) SaveFeeLedger(ctx context.Context, entries []FeeLedgerEntry) error {
+	return d.transact(ctx, func(tx dbConn) error {
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO fee_ledger (position_id, version, wallet_address, fees0, fees1, period_start, period_end)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, e := range entries {
+			if _, err := stmt.ExecContext(ctx, e.PositionID, e.Version, d.addresses.seal(e.WalletAddress), e.Fees0, e.Fees1, e.From.UTC(), e.To.UTC()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetFeeLedger returns a position's ledger entries ending at or after since, oldest first
+func (d *Database) GetFeeLedger(ctx context.Context, positionID, version string, since time.Time) ([]FeeLedgerEntry, error) {
+	rows, err := d.conn.QueryContext(ctx, `
+		SELECT wallet_address, fees0, fees1, period_start, period_end FROM fee_ledger
+		WHERE position_id = ? AND version = ? AND period_end >= ?
+		ORDER BY period_end`,
+		positionID, version, since.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []FeeLedgerEntry
+	for rows.Next() {
+		e := FeeLedgerEntry{PositionID: positionID, Version: version}
+		if err := rows.Scan(&e.WalletAddress, &e.Fees0, &e.Fees1, &e.From, &e.To); err != nil {
+			return nil, err
+		}
+		if e.WalletAddress, err = d.addresses.open(e.WalletAddress); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// SaveCachedPositions remembers the V3 and V4 positions of a wallet as fetched at fetchedAt
+func (d *Database) SaveCachedPositions(ctx context.Context, walletAddress string, positions []uniswap.Position, fetchedAt time.Time) error {
+	return d.saveWalletPositions(ctx, "position_cache", walletAddress, positions, fetchedAt)
+}
+
+// GetCachedPositions returns the positions last cached for a wallet and when they were fetched.
+// It returns false if nothing is cached.
+func (d *Database) GetCachedPositions(ctx context.Context, walletAddress string) ([]uniswap.Position, time.Time, bool, error) {
+	return d.getWalletPositions(ctx, "position_cache", walletAddress)
+}
+
+// SaveLastSeenPositions records the positions the monitor last saw for a wallet, the baseline its next
+// check is compared with
+func (d *Database) SaveLastSeenPositions(ctx context.Context, walletAddress string, positions []uniswap.Position, seenAt time.Time) error {
+	return d.saveWalletPositions(ctx, "last_seen_positions", walletAddress, positions, seenAt)
+}
+
+// GetLastSeenPositions returns the positions the monitor last saw for a wallet and when. It returns
+// false if the monitor never checked the wallet.
+func (d *Database) GetLastSeenPositions(ctx context.Context, walletAddress string) ([]uniswap.Position, time.Time, bool, error) {
+	return d.getWalletPositions(ctx, "last_seen_positions", walletAddress)
+}
+
+// SaveLastSeenTrackedPosition records a tracked position as the monitor last saw it, the baseline
+// its next check is compared with
+func (d *Database) SaveLastSeenTrackedPosition(ctx context.Context, pos uniswap.Position, seenAt time.Time) error {
+	data, err := json.Marshal(pos)
+	if err != nil {
+		return err
+	}
+	sealed, err := d.addresses.sealPayload(string(data))
+	if err != nil {
+		return err
+	}
+	_, err = d.conn.ExecContext(ctx, `
+		INSERT INTO last_seen_tracked_positions (position_id, version, position, seen_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (position_id, version) DO UPDATE SET
+			position = excluded.position,
+			seen_at = excluded.seen_at`,
+		pos.ID.String(), string(pos.Version), sealed, seenAt.UTC(),
+	)
+	return err
+}
+
+// GetLastSeenTrackedPosition returns a tracked position as the monitor last saw it. It returns false
+// if the monitor never saw the position, or saw it gone.
+func (d *Database) GetLastSeenTrackedPosition(ctx context.Context, positionID, version string) (*uniswap.Position, bool, error) {
+	var data string
+	err := d.conn.QueryRowContext(ctx,
+		"SELECT position FROM last_seen_tracked_positions WHERE position_id = ? AND version = ?",
+		positionID, version,
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if data, err = d.addresses.open(data); err != nil {
+		return nil, false, err
+	}
+	var pos uniswap.Position
+	if err := json.Unmarshal([]byte(data), &pos); err != nil {
+		return nil, false, err
+	}
+	return &pos, true, nil
+}
+
+// DeleteLastSeenTrackedPosition forgets a tracked position the monitor saw gone
+func (d *Database) DeleteLastSeenTrackedPosition(ctx context.Context, positionID, version string) error {
+	_, err := d.conn.ExecContext(ctx,
+		"DELETE FROM last_seen_tracked_positions WHERE position_id = ? AND version = ?",
+		positionID, version,
+	)
+	return err
+}
+
+// SaveMonitorCursor records how far the monitor got with the events it follows under name
+func (d *Database) SaveMonitorCursor(ctx context.Context, name string, at time.Time) error {
+	_, err := d.conn.ExecContext(ctx,
+		"INSERT INTO monitor_cursors (name, at) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET at = excluded.at",
+		name, at.UTC(),
+	)
+	return err
+}
+
+// GetMonitorCursor returns the cursor saved under name, and false if none was saved
+func (d *Database) GetMonitorCursor(ctx context.Context, name string) (time.Time, bool, error) {
+	var at time.Time
+	err := d.conn.QueryRowContext(ctx,
+		"SELECT at FROM monitor_cursors WHERE name = ?",
+		name,
+	).Scan(&at)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return at, true, nil
+}
+
+// saveWalletPositions stores a wallet's positions as JSON in table, encrypted like the wallet's
+// address, since the positions name their owner
+func (d *Database) saveWalletPositions(ctx context.Context, table, walletAddress string, positions []uniswap.Position, fetchedAt time.Time) error {
+	data, err := json.Marshal(positions)
+	if err != nil {
+		return err
+	}
+	sealed, err := d.addresses.sealPayload(string(data))
+	if err != nil {
+		return err
+	}
+	_, err = d.conn.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (wallet_address, positions, fetched_at) VALUES (?, ?, ?)
+		ON CONFLICT (wallet_address) DO UPDATE SET
+			positions = excluded.positions,
+			fetched_at = excluded.fetched_at`, table),
+		d.addresses.seal(walletAddress), sealed, fetchedAt.UTC(),
+	)
+	return err
+}
+
+// getWalletPositions loads a wallet's positions stored by saveWalletPositions
+func (d *Database) getWalletPositions(ctx context.Context, table, walletAddress string) ([]uniswap.Position, time.Time, bool, error) {
+	var data string
+	var fetchedAt time.Time
+	err := d.conn.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT positions, fetched_at FROM %s WHERE wallet_address = ?", table),
+		d.addresses.seal(walletAddress),
+	).Scan(&data, &fetchedAt)
+	if err == sql.ErrNoRows {
+		return nil, time.Time{}, false, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+
+	if data, err = d.addresses.open(data); err != nil {
+		return nil, time.Time{}, false, err
+	}
+	var positions []uniswap.Position
+	if err := json.Unmarshal([]byte(data), &positions); err != nil {
+		return nil, time.Time{}, false, err
+	}
+	return positions, fetchedAt, true, nil
+}
+
+// LoadTokens returns the metadata of all tokens resolved so far
+func (d *Database) LoadTokens(ctx context.Context) ([]uniswap.TokenMetadata, error) {
+	rows, err := d.conn.QueryContext(ctx, "SELECT chain, address, symbol, decimals, logo_uri FROM tokens")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []uniswap.TokenMetadata
+	for rows.Next() {
+		var token uniswap.TokenMetadata
+		var address string
+		if err := rows.Scan(&token.Chain, &address, &token.Symbol, &token.Decimals, &token.LogoURI); err != nil {
+			return nil, err
+		}
+		token.Address = common.HexToAddress(address)
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// SaveTokens stores or updates token metadata
+func (d *Database) SaveTokens(ctx context.Context, tokens []uniswap.TokenMetadata) error {
+	return d.transact(ctx, func(tx dbConn) error {
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO tokens (chain, address, symbol, decimals, logo_uri) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (chain, address) DO UPDATE SET
+				symbol = excluded.symbol,
+				decimals = excluded.decimals,
+				logo_uri = CASE WHEN excluded.logo_uri = '' THEN tokens.logo_uri ELSE excluded.logo_uri END,
+				updated_at = CURRENT_TIMESTAMP`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, t := range tokens {
+			if _, err := stmt.ExecContext(ctx, t.Chain, t.Address.Hex(), t.Symbol, t.Decimals, t.LogoURI); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CreateAlertRule stores a new alert rule and returns its ID
+func (d *Database) CreateAlertRule(ctx context.Context, rule AlertRule) (int64, error) {
+	res, err := d.conn.ExecContext(ctx,
+		"INSERT INTO alert_rules (chat_id, type, target, threshold, cooldown_seconds, enabled) VALUES (?, ?, ?, ?, ?, ?)",
+		rule.ChatID, rule.Type, rule.Target, rule.Threshold, int64(rule.Cooldown.Seconds()), rule.Enabled,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetAlertRule returns one of the chat's alert rules. It returns false if the chat has no rule with that ID.
+func (d *Database) GetAlertRule(ctx context.Context, chatID, id int64) (AlertRule, bool, error) {
+	rules, err := d.queryAlertRules(ctx, "WHERE chat_id = ? AND id = ?", chatID, id)
+	if err != nil || len(rules) == 0 {
+		return AlertRule{}, false, err
+	}
+	return rules[0], true, nil
+}
+
+// GetAlertRules returns the chat's alert rules, oldest first
+func (d *Database) GetAlertRules(ctx context.Context, chatID int64) ([]AlertRule, error) {
+	return d.queryAlertRules(ctx, "WHERE chat_id = ? ORDER BY id", chatID)
+}
+
+// ListEnabledAlertRules returns the enabled alert rules of all chats
+func (d *Database) ListEnabledAlertRules(ctx context.Context) ([]AlertRule, error) {
+	return d.queryAlertRules(ctx, "WHERE enabled = 1 ORDER BY id")
+}
+
+func (d *Database) queryAlertRules(ctx context.Context, where string, args ...any) ([]AlertRule, error) {
+	rows, err := d.conn.QueryContext(ctx,
+		"SELECT "+alertRuleColumns+" FROM alert_rules "+where,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanRows(rows, scanAlertRule)
+}
+
+// UpdateAlertRule saves the threshold, cooldown and enabled state of one of the chat's alert rules.
+// It returns false if the chat has no rule with that ID.
+func (d *Database) UpdateAlertRule(ctx context.Context, rule AlertRule) (bool, error) {
+	res, err := d.conn.ExecContext(ctx,
+		"UPDATE alert_rules SET threshold = ?, cooldown_seconds = ?, enabled = ? WHERE chat_id = ? AND id = ?",
+		rule.Threshold, int64(rule.Cooldown.Seconds()), rule.Enabled, rule.ChatID, rule.ID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteAlertRule removes one of the chat's alert rules and its deliveries. It returns false if the chat has no rule with that ID.
+func (d *Database) DeleteAlertRule(ctx context.Context, chatID, id int64) (bool, error) {
+	var deleted bool
+	err := d.transact(ctx, func(tx dbConn) error {
+		res, err := tx.ExecContext(ctx,
+			"DELETE FROM alert_rules WHERE chat_id = ? AND id = ?",
+			chatID, id,
+		)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil || n == 0 {
+			return err
+		}
+		deleted = true
+		_, err = tx.ExecContext(ctx, "DELETE FROM alert_deliveries WHERE rule_id = ?", id)
+		return err
+	})
+	return deleted && err == nil, err
+}
+
+// MarkAlertRuleFired records when an alert rule last fired, for its cooldown
+func (d *Database) MarkAlertRuleFired(ctx context.Context, id int64, at time.Time) error {
+	_, err := d.conn.ExecContext(ctx,
+		"UPDATE alert_rules SET last_fired_at = ? WHERE id = ?",
+		at.UTC(), id,
+	)
+	return err
+}
+
+// RecordAlertDelivery appends an entry to the alert delivery ledger
+func (d *Database) RecordAlertDelivery(ctx context.Context, delivery AlertDelivery) error {
+	_, err := d.conn.ExecContext(ctx,
+		"INSERT INTO alert_deliveries (rule_id, position, triggered, recorded_at) VALUES (?, ?, ?, ?)",
+		delivery.RuleID, delivery.Position, delivery.Triggered, delivery.At.UTC(),
+	)
+	return err
+}
+
+// GetLatestAlertDeliveries returns the latest ledger entry of each rule and position
+func (d *Database) GetLatestAlertDeliveries(ctx context.Context) ([]AlertDelivery, error) {
+	rows, err := d.conn.QueryContext(ctx, `
+		SELECT rule_id, position, triggered, recorded_at FROM alert_deliveries
+		WHERE id IN (SELECT MAX(id) FROM alert_deliveries GROUP BY rule_id, position)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []AlertDelivery
+	for rows.Next() {
+		var delivery AlertDelivery
+		if err := rows.Scan(&delivery.RuleID, &delivery.Position, &delivery.Triggered, &delivery.At); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// EnqueueNotification queues text for delivery to the chat as soon as possible
+func (d *Database) EnqueueNotification(ctx context.Context, chatID int64, text string) error {
+	now := time.Now().UTC()
+	_, err := d.conn.ExecContext(ctx,
+		"INSERT INTO notifications (chat_id, text, next_attempt_at, created_at) VALUES (?, ?, ?, ?)",
+		chatID, text, now, now,
+	)
+	return err
+}
+
+// ClaimDueNotifications returns up to limit queued notifications due for an attempt at now, oldest
+// first, and makes them due again only at until, so no other run delivers them meanwhile.
+// Dead-lettered notifications are never due, and neither are notifications queued for a chat after
+// one waiting to be retried or claimed, so each chat receives its notifications in order.
+func (d *Database) ClaimDueNotifications(ctx context.Context, now, until time.Time, limit int) ([]Notification, error) {
+	var notifications []Notification
+	err := d.transact(ctx, func(tx dbConn) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT `+notificationColumns+` FROM notifications n
+			WHERE dead_at IS NULL AND next_attempt_at <= ?1
+			AND NOT EXISTS (
+				SELECT 1 FROM notifications earlier
+				WHERE earlier.chat_id = n.chat_id AND earlier.id < n.id
+				AND earlier.dead_at IS NULL AND earlier.next_attempt_at > ?1
+			)
+			ORDER BY id LIMIT ?2`,
+			now.UTC(), limit,
+		)
+		if err != nil {
+			return err
+		}
+		if notifications, err = scanRows(rows, scanNotification); err != nil {
+			return err
+		}
+
+		for _, n := range notifications {
+			if _, err := tx.ExecContext(ctx, "UPDATE notifications SET next_attempt_at = ? WHERE id = ?", until.UTC(), n.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return notifications, err
+}
+
+// ReleaseNotifications makes claimed notifications that weren't attempted due again at next
+func (d *Database) ReleaseNotifications(ctx context.Context, ids []int64, next time.Time) error {
+	return d.transact(ctx, func(tx dbConn) error {
+		for _, id := range ids {
+			if _, err := tx.ExecContext(ctx, "UPDATE notifications SET next_attempt_at = ? WHERE id = ?", next.UTC(), id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteNotification removes a delivered notification from the queue
+func (d *Database) DeleteNotification(ctx context.Context, id int64) error {
+	_, err := d.conn.ExecContext(ctx, "DELETE FROM notifications WHERE id = ?", id)
+	return err
+}
+
+// RetryNotification records a failed attempt to deliver a notification and when to try again
+func (d *Database) RetryNotification(ctx context.Context, id int64, next time.Time, lastError string) error {
+	_, err := d.conn.ExecContext(ctx,
+		"UPDATE notifications SET attempts = attempts + 1, next_attempt_at = ?, last_error = ? WHERE id = ?",
+		next.UTC(), lastError, id,
+	)
+	return err
+}
+
+// DeadLetterNotification records the last failed attempt to deliver a notification that won't be
+// tried again. It stays in the queue for inspection.
+func (d *Database) DeadLetterNotification(ctx context.Context, id int64, at time.Time, lastError string) error {
+	_, err := d.conn.ExecContext(ctx,
+		"UPDATE notifications SET attempts = attempts + 1, dead_at = ?, last_error = ? WHERE id = ?",
+		at.UTC(), lastError, id,
+	)
+	return err
+}
+
+// RecordUsage adds to the usage statistics: the users active on each day and counts to add to the counters
+func (d *Database) RecordUsage(ctx context.Context, activeUsers map[string][]int64, counters []UsageCounter) error {
+	return d.transact(ctx, func(tx dbConn) error {
+		users, err := tx.PrepareContext(ctx, "INSERT OR IGNORE INTO usage_users (day, user_id) VALUES (?, ?)")
+		if err != nil {
+			return err
+		}
+		defer users.Close()
+		for day, ids := range activeUsers {
+			for _, id := range ids {
+				if _, err := users.ExecContext(ctx, day, id); err != nil {
+					return err
+				}
+			}
+		}
+
+		counts, err := tx.PrepareContext(ctx, `
+			INSERT INTO usage_counters (day, kind, name, count) VALUES (?, ?, ?, ?)
+			ON CONFLICT (day, kind, name) DO UPDATE SET count = count + excluded.count`)
+		if err != nil {
+			return err
+		}
+		defer counts.Close()
+		for _, c := range counters {
+			if _, err := counts.ExecContext(ctx, c.Day, c.Kind, c.Name, c.Count); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetUsage returns the usage of each day since the given one (YYYY-MM-DD), newest first. Counters are
+// sorted by count, highest first.
+func (d *Database) GetUsage(ctx context.Context, since string) ([]DailyUsage, error) {
+	byDay := make(map[string]*DailyUsage)
+	day := func(name string) *DailyUsage {
+		if byDay[name] == nil {
+			byDay[name] = &DailyUsage{Day: name}
+		}
+		return byDay[name]
+	}
+
+	rows, err := d.conn.QueryContext(ctx,
+		"SELECT day, COUNT(*) FROM usage_users WHERE day >= ? GROUP BY day",
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var users int
+		if err := rows.Scan(&name, &users); err != nil {
+			return nil, err
+		}
+		day(name).ActiveUsers = users
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = d.conn.QueryContext(ctx,
+		"SELECT day, kind, name, count FROM usage_counters WHERE day >= ? ORDER BY count DESC, kind, name",
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c UsageCounter
+		if err := rows.Scan(&c.Day, &c.Kind, &c.Name, &c.Count); err != nil {
+			return nil, err
+		}
+		u := day(c.Day)
+		u.Counters = append(u.Counters, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	usage := make([]DailyUsage, 0, len(byDay))
+	for _, u := range byDay {
+		usage = append(usage, *u)
 	}
-	return wallets, nil
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Day > usage[j].Day })
+	return usage, nil
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+)
+
+func TestMemoryDBWallets(t *testing.T) {
+	db, err := newMemoryDB()
+	if err != nil {
+		t.Fatalf("newMemoryDB returned error: %v", err)
+	}
+	defer db.db.Close()
+
+	ctx := context.Background()
+	const chatID, userID = 1, 2
+	const wallet = "0x1111111111111111111111111111111111111111"
+	added, err := db.AddWallet(ctx, chatID, userID, wallet, "")
+	if err != nil || !added {
+		t.Fatalf("AddWallet = %v, %v, want true, nil", added, err)
+	}
+
+	wallets, err := db.GetChatWallets(ctx, chatID)
+	if err != nil {
+		t.Fatalf("GetChatWallets returned error: %v", err)
+	}
+	if len(wallets) != 1 || wallets[0].WalletAddress != wallet || wallets[0].UserID != userID {
+		t.Fatalf("GetChatWallets = %+v, want the added wallet", wallets)
+	}
+}
+
+func TestWalletLimitCountsUserAcrossChats(t *testing.T) {
+	db, err := newMemoryDB()
+	if err != nil {
+		t.Fatalf("newMemoryDB returned error: %v", err)
+	}
+	defer db.db.Close()
+	db.SetWalletLimit(1)
+
+	ctx := context.Background()
+	const groupID, userID, otherUserID = -100, 1, 2
+	if _, err := db.AddWallet(ctx, groupID, otherUserID, "0x1111111111111111111111111111111111111111", ""); err != nil {
+		t.Fatalf("AddWallet returned error: %v", err)
+	}
+	// Another member's wallet doesn't count against the user
+	if _, err := db.AddWallet(ctx, groupID, userID, "0x2222222222222222222222222222222222222222", ""); err != nil {
+		t.Fatalf("AddWallet returned error: %v", err)
+	}
+	// The user's wallet in the group counts in their private chat too
+	var limitErr *WalletLimitError
+	if _, err := db.AddWallet(ctx, userID, userID, "0x3333333333333333333333333333333333333333", ""); !errors.As(err, &limitErr) {
+		t.Fatalf("AddWallet returned %v, want a *WalletLimitError", err)
+	}
+}
+
+func TestEncryptedPositionCache(t *testing.T) {
+	db, err := newMemoryDB()
+	if err != nil {
+		t.Fatalf("newMemoryDB returned error: %v", err)
+	}
+	defer db.db.Close()
+
+	ctx := context.Background()
+	owner := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	positions := []uniswap.Position{{ID: big.NewInt(1), Version: uniswap.VersionV3, Owner: owner}}
+	if err := db.SaveCachedPositions(ctx, owner.Hex(), positions, time.Now()); err != nil {
+		t.Fatalf("SaveCachedPositions returned error: %v", err)
+	}
+
+	// Positions cached before encryption was enabled are encrypted along with the addresses
+	if err := db.EncryptAddresses(ctx, make([]byte, 32)); err != nil {
+		t.Fatalf("EncryptAddresses returned error: %v", err)
+	}
+	var stored string
+	if err := db.db.QueryRow("SELECT positions FROM position_cache").Scan(&stored); err != nil {
+		t.Fatalf("failed to read position_cache: %v", err)
+	}
+	if strings.Contains(strings.ToLower(stored), strings.ToLower(owner.Hex()[2:])) {
+		t.Errorf("position_cache holds the owner in plain text: %s", stored)
+	}
+
+	cached, _, ok, err := db.GetCachedPositions(ctx, owner.Hex())
+	if err != nil || !ok || len(cached) != 1 || cached[0].Owner != owner {
+		t.Fatalf("GetCachedPositions = %+v, %v, %v, want the cached position", cached, ok, err)
+	}
+}
+
+func TestLastSeenTrackedPosition(t *testing.T) {
+	db, err := newMemoryDB()
+	if err != nil {
+		t.Fatalf("newMemoryDB returned error: %v", err)
+	}
+	defer db.db.Close()
+
+	ctx := context.Background()
+	owner := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	pos := uniswap.Position{ID: big.NewInt(7), Version: uniswap.VersionV4, Owner: owner}
+	if err := db.SaveLastSeenTrackedPosition(ctx, pos, time.Now()); err != nil {
+		t.Fatalf("SaveLastSeenTrackedPosition returned error: %v", err)
+	}
+
+	seen, ok, err := db.GetLastSeenTrackedPosition(ctx, "7", string(uniswap.VersionV4))
+	if err != nil || !ok || seen.Owner != owner || seen.ID.Cmp(pos.ID) != 0 {
+		t.Fatalf("GetLastSeenTrackedPosition = %+v, %v, %v, want the saved position", seen, ok, err)
+	}
+	if _, ok, _ := db.GetLastSeenTrackedPosition(ctx, "7", string(uniswap.VersionV3)); ok {
+		t.Errorf("GetLastSeenTrackedPosition found the position under the other version")
+	}
+
+	if err := db.DeleteLastSeenTrackedPosition(ctx, "7", string(uniswap.VersionV4)); err != nil {
+		t.Fatalf("DeleteLastSeenTrackedPosition returned error: %v", err)
+	}
+	if _, ok, _ := db.GetLastSeenTrackedPosition(ctx, "7", string(uniswap.VersionV4)); ok {
+		t.Errorf("GetLastSeenTrackedPosition found the deleted position")
+	}
+}
+
+func TestClaimDueNotifications(t *testing.T) {
+	db, err := newMemoryDB()
+	if err != nil {
+		t.Fatalf("newMemoryDB returned error: %v", err)
+	}
+	defer db.db.Close()
+
+	ctx := context.Background()
+	for _, text := range []string{"first", "second"} {
+		if err := db.EnqueueNotification(ctx, 1, text); err != nil {
+			t.Fatalf("EnqueueNotification returned error: %v", err)
+		}
+	}
+
+	now := time.Now()
+	claimed, err := db.ClaimDueNotifications(ctx, now, now.Add(time.Minute), 1)
+	if err != nil || len(claimed) != 1 || claimed[0].Text != "first" {
+		t.Fatalf("ClaimDueNotifications = %+v, %v, want the first notification", claimed, err)
+	}
+
+	// While the first is claimed, neither it nor the chat's later notifications are due
+	if again, err := db.ClaimDueNotifications(ctx, now, now.Add(time.Minute), 10); err != nil || len(again) != 0 {
+		t.Fatalf("ClaimDueNotifications = %+v, %v, want none while claimed", again, err)
+	}
+
+	if err := db.ReleaseNotifications(ctx, []int64{claimed[0].ID}, now); err != nil {
+		t.Fatalf("ReleaseNotifications returned error: %v", err)
+	}
+	if again, err := db.ClaimDueNotifications(ctx, now, now.Add(time.Minute), 10); err != nil || len(again) != 2 {
+		t.Fatalf("ClaimDueNotifications = %+v, %v, want both once released", again, err)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// Deep-link payload prefixes, as in t.me/<bot>?start=add_0x1234...
+//
+// A bare address (or one prefixed with "status_") looks the wallet up once, "add_" adds it
+// to the chat's tracked wallets.
+const (
+	deepLinkAddPrefix    = "add_"
+	deepLinkStatusPrefix = "status_"
+)
+
+// handleStartPayload handles /start <payload> coming from a deep link. It returns false if
+// the payload isn't one of ours, in which case the regular /start flow continues.
+func (h *BotHandlers) handleStartPayload(reqCtx context.Context, b *gotgbot.Bot, ctx *ext.Context, payload string) (bool, error) {
+	switch {
+	case strings.HasPrefix(payload, deepLinkAddPrefix):
+		return true, h.addWalletFromLink(reqCtx, b, ctx, strings.TrimPrefix(payload, deepLinkAddPrefix))
+	case strings.HasPrefix(payload, deepLinkStatusPrefix):
+		return true, h.queryWalletFromLink(b, ctx, strings.TrimPrefix(payload, deepLinkStatusPrefix))
+	case strings.HasPrefix(payload, "0x"):
+		return true, h.queryWalletFromLink(b, ctx, payload)
+	}
+	return false, nil
+}
+
+func (h *BotHandlers) addWalletFromLink(reqCtx context.Context, b *gotgbot.Bot, ctx *ext.Context, walletAddress string) error {
+	h.log(ctx).Infow("Adding wallet from deep link", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id, "address", walletAddress)
+
+	// Only administrators may change what a group chat tracks
+	if allowed, err := h.requireChatAdmin(b, ctx); !allowed {
+		return err
+	}
+
+	address, err := parseWalletAddress(walletAddress)
+	if err != nil {
+		_, err := ctx.EffectiveMessage.Reply(b, "The link contains an invalid wallet address.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	added, err := h.db.AddWallet(reqCtx, ctx.EffectiveChat.Id, ctx.EffectiveUser.Id, address.Hex(), "")
+	if err != nil {
+		_, err := ctx.EffectiveMessage.Reply(b, h.addWalletFailure(ctx, err), &gotgbot.SendMessageOpts{})
+		return err
+	}
+	if !added {
+		msg := fmt.Sprintf("Wallet %s from the link you followed is already tracked. Use /status to see its positions.", address.Hex())
+		_, err := ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	msg := fmt.Sprintf("Wallet %s added from the link you followed.\nUse /status to see its positions or /remove_wallet %s to undo.", address.Hex(), address.Hex())
+	_, err = ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
+	return err
+}
+
+func (h *BotHandlers) queryWalletFromLink(b *gotgbot.Bot, ctx *ext.Context, walletAddress string) error {
+	h.log(ctx).Infow("Querying wallet from deep link", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id, "address", walletAddress)
+
+	statusMsg, err := ctx.EffectiveMessage.Reply(b, "Fetching Uniswap positions... This may take a moment.", &gotgbot.SendMessageOpts{})
+	if err != nil {
+		return err
+	}
+
+	return h.sendWalletStatus(b, ctx, statusMsg, walletAddress)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// deleteMeCallbackPrefix is followed by the ID of the user who asked for the deletion, or "cancel"
+const deleteMeCallbackPrefix = "delete_me:"
+
+func (h *BotHandlers) handleDeleteMe(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received delete_me command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	msg := `This deletes all data stored about you: your preferences and the wallets, positions, settings, share links and alert rules of your private chat with the bot.
+
+Wallets tracked in group chats belong to the group and are kept. This can't be undone.`
+	_, err := ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{
+			InlineKeyboard: [][]gotgbot.InlineKeyboardButton{{
+				{Text: "Delete my data", CallbackData: deleteMeCallbackPrefix + strconv.FormatInt(ctx.EffectiveUser.Id, 10)},
+				{Text: "Cancel", CallbackData: deleteMeCallbackPrefix + "cancel"},
+			}},
+		},
+	})
+	return err
+}
+
+func (h *BotHandlers) handleDeleteMeCallback(b *gotgbot.Bot, ctx *ext.Context) error {
+	cb := ctx.CallbackQuery
+	choice := strings.TrimPrefix(cb.Data, deleteMeCallbackPrefix)
+	h.log(ctx).Infow("Received delete_me callback", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id, "choice", choice)
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	if choice == "cancel" {
+		if _, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: "Nothing was deleted."}); err != nil {
+			return err
+		}
+		return h.editDeleteMeMessage(b, cb, "Deletion cancelled, nothing was deleted.")
+	}
+
+	// In group chats only the user who asked may confirm
+	if choice != strconv.FormatInt(cb.From.Id, 10) {
+		_, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: "Only the user who sent /delete_me can confirm it."})
+		return err
+	}
+
+	if err := h.db.DeleteUser(reqCtx, cb.From.Id); err != nil {
+		h.log(ctx).Errorw("Failed to delete user data", "user_id", cb.From.Id, "error", err)
+		_, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: "Failed to delete your data. Please try again later."})
+		return err
+	}
+	h.log(ctx).Infow("Deleted user data", "user_id", cb.From.Id)
+
+	if _, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: "Your data was deleted."}); err != nil {
+		return err
+	}
+	return h.editDeleteMeMessage(b, cb, "All data stored about you was deleted. Send /start to use the bot again.")
+}
+
+// editDeleteMeMessage replaces the confirmation prompt, removing its buttons
+func (h *BotHandlers) editDeleteMeMessage(b *gotgbot.Bot, cb *gotgbot.CallbackQuery, text string) error {
+	if cb.Message == nil {
+		return nil
+	}
+	_, _, err := cb.Message.EditText(b, text, &gotgbot.EditMessageTextOpts{})
+	if isMessageNotModified(err) {
+		return nil
+	}
+	return err
+}
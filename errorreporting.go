@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	// sentryFlushTimeout bounds sending the events still queued when the bot stops
+	sentryFlushTimeout = 5 * time.Second
+	// repeatedFailureThreshold is how many requests in a row to a subgraph fail before it is reported
+	repeatedFailureThreshold = 5
+)
+
+// initErrorReporting sends the errors reported below to the Sentry project of dsn. Without it
+// reporting does nothing. The returned function sends the events still queued.
+func initErrorReporting(dsn string) (flush func(), err error) {
+	if err := sentry.Init(sentry.ClientOptions{Dsn: dsn}); err != nil {
+		return nil, fmt.Errorf("failed to initialize Sentry: %w", err)
+	}
+	return func() { sentry.Flush(sentryFlushTimeout) }, nil
+}
+
+// reportError sends err to Sentry, tagged with tags
+func reportError(err error, tags map[string]string) {
+	sentry.WithScope(func(scope *sentry.Scope) {
+		scope.SetTags(tags)
+		sentry.CaptureException(err)
+	})
+}
+
+// reportHandlerError sends an error a handler returned to Sentry, with the user, chat and
+// command of the update it handled
+func reportHandlerError(ctx *ext.Context, err error) {
+	sentry.WithScope(func(scope *sentry.Scope) {
+		setUpdateScope(scope, ctx)
+		sentry.CaptureException(err)
+	})
+}
+
+// reportHandlerPanic sends a panic recovered from a handler to Sentry like reportHandlerError.
+// It must be called while recovering, so the stack trace shows where the panic happened.
+func reportHandlerPanic(ctx *ext.Context, r any) {
+	sentry.WithScope(func(scope *sentry.Scope) {
+		setUpdateScope(scope, ctx)
+		sentry.CurrentHub().Recover(r)
+	})
+}
+
+// setUpdateScope describes the update being handled: who sent it, in which chat, and the
+// command or button it is about
+func setUpdateScope(scope *sentry.Scope, ctx *ext.Context) {
+	if ctx == nil {
+		return
+	}
+	if id := updateCorrelationID(ctx); id != "" {
+		scope.SetTag("correlation_id", id)
+	}
+	if user := ctx.EffectiveUser; user != nil {
+		scope.SetUser(sentry.User{ID: strconv.FormatInt(user.Id, 10), Username: user.Username})
+	}
+	if chat := ctx.EffectiveChat; chat != nil {
+		scope.SetTag("chat_id", strconv.FormatInt(chat.Id, 10))
+		scope.SetTag("chat_type", chat.Type)
+	}
+	switch {
+	case ctx.CallbackQuery != nil:
+		scope.SetTag("callback_data", ctx.CallbackQuery.Data)
+	case ctx.InlineQuery != nil:
+		scope.SetTag("update", "inline_query")
+	case ctx.EffectiveMessage != nil && strings.HasPrefix(ctx.EffectiveMessage.Text, "/"):
+		command, _, _ := strings.Cut(ctx.EffectiveMessage.Text, " ")
+		scope.SetTag("command", command)
+	}
+}
+
+// graphFailureReporter reports requests to a subgraph that keep failing. A single failure is
+// usually a hiccup, so it reports once repeatedFailureThreshold requests in a row failed, and
+// again only after a request succeeded.
+type graphFailureReporter struct {
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+func newGraphFailureReporter() *graphFailureReporter {
+	return &graphFailureReporter{failures: make(map[string]int)}
+}
+
+// ObserveResponse counts the outcome of a request, it is a uniswap.ResponseObserver
+func (r *graphFailureReporter) ObserveResponse(subgraph string, err error) {
+	r.mu.Lock()
+	if err == nil {
+		delete(r.failures, subgraph)
+		r.mu.Unlock()
+		return
+	}
+	r.failures[subgraph]++
+	failures := r.failures[subgraph]
+	r.mu.Unlock()
+
+	if failures == repeatedFailureThreshold {
+		reportError(fmt.Errorf("%d requests in a row to the %s subgraph failed: %w", failures, subgraph, err),
+			map[string]string{"subgraph": subgraph})
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+)
+
+const chartFeesUsage = "Usage: /chart_fees <position id> [30|90]"
+
+func (h *BotHandlers) handleChartFees(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received chart_fees command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	args := ctx.Args()
+	if len(args) < 2 {
+		_, err := ctx.EffectiveMessage.Reply(b, chartFeesUsage, &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	id, ok := new(big.Int).SetString(args[1], 10)
+	if !ok || id.Sign() < 0 {
+		_, err := ctx.EffectiveMessage.Reply(b, "Invalid position. "+chartFeesUsage, &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	days := 30
+	if len(args) >= 3 {
+		switch args[2] {
+		case "30":
+		case "90":
+			days = 90
+		default:
+			_, err := ctx.EffectiveMessage.Reply(b, "The period must be 30 or 90 days. "+chartFeesUsage, &gotgbot.SendMessageOpts{})
+			return err
+		}
+	}
+
+	source, ok := h.uniswapClient.(uniswap.FeeHistorySource)
+	pricer, priced := h.uniswapClient.(uniswap.PriceProvider)
+	if !ok || !priced {
+		_, err := ctx.EffectiveMessage.Reply(b, "Fee charts are not supported by the configured data source.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	bgCtx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	// Fee history is only recorded by the V3 subgraph
+	pos, err := h.uniswapClient.GetPosition(bgCtx, uniswap.VersionV3, id)
+	if errors.Is(err, uniswap.ErrPositionNotFound) {
+		_, err := ctx.EffectiveMessage.Reply(b, fmt.Sprintf("V3 position %s not found. Fee charts are only available for V3 positions.", id.String()), &gotgbot.SendMessageOpts{})
+		return err
+	}
+	if err != nil {
+		h.log(ctx).Errorw("Failed to fetch position", "position_id", id.String(), "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to look up position. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	now := time.Now()
+	since := now.AddDate(0, 0, -days)
+
+	prices, err := pricer.GetTokenPricesUSD(bgCtx, uniswap.VersionV3, []common.Address{pos.Token0.Address, pos.Token1.Address})
+	if err != nil {
+		h.log(ctx).Errorw("Failed to price tokens", "position_id", id.String(), "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to fetch token prices. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+	price0, ok0 := prices[pos.Token0.Address]
+	price1, ok1 := prices[pos.Token1.Address]
+	if !ok0 || !ok1 {
+		_, err := ctx.EffectiveMessage.Reply(b, "The tokens of this position can't be priced in USD, so no chart can be drawn.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	// The fee ledger has the fees earned between the monitor's refreshes, collected or not, of
+	// positions in tracked wallets or tracked on their own. Other positions fall back to the fees
+	// the subgraph recorded as collected.
+	var points []chartPoint
+	var caption string
+	entries, err := h.db.GetFeeLedger(bgCtx, id.String(), string(uniswap.VersionV3), since)
+	if err != nil {
+		h.log(ctx).Warnw("Failed to get fee ledger", "position_id", id.String(), "error", err)
+	}
+	if len(entries) > 0 {
+		points = ledgerFeePoints(*pos, entries, price0, price1)
+		end := 0.0
+		if len(points) > 0 {
+			end = points[len(points)-1].value
+		}
+		caption = fmt.Sprintf("%s #%s: cumulative fees earned over the last %d days\nSince %s: $%.2f\nCollected or not, as seen by the bot's refreshes since %s, valued at current token prices.",
+			pos.Pair(), pos.ID.String(), days, since.Format("2006-01-02"), end, entries[0].From.Format("2006-01-02"))
+	} else {
+		history, err := source.GetFeeHistory(bgCtx, uniswap.VersionV3, id, since)
+		if err != nil {
+			h.log(ctx).Errorw("Failed to fetch fee history", "position_id", id.String(), "error", err)
+			_, err := ctx.EffectiveMessage.Reply(b, "Failed to fetch fee history. Please try again later.", &gotgbot.SendMessageOpts{})
+			return err
+		}
+		points = make([]chartPoint, 0, len(history))
+		for _, s := range history {
+			fees0, _ := s.CollectedFees0.Float64()
+			fees1, _ := s.CollectedFees1.Float64()
+			points = append(points, chartPoint{at: s.Timestamp, value: fees0*price0 + fees1*price1})
+		}
+
+		start, end := 0.0, 0.0
+		if len(points) > 0 {
+			end = points[len(points)-1].value
+			if !points[0].at.After(since) {
+				start = points[0].value
+			}
+		}
+		caption = fmt.Sprintf("%s #%s: cumulative fees collected over the last %d days\n%s: $%.2f\nNow: $%.2f (+$%.2f)\nValued at current token prices; unclaimed fees show up once collected. Track the position with /track_position to chart all fees it earns from now on.",
+			pos.Pair(), pos.ID.String(), days, since.Format("2006-01-02"), start, end, end-start)
+	}
+
+	chart, err := renderStepChart(points, since, now)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to render fee chart", "position_id", id.String(), "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to render chart. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	_, err = b.SendPhoto(ctx.EffectiveChat.Id, gotgbot.NamedFile{
+		File:     bytes.NewReader(chart),
+		FileName: "fees.png",
+	}, &gotgbot.SendPhotoOpts{
+		Caption:         caption,
+		ReplyParameters: &gotgbot.ReplyParameters{MessageId: ctx.EffectiveMessage.MessageId},
+	})
+	return err
+}
+
+// ledgerFeePoints adds up the fees of a position's fee ledger entries, valued in USD, into the
+// total at the end of each entry
+func ledgerFeePoints(pos uniswap.Position, entries []FeeLedgerEntry, price0, price1 float64) []chartPoint {
+	points := make([]chartPoint, 0, len(entries))
+	total := 0.0
+	for _, e := range entries {
+		fees0, ok0 := new(big.Int).SetString(e.Fees0, 10)
+		fees1, ok1 := new(big.Int).SetString(e.Fees1, 10)
+		if !ok0 || !ok1 {
+			continue
+		}
+		total += uniswap.TokenAmountUSD(fees0, pos.Token0, price0) + uniswap.TokenAmountUSD(fees1, pos.Token1, price1)
+		points = append(points, chartPoint{at: e.To, value: total})
+	}
+	return points
+}
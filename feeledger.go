@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"go.uber.org/zap"
+)
+
+// aprWindow is how much fee ledger history APR calculations look at
+const aprWindow = 7 * 24 * time.Hour
+
+// feeAccrual is the fees a position was observed to accrue over a period
+type feeAccrual struct {
+	fees0, fees1 *big.Int
+	period       time.Duration
+}
+
+// newFeeLedgerEntries returns the fees each position accrued since its previous snapshot. Positions
+// seen for the first time or with unknown fees have no entry.
+func newFeeLedgerEntries(previous, current []PositionSnapshot) []FeeLedgerEntry {
+	prev := make(map[string]PositionSnapshot, len(previous))
+	for _, s := range previous {
+		prev[snapshotKey(s)] = s
+	}
+
+	var entries []FeeLedgerEntry
+	for _, s := range current {
+		old, ok := prev[snapshotKey(s)]
+		if !ok || !s.TakenAt.After(old.TakenAt) {
+			continue
+		}
+		fees0, ok0 := feeDelta(old.Fees0, s.Fees0)
+		fees1, ok1 := feeDelta(old.Fees1, s.Fees1)
+		if !ok0 || !ok1 {
+			continue
+		}
+		entries = append(entries, FeeLedgerEntry{
+			PositionID:    s.PositionID,
+			Version:       s.Version,
+			WalletAddress: s.WalletAddress,
+			Fees0:         fees0.String(),
+			Fees1:         fees1.String(),
+			From:          old.TakenAt,
+			To:            s.TakenAt,
+		})
+	}
+	return entries
+}
+
+// snapshotKey identifies a snapshot's position like uniswap.PositionKey
+func snapshotKey(s PositionSnapshot) string {
+	return fmt.Sprintf("%s:%s", s.Version, s.PositionID)
+}
+
+// feeDelta returns how much a position's collected fees grew between two snapshots
+func feeDelta(previous, current string) (*big.Int, bool) {
+	prev, ok := new(big.Int).SetString(previous, 10)
+	if !ok {
+		return nil, false
+	}
+	cur, ok := new(big.Int).SetString(current, 10)
+	if !ok {
+		return nil, false
+	}
+	if cur.Cmp(prev) <= 0 {
+		return new(big.Int), true
+	}
+	return cur.Sub(cur, prev), true
+}
+
+// fetchFeeAccrual sums the fee ledger of each position since the given time, keyed by uniswap.PositionKey.
+// Positions without ledger entries are left out.
+func fetchFeeAccrual(ctx context.Context, db FeeLedgerStore, logger *zap.SugaredLogger, positions []uniswap.Position, since time.Time) map[string]feeAccrual {
+	accruals := make(map[string]feeAccrual)
+	for _, pos := range positions {
+		entries, err := db.GetFeeLedger(ctx, pos.ID.String(), string(pos.Version), since)
+		if err != nil {
+			logger.Warnw("Failed to get fee ledger", "position_id", pos.ID.String(), "version", pos.Version, "error", err)
+			continue
+		}
+
+		accrual := feeAccrual{fees0: new(big.Int), fees1: new(big.Int)}
+		for _, e := range entries {
+			fees0, ok0 := new(big.Int).SetString(e.Fees0, 10)
+			fees1, ok1 := new(big.Int).SetString(e.Fees1, 10)
+			if !ok0 || !ok1 {
+				continue
+			}
+			accrual.fees0.Add(accrual.fees0, fees0)
+			accrual.fees1.Add(accrual.fees1, fees1)
+			accrual.period += e.To.Sub(e.From)
+		}
+		if accrual.period > 0 {
+			accruals[uniswap.PositionKey(pos)] = accrual
+		}
+	}
+	return accruals
+}
+
+// annualizedFeesUSD extrapolates a position's fees to a year. It uses the accrual observed in the fee
+// ledger if there is any, and otherwise averages the fees collected so far over the position's age.
+func annualizedFeesUSD(pos uniswap.Position, prices map[common.Address]float64, accrual *feeAccrual) (float64, bool) {
+	price0, ok0 := prices[pos.Token0.Address]
+	price1, ok1 := prices[pos.Token1.Address]
+	if !ok0 || !ok1 {
+		return 0, false
+	}
+
+	fees0, fees1, period := pos.UnclaimedFees0, pos.UnclaimedFees1, time.Since(pos.CreatedAt)
+	if accrual != nil {
+		fees0, fees1, period = accrual.fees0, accrual.fees1, accrual.period
+	}
+	if period <= 0 {
+		return 0, false
+	}
+
+	fees := uniswap.TokenAmountUSD(fees0, pos.Token0, price0) + uniswap.TokenAmountUSD(fees1, pos.Token1, price1)
+	return fees * (365 * 24 * time.Hour).Hours() / period.Hours(), true
+}
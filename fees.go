@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"github.com/korjavin/uniswapfetcher/uniswap/filter"
+)
+
+const feesUsage = `Usage: /fees [filters]
+
+Filters, all optional:
+in_range or out_of_range
+$1000 (worth at least $1,000)
+token=WETH (repeat for any of several tokens)
+v3 or v4
+chain=ethereum
+
+e.g. /fees in_range token=WETH $1000`
+
+func (h *BotHandlers) handleFees(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received fees command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	// The quick-action button shares this handler, its label is not a filter
+	var filters positionFilters
+	if isCommand(ctx.EffectiveMessage) {
+		var err error
+		if filters, err = parsePositionFilterArgs(ctx.Args()[1:]); err != nil {
+			_, err := ctx.EffectiveMessage.Reply(b, fmt.Sprintf("Invalid filters: %v\n\n%s", err, feesUsage), &gotgbot.SendMessageOpts{})
+			return err
+		}
+	}
+
+	statusMsg, err := ctx.EffectiveMessage.Reply(b, "Fetching fees...", &gotgbot.SendMessageOpts{})
+	if err != nil {
+		return err
+	}
+
+	bgCtx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	positions, failed, err := fetchChatPositions(bgCtx, h.db, h.uniswapClient, h.logger, ctx.EffectiveChat.Id)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to fetch positions", "chat_id", ctx.EffectiveChat.Id, "error", err)
+		_, _, err := statusMsg.EditText(b, "Failed to fetch fees. Please try again later.", &gotgbot.EditMessageTextOpts{})
+		return err
+	}
+
+	prices := priceTokens(bgCtx, h.uniswapClient, positions, h.logger)
+	if !filters.empty() && len(positions) > 0 {
+		positions = filter.Apply(positions, filters.filter(prices))
+		if len(positions) == 0 {
+			_, _, err := statusMsg.EditText(b, "No positions match the filters.", &gotgbot.EditMessageTextOpts{})
+			return err
+		}
+	}
+
+	_, _, err = statusMsg.EditText(b, formatFees(positions, prices, failed), &gotgbot.EditMessageTextOpts{})
+	return err
+}
+
+// formatFees lists the fees collected by each position and their USD total where all tokens could be priced
+func formatFees(positions []uniswap.Position, prices map[common.Address]float64, failed int) string {
+	if len(positions) == 0 {
+		if failed > 0 {
+			return "Failed to fetch fees. Please try again later."
+		}
+		return "No positions found. Add a wallet with /add_wallet or a position with /track_position."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Collected fees\n\n")
+
+	var total float64
+	priced := true
+	for i, pos := range positions {
+		summary := uniswap.FormatPositionSummary(pos)
+		fmt.Fprintf(&sb, "%d. %s #%s: %s", i+1, pos.Pair(), summary.ID, summary.UnclaimedFees)
+
+		price0, ok0 := prices[pos.Token0.Address]
+		price1, ok1 := prices[pos.Token1.Address]
+		if ok0 && ok1 {
+			fees := uniswap.TokenAmountUSD(pos.UnclaimedFees0, pos.Token0, price0) + uniswap.TokenAmountUSD(pos.UnclaimedFees1, pos.Token1, price1)
+			fmt.Fprintf(&sb, " (%s)", formatUSD(fees, true))
+			total += fees
+		} else {
+			priced = false
+		}
+		sb.WriteString("\n")
+	}
+
+	if priced {
+		fmt.Fprintf(&sb, "\nTotal: %s", formatUSD(total, true))
+	} else {
+		fmt.Fprintf(&sb, "\nTotal: %s for the priced positions", formatUSD(total, true))
+	}
+	if failed > 0 {
+		fmt.Fprintf(&sb, "\n%d lookups failed, some positions may be missing.", failed)
+	}
+	return sb.String()
+}
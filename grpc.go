@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	uniswapfetcherv1 "github.com/korjavin/uniswapfetcher/proto/uniswapfetcher/v1"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcServiceName is the full name of the gRPC service, as in /uniswapfetcher.v1.UniswapFetcher/GetPool
+const grpcServiceName = "uniswapfetcher.v1.UniswapFetcher"
+
+const (
+	// defaultStreamInterval is how often StreamPositionUpdates fetches the positions unless asked otherwise
+	defaultStreamInterval = 5 * time.Minute
+	// minStreamInterval keeps streams from spending the Graph API quota too fast
+	minStreamInterval = time.Minute
+)
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec encodes gRPC messages as the JSON objects of the REST API. Clients select it with
+// the content subtype "json", i.e. the content type application/grpc+json, rather than grpc-go's
+// default protobuf codec.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	switch m := v.(type) {
+	case *uniswapfetcherv1.Portfolio:
+		return json.Marshal(apiPositionsResponseFromPB(m))
+	case *uniswapfetcherv1.Pool:
+		return json.Marshal(apiPoolFromPB(m))
+	case proto.Message:
+		return protojson.Marshal(m)
+	}
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes requests, whose JSON names are the camel case of the proto field names,
+// e.g. intervalSeconds
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	if m, ok := v.(proto.Message); ok {
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, m)
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string { return "json" }
+
+// GRPCServer serves the positions and pools the bot fetches over gRPC, for internal services
+// that would rather reuse the bot's fetching and caching than call The Graph themselves. It
+// shares the REST API's keys, and sends the messages of proto/uniswapfetcher/v1, converted from
+// the REST API's types, as protobuf or as JSON.
+type GRPCServer struct {
+	uniswapClient uniswap.Client
+	keys          apiKeySet
+	logger        *zap.SugaredLogger
+}
+
+func NewGRPCServer(uniswapClient uniswap.Client, apiKeys []string, logger *zap.SugaredLogger) *GRPCServer {
+	return &GRPCServer{uniswapClient: uniswapClient, keys: newAPIKeySet(apiKeys), logger: logger}
+}
+
+// grpcUniswapService is the handler type of grpcServiceDesc
+type grpcUniswapService interface {
+	GetPositions(ctx context.Context, req *uniswapfetcherv1.GetPositionsRequest) (*uniswapfetcherv1.Portfolio, error)
+	GetPool(ctx context.Context, req *uniswapfetcherv1.GetPoolRequest) (*uniswapfetcherv1.Pool, error)
+	StreamPositionUpdates(req *uniswapfetcherv1.StreamPositionUpdatesRequest, stream grpc.ServerStream) error
+}
+
+var grpcServiceDesc = grpc.ServiceDesc{
+	ServiceName: grpcServiceName,
+	HandlerType: (*grpcUniswapService)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetPositions",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				return handleUnary(srv.(grpcUniswapService).GetPositions, "GetPositions", ctx, dec, interceptor)
+			},
+		},
+		{
+			MethodName: "GetPool",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				return handleUnary(srv.(grpcUniswapService).GetPool, "GetPool", ctx, dec, interceptor)
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamPositionUpdates",
+			ServerStreams: true,
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				req := new(uniswapfetcherv1.StreamPositionUpdatesRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(grpcUniswapService).StreamPositionUpdates(req, stream)
+			},
+		},
+	},
+}
+
+// handleUnary decodes the request of a unary method and calls the method through interceptor
+func handleUnary[Req, Resp any](method func(context.Context, *Req) (*Resp, error), name string, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	req := new(Req)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return method(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/" + grpcServiceName + "/" + name}
+	return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+		return method(ctx, req.(*Req))
+	})
+}
+
+// Start serves the service on addr in the background
+func (s *GRPCServer) Start(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(s.authenticateUnary),
+		grpc.StreamInterceptor(s.authenticateStream),
+	)
+	server.RegisterService(&grpcServiceDesc, s)
+
+	go func() {
+		if err := server.Serve(ln); err != nil {
+			s.logger.Fatalf("gRPC server failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// authenticate accepts calls with an API key in "authorization: Bearer" or x-api-key metadata
+func (s *GRPCServer) authenticate(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var key string
+	for _, v := range md.Get("authorization") {
+		if k, ok := strings.CutPrefix(v, "Bearer "); ok {
+			key = k
+		}
+	}
+	if key == "" {
+		if v := md.Get("x-api-key"); len(v) > 0 {
+			key = v[0]
+		}
+	}
+	if !s.keys.valid(key) {
+		return status.Error(codes.Unauthenticated, "missing or invalid API key")
+	}
+	return nil
+}
+
+func (s *GRPCServer) authenticateUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := s.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *GRPCServer) authenticateStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authenticate(stream.Context()); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// GetPositions lists a wallet's positions
+func (s *GRPCServer) GetPositions(ctx context.Context, req *uniswapfetcherv1.GetPositionsRequest) (*uniswapfetcherv1.Portfolio, error) {
+	posReq, err := parsePositionRequest(req.GetWallet(), req.GetVersion())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	resp, err := s.fetchPositions(ctx, posReq)
+	if err != nil {
+		return nil, err
+	}
+	return pbPortfolio(resp), nil
+}
+
+// GetPool shows a pool by the ID parsePoolID accepts
+func (s *GRPCServer) GetPool(ctx context.Context, req *uniswapfetcherv1.GetPoolRequest) (*uniswapfetcherv1.Pool, error) {
+	source, ok := s.uniswapClient.(uniswap.PoolSource)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "pools are not available")
+	}
+	version, err := parsePoolID(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	pool, err := source.GetPool(ctx, version, req.GetId())
+	if errors.Is(err, uniswap.ErrPoolNotFound) {
+		return nil, status.Error(codes.NotFound, "pool not found")
+	}
+	if err != nil {
+		s.logger.Errorw("Failed to fetch pool", "pool", req.GetId(), "version", version, "error", err)
+		return nil, status.Error(codes.Unavailable, "failed to fetch pool")
+	}
+	return pbPool(newAPIPool(*pool)), nil
+}
+
+// StreamPositionUpdates sends a wallet's positions right away and then whenever they changed,
+// checking every IntervalSeconds until the client cancels the call. A failed fetch is logged
+// and retried at the next check rather than ending the stream.
+func (s *GRPCServer) StreamPositionUpdates(req *uniswapfetcherv1.StreamPositionUpdatesRequest, stream grpc.ServerStream) error {
+	posReq, err := parsePositionRequest(req.GetWallet(), req.GetVersion())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	interval := defaultStreamInterval
+	if req.GetIntervalSeconds() != 0 {
+		interval = time.Duration(req.GetIntervalSeconds()) * time.Second
+	}
+	if interval < minStreamInterval {
+		return status.Errorf(codes.InvalidArgument, "intervalSeconds must be at least %d", int(minStreamInterval.Seconds()))
+	}
+
+	ctx := stream.Context()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last *uniswapfetcherv1.Portfolio
+	for {
+		resp, err := s.fetchPositions(ctx, posReq)
+		if err == nil {
+			portfolio := pbPortfolio(resp)
+			if last == nil || !proto.Equal(portfolio, last) {
+				if err := stream.SendMsg(portfolio); err != nil {
+					return err
+				}
+				last = portfolio
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *GRPCServer) fetchPositions(ctx context.Context, req uniswap.PositionRequest) (apiPositionsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	positions, err := s.uniswapClient.GetPositions(ctx, req)
+	if err != nil && !errors.Is(err, uniswap.ErrWalletHasNoPositions) && !errors.Is(err, uniswap.ErrPartialPositions) {
+		s.logger.Errorw("Failed to fetch positions", "wallet", req.WalletAddress.Hex(), "error", err)
+		if errors.Is(err, uniswap.ErrRateLimited) {
+			return apiPositionsResponse{}, status.Error(codes.ResourceExhausted, "rate limited by The Graph")
+		}
+		return apiPositionsResponse{}, status.Error(codes.Unavailable, "failed to fetch positions")
+	}
+	return newAPIPositionsResponse(req, positions), nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	uniswapfetcherv1 "github.com/korjavin/uniswapfetcher/proto/uniswapfetcher/v1"
+)
+
+// The gRPC service sends the messages generated from proto/uniswapfetcher/v1, which mirror the
+// REST API's types. The functions below convert between the two, the pb ones for the protobuf
+// codec and the api ones so the json codec sends the REST API's JSON objects.
+
+func pbToken(t apiToken) *uniswapfetcherv1.Token {
+	return &uniswapfetcherv1.Token{Address: t.Address, Symbol: t.Symbol, Decimals: uint32(t.Decimals)}
+}
+
+func apiTokenFromPB(t *uniswapfetcherv1.Token) apiToken {
+	return apiToken{Address: t.GetAddress(), Symbol: t.GetSymbol(), Decimals: uint8(t.GetDecimals())}
+}
+
+func pbPosition(p apiPosition) *uniswapfetcherv1.Position {
+	return &uniswapfetcherv1.Position{
+		Id:             p.ID,
+		Version:        p.Version,
+		Owner:          p.Owner,
+		Token0:         pbToken(p.Token0),
+		Token1:         pbToken(p.Token1),
+		FeeTier:        p.FeeTier,
+		TickLower:      int32(p.TickLower),
+		TickUpper:      int32(p.TickUpper),
+		Liquidity:      p.Liquidity,
+		Amount0:        p.Amount0,
+		Amount1:        p.Amount1,
+		UnclaimedFees0: p.UnclaimedFees0,
+		UnclaimedFees1: p.UnclaimedFees1,
+		PriceLower:     p.PriceLower,
+		PriceUpper:     p.PriceUpper,
+		CurrentPrice:   p.CurrentPrice,
+		InRange:        p.InRange,
+		Pool:           p.Pool,
+		ChainId:        p.ChainID,
+	}
+}
+
+func apiPositionFromPB(p *uniswapfetcherv1.Position) apiPosition {
+	return apiPosition{
+		ID:             p.GetId(),
+		ChainID:        p.GetChainId(),
+		Version:        p.GetVersion(),
+		Owner:          p.GetOwner(),
+		Pool:           p.GetPool(),
+		Token0:         apiTokenFromPB(p.GetToken0()),
+		Token1:         apiTokenFromPB(p.GetToken1()),
+		FeeTier:        p.GetFeeTier(),
+		TickLower:      int(p.GetTickLower()),
+		TickUpper:      int(p.GetTickUpper()),
+		Liquidity:      p.GetLiquidity(),
+		Amount0:        p.GetAmount0(),
+		Amount1:        p.GetAmount1(),
+		UnclaimedFees0: p.GetUnclaimedFees0(),
+		UnclaimedFees1: p.GetUnclaimedFees1(),
+		PriceLower:     p.GetPriceLower(),
+		PriceUpper:     p.GetPriceUpper(),
+		CurrentPrice:   p.GetCurrentPrice(),
+		InRange:        p.GetInRange(),
+	}
+}
+
+// pbPortfolio converts the REST API's PositionsResponse
+func pbPortfolio(r apiPositionsResponse) *uniswapfetcherv1.Portfolio {
+	positions := make([]*uniswapfetcherv1.Position, len(r.Positions))
+	for i, pos := range r.Positions {
+		positions[i] = pbPosition(pos)
+	}
+	return &uniswapfetcherv1.Portfolio{Wallet: r.Wallet, Positions: positions}
+}
+
+func apiPositionsResponseFromPB(p *uniswapfetcherv1.Portfolio) apiPositionsResponse {
+	// Like the REST API, list no positions as [] rather than null
+	positions := make([]apiPosition, len(p.GetPositions()))
+	for i, pos := range p.GetPositions() {
+		positions[i] = apiPositionFromPB(pos)
+	}
+	return apiPositionsResponse{Wallet: p.GetWallet(), Positions: positions}
+}
+
+func pbPool(p apiPool) *uniswapfetcherv1.Pool {
+	return &uniswapfetcherv1.Pool{
+		Id:          p.ID,
+		Version:     p.Version,
+		Token0:      pbToken(p.Token0),
+		Token1:      pbToken(p.Token1),
+		FeeTier:     p.FeeTier,
+		Liquidity:   p.Liquidity,
+		SqrtPrice:   p.SqrtPrice,
+		Tick:        int32(p.Tick),
+		Token0Price: p.Token0Price,
+		Token1Price: p.Token1Price,
+		TvlUsd:      p.TVLUSD,
+		VolumeUsd:   p.VolumeUSD,
+		FeesUsd:     p.FeesUSD,
+	}
+}
+
+func apiPoolFromPB(p *uniswapfetcherv1.Pool) apiPool {
+	return apiPool{
+		ID:          p.GetId(),
+		Version:     p.GetVersion(),
+		Token0:      apiTokenFromPB(p.GetToken0()),
+		Token1:      apiTokenFromPB(p.GetToken1()),
+		FeeTier:     p.GetFeeTier(),
+		Liquidity:   p.GetLiquidity(),
+		SqrtPrice:   p.GetSqrtPrice(),
+		Tick:        int(p.GetTick()),
+		Token0Price: p.GetToken0Price(),
+		Token1Price: p.GetToken1Price(),
+		TVLUSD:      p.GetTvlUsd(),
+		VolumeUSD:   p.GetVolumeUsd(),
+		FeesUSD:     p.GetFeesUsd(),
+	}
+}
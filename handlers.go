@@ -2,116 +2,290 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/big"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers/filters/callbackquery"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers/filters/inlinequery"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/korjavin/uniswapfetcher/uniswap"
 	"go.uber.org/zap"
 )
 
+// trackPositionCallbackPrefix prefixes the Retry button of a failed /track_position lookup
+const trackPositionCallbackPrefix = "track_position:"
+
 type BotHandlers struct {
 	bot           *gotgbot.Bot
-	db            *Database
+	db            Store
 	uniswapClient uniswap.Client
 	logger        *zap.SugaredLogger
+
+	statusThrottle *commandThrottle
+
+	router *commandRouter
+
+	// publicURL is the base URL of the bot's HTTP server, share links are disabled if empty
+	publicURL string
+
+	// admins may run the commands that operate the bot itself, such as /backup and /admin_stats
+	admins map[int64]bool
+
+	usage *UsageTracker
+
+	// registry reloads the chains and known tokens for /reload_registry, nil without a registry file
+	registry *RegistryLoader
+
+	// metadata reads the pictures of position NFTs for /position, nil without an Ethereum node
+	metadata uniswap.PositionMetadataSource
+
+	// screener flags suspicious tokens in positions shown, nil if token screening is disabled
+	screener *uniswap.TokenScreener
+
+	// fetchConcurrency is how many wallets and positions /status fetches at the same time
+	fetchConcurrency int
 }
 
-func NewBotHandlers(bot *gotgbot.Bot, db *Database, uniswapClient uniswap.Client, logger *zap.SugaredLogger) *BotHandlers {
-	return &BotHandlers{
+func NewBotHandlers(bot *gotgbot.Bot, db Store, uniswapClient uniswap.Client, logger *zap.SugaredLogger, publicURL string, adminIDs []int64, usage *UsageTracker) *BotHandlers {
+	admins := make(map[int64]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		admins[id] = true
+	}
+
+	h := &BotHandlers{
 		bot:           bot,
 		db:            db,
 		uniswapClient: uniswapClient,
 		logger:        logger,
+		publicURL:     publicURL,
+		admins:        admins,
+		usage:         usage,
+
+		statusThrottle:   newCommandThrottle(statusCooldown),
+		fetchConcurrency: defaultFetchConcurrency,
 	}
+	h.router = newCommandRouter(h.commands())
+	return h
+}
+
+// SetFetchConcurrency sets how many wallets and positions /status fetches at the same time
+func (h *BotHandlers) SetFetchConcurrency(n int) {
+	h.fetchConcurrency = n
+}
+
+// SetRegistry lets bot administrators reload registry with /reload_registry
+func (h *BotHandlers) SetRegistry(registry *RegistryLoader) {
+	h.registry = registry
+}
+
+// SetPositionMetadataSource lets /position send the pictures of position NFTs
+func (h *BotHandlers) SetPositionMetadataSource(metadata uniswap.PositionMetadataSource) {
+	h.metadata = metadata
+}
+
+// SetTokenScreener makes /status and /position flag positions holding suspicious tokens
+func (h *BotHandlers) SetTokenScreener(screener *uniswap.TokenScreener) {
+	h.screener = screener
+}
+
+// defaultRequestTimeout bounds the work done for a single update unless COMMAND_TIMEOUT says otherwise
+const defaultRequestTimeout = 30 * time.Second
+
+// requestTimeout bounds the database and API work done for a single update, and likewise for a
+// request to the Mini App, share pages or APIs and for refreshing a wallet. It is set at startup.
+var requestTimeout = defaultRequestTimeout
+
+// defaultLookupTimeout bounds fetching one wallet or position unless LOOKUP_TIMEOUT says otherwise
+const defaultLookupTimeout = 10 * time.Second
+
+// lookupTimeout bounds fetching one wallet's positions or one tracked position within the work
+// for an update, so a slow subgraph answering for one of them doesn't use up requestTimeout and
+// leave the others unfetched. It is set at startup.
+var lookupTimeout = defaultLookupTimeout
+
+// newLookupContext returns the context bounding a single wallet or position lookup made while
+// handling an update with ctx
+func newLookupContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, lookupTimeout)
+}
+
+// newRequestContext returns the context bounding the work done for a single update. It carries the
+// update's correlation ID, and is part of the update's trace if the update is a traced command.
+func newRequestContext(update *ext.Context) (context.Context, context.CancelFunc) {
+	ctx := updateTraceContext(update)
+	if id := updateCorrelationID(update); id != "" {
+		ctx = uniswap.WithCorrelationID(ctx, id)
+	}
+	return context.WithTimeout(ctx, requestTimeout)
+}
+
+// log returns the handlers' logger annotated with the correlation ID of update
+func (h *BotHandlers) log(update *ext.Context) *zap.SugaredLogger {
+	return updateLogger(update, h.logger)
 }
 
 func (h *BotHandlers) RegisterHandlers(dispatcher *ext.Dispatcher) {
-	dispatcher.AddHandler(handlers.NewCommand("start", h.handleStart))
-	dispatcher.AddHandler(handlers.NewCommand("add_wallet", h.handleAddWallet))
-	dispatcher.AddHandler(handlers.NewCommand("remove_wallet", h.handleRemoveWallet))
-	dispatcher.AddHandler(handlers.NewCommand("list_wallets", h.handleListWallets))
-	dispatcher.AddHandler(handlers.NewCommand("status", h.handleStatus))
+	dispatcher.AddHandler(h.newOnboardingConversation())
+	h.router.Register(dispatcher, h.handleUnknownCommand)
+	dispatcher.AddHandler(h.newQuickActionHandler())
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix(statusCallbackPrefix), h.handleStatusCallback))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix(walletStatusCallbackPrefix), h.handleWalletStatusCallback))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix(trackPositionCallbackPrefix), h.handleTrackPositionCallback))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix(settingsCallbackPrefix), h.handleSettingsCallback))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix(walletQRCallbackPrefix), h.handleWalletQRCallback))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix(deleteMeCallbackPrefix), h.handleDeleteMeCallback))
+	dispatcher.AddHandler(handlers.NewInlineQuery(inlinequery.All, h.handleInlineQuery))
+	dispatcher.AddHandlerToGroup(h.usage.Handler(h.router.Resolve), usageGroup)
 }
 
 func (h *BotHandlers) handleStart(b *gotgbot.Bot, ctx *ext.Context) error {
-	h.logger.Infow("Received start command", "user_id", ctx.EffectiveUser.Id)
+	h.log(ctx).Infow("Received start command", "user_id", ctx.EffectiveUser.Id)
 
-	msg := `Welcome to Uniswap Position Tracker!
-Available commands:
-/add_wallet <address> - Add wallet to track
-/remove_wallet <address> - Remove wallet
-/list_wallets - Show tracked wallets
-/status - Show positions status`
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	// Deep links (t.me/<bot>?start=<payload>) arrive as /start <payload>
+	if args := ctx.Args(); len(args) >= 2 {
+		if handled, err := h.handleStartPayload(reqCtx, b, ctx, args[1]); handled {
+			return err
+		}
+	}
+
+	msg := "Welcome to Uniswap Position Tracker!\n\n" + formatHelp(h.commands(), false) + "\n\nSend /help for examples."
 
 	_, err := ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
-	return err
+	if err != nil {
+		return err
+	}
+
+	// Walk new users in private chats through the guided setup
+	if ctx.EffectiveChat.Type != gotgbot.ChatTypePrivate {
+		return nil
+	}
+	wallets, err := h.db.GetChatWallets(reqCtx, ctx.EffectiveChat.Id)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get wallets", "error", err)
+		return nil
+	}
+	if len(wallets) > 0 {
+		return nil
+	}
+	return h.promptOnboardingWallet(b, ctx)
 }
 
 func (h *BotHandlers) handleAddWallet(b *gotgbot.Bot, ctx *ext.Context) error {
-	h.logger.Infow("Received add_wallet command", "user_id", ctx.EffectiveUser.Id)
+	h.log(ctx).Infow("Received add_wallet command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
 
-	// Extract wallet address from command
-	args := ctx.Args()
-	h.logger.Debugw("Command arguments", "args", args)
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
 
-	// The first argument is the command itself, so we need at least 2 arguments
-	if len(args) < 2 {
-		_, err := ctx.EffectiveMessage.Reply(b, "Please provide a wallet address: /add_wallet <address>", &gotgbot.SendMessageOpts{})
+	// Only administrators may change what a group chat tracks
+	if allowed, err := h.requireChatAdmin(b, ctx); !allowed {
 		return err
 	}
 
-	walletAddress := args[1] // Use the second argument, which is the actual address
-
-	// Validate Ethereum address
-	h.logger.Debugw("Validating Ethereum address", "address", walletAddress, "isValid", common.IsHexAddress(walletAddress))
+	// Extract wallet address from command
+	args := ctx.Args()
+	h.log(ctx).Debugw("Command arguments", "args", args)
+
+	// Addresses may also come from an uploaded text/CSV file, either captioned with the
+	// command or replied to with it
+	inputs := args[1:]
+	doc := importDocument(ctx.EffectiveMessage)
+	if doc != nil {
+		fileInputs, err := downloadImportFile(b, doc)
+		if err != nil {
+			h.log(ctx).Errorw("Failed to read import file", "file_name", doc.FileName, "error", err)
+			_, err := ctx.EffectiveMessage.Reply(b, "Failed to read the file. Please upload a text or CSV file with one address per line.", &gotgbot.SendMessageOpts{})
+			return err
+		}
+		inputs = append(inputs, fileInputs...)
+	}
 
-	// Check if address has 0x prefix
-	if len(walletAddress) < 2 || walletAddress[:2] != "0x" {
-		h.logger.Debugw("Address missing 0x prefix", "address", walletAddress)
-		_, err := ctx.EffectiveMessage.Reply(b, "Ethereum address must start with '0x'. Please provide a valid address.", &gotgbot.SendMessageOpts{})
-		return err
+	// A trailing v3 or v4 restricts lookups of the added wallets to that version
+	var version string
+	if n := len(inputs); n > 0 {
+		if v, ok := parseVersionArg(inputs[n-1]); ok {
+			version = string(v)
+			inputs = inputs[:n-1]
+		}
 	}
 
-	// Check if address has correct length
-	if len(walletAddress) != 42 {
-		h.logger.Debugw("Address has incorrect length", "address", walletAddress, "length", len(walletAddress))
-		_, err := ctx.EffectiveMessage.Reply(b, "Ethereum address must be 42 characters long (including '0x' prefix). Please provide a valid address.", &gotgbot.SendMessageOpts{})
+	if len(inputs) == 0 {
+		msg := "Please provide a wallet address: /add_wallet <address> [v3|v4]"
+		if doc != nil {
+			msg = "No addresses found in the file."
+		}
+		_, err := ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
 		return err
 	}
+	if len(inputs) > 1 || doc != nil {
+		return h.addWallets(reqCtx, b, ctx, inputs, version)
+	}
 
-	// Use go-ethereum's validation
-	if !common.IsHexAddress(walletAddress) {
-		h.logger.Debugw("Address failed go-ethereum validation", "address", walletAddress)
-		_, err := ctx.EffectiveMessage.Reply(b, "Invalid Ethereum address format. Please provide a valid address.", &gotgbot.SendMessageOpts{})
+	walletAddress := inputs[0]
+
+	// Validate and normalize Ethereum address
+	address, err := parseWalletAddress(walletAddress)
+	if err != nil {
+		h.log(ctx).Debugw("Invalid Ethereum address", "address", walletAddress, "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, err.Error(), &gotgbot.SendMessageOpts{})
 		return err
 	}
-
-	// Normalize address
-	normalizedAddress := common.HexToAddress(walletAddress).Hex()
+	normalizedAddress := address.Hex()
 
 	// Add wallet to database
-	err := h.db.AddWallet(ctx.EffectiveUser.Id, normalizedAddress)
+	added, err := h.db.AddWallet(reqCtx, ctx.EffectiveChat.Id, ctx.EffectiveUser.Id, normalizedAddress, version)
 	if err != nil {
-		h.logger.Errorw("Failed to add wallet", "error", err)
-		_, err := ctx.EffectiveMessage.Reply(b, "Failed to add wallet. Please try again later.", &gotgbot.SendMessageOpts{})
+		_, err := ctx.EffectiveMessage.Reply(b, h.addWalletFailure(ctx, err), &gotgbot.SendMessageOpts{})
+		return err
+	}
+	if !added {
+		msg := fmt.Sprintf("Wallet %s is already tracked. Use /status to see its positions.", normalizedAddress)
+		_, err := ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
 		return err
 	}
 
-	_, err = ctx.EffectiveMessage.Reply(b, fmt.Sprintf("Wallet %s added successfully.", normalizedAddress), &gotgbot.SendMessageOpts{})
+	msg := fmt.Sprintf("Wallet %s added successfully.", normalizedAddress)
+	if version != "" {
+		msg = fmt.Sprintf("Wallet %s added successfully, only its %s positions will be looked up.", normalizedAddress, version)
+	}
+	_, err = ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
 	return err
 }
 
+// addWalletFailure logs why a wallet couldn't be added and returns the reply for the user
+func (h *BotHandlers) addWalletFailure(ctx *ext.Context, err error) string {
+	var limitErr *WalletLimitError
+	if errors.As(err, &limitErr) {
+		h.log(ctx).Infow("Wallet limit reached", "limit", limitErr.Limit)
+		return fmt.Sprintf("You can add at most %d wallets across all your chats. Remove one with /remove_wallet first.", limitErr.Limit)
+	}
+	h.log(ctx).Errorw("Failed to add wallet", "error", err)
+	return "Failed to add wallet. Please try again later."
+}
+
 func (h *BotHandlers) handleRemoveWallet(b *gotgbot.Bot, ctx *ext.Context) error {
-	h.logger.Infow("Received remove_wallet command", "user_id", ctx.EffectiveUser.Id)
+	h.log(ctx).Infow("Received remove_wallet command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	// Only administrators may change what a group chat tracks
+	if allowed, err := h.requireChatAdmin(b, ctx); !allowed {
+		return err
+	}
 
 	// Extract wallet address from command
 	args := ctx.Args()
-	h.logger.Debugw("Command arguments for remove_wallet", "args", args)
+	h.log(ctx).Debugw("Command arguments for remove_wallet", "args", args)
 
 	// The first argument is the command itself, so we need at least 2 arguments
 	if len(args) < 2 {
@@ -121,37 +295,19 @@ func (h *BotHandlers) handleRemoveWallet(b *gotgbot.Bot, ctx *ext.Context) error
 
 	walletAddress := args[1] // Use the second argument, which is the actual address
 
-	// Validate Ethereum address
-	h.logger.Debugw("Validating Ethereum address for removal", "address", walletAddress, "isValid", common.IsHexAddress(walletAddress))
-
-	// Check if address has 0x prefix
-	if len(walletAddress) < 2 || walletAddress[:2] != "0x" {
-		h.logger.Debugw("Address missing 0x prefix", "address", walletAddress)
-		_, err := ctx.EffectiveMessage.Reply(b, "Ethereum address must start with '0x'. Please provide a valid address.", &gotgbot.SendMessageOpts{})
-		return err
-	}
-
-	// Check if address has correct length
-	if len(walletAddress) != 42 {
-		h.logger.Debugw("Address has incorrect length", "address", walletAddress, "length", len(walletAddress))
-		_, err := ctx.EffectiveMessage.Reply(b, "Ethereum address must be 42 characters long (including '0x' prefix). Please provide a valid address.", &gotgbot.SendMessageOpts{})
-		return err
-	}
-
-	// Use go-ethereum's validation
-	if !common.IsHexAddress(walletAddress) {
-		h.logger.Debugw("Address failed go-ethereum validation", "address", walletAddress)
-		_, err := ctx.EffectiveMessage.Reply(b, "Invalid Ethereum address format. Please provide a valid address.", &gotgbot.SendMessageOpts{})
+	// Validate and normalize Ethereum address
+	address, err := parseWalletAddress(walletAddress)
+	if err != nil {
+		h.log(ctx).Debugw("Invalid Ethereum address for removal", "address", walletAddress, "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, err.Error(), &gotgbot.SendMessageOpts{})
 		return err
 	}
-
-	// Normalize address
-	normalizedAddress := common.HexToAddress(walletAddress).Hex()
+	normalizedAddress := address.Hex()
 
 	// Remove wallet from database
-	err := h.db.RemoveWallet(ctx.EffectiveUser.Id, normalizedAddress)
+	err = h.db.RemoveWallet(reqCtx, ctx.EffectiveChat.Id, normalizedAddress)
 	if err != nil {
-		h.logger.Errorw("Failed to remove wallet", "error", err)
+		h.log(ctx).Errorw("Failed to remove wallet", "error", err)
 		_, err := ctx.EffectiveMessage.Reply(b, "Failed to remove wallet. Please try again later.", &gotgbot.SendMessageOpts{})
 		return err
 	}
@@ -161,12 +317,15 @@ func (h *BotHandlers) handleRemoveWallet(b *gotgbot.Bot, ctx *ext.Context) error
 }
 
 func (h *BotHandlers) handleListWallets(b *gotgbot.Bot, ctx *ext.Context) error {
-	h.logger.Infow("Received list_wallets command", "user_id", ctx.EffectiveUser.Id)
+	h.log(ctx).Infow("Received list_wallets command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
 
 	// Get wallets from database
-	wallets, err := h.db.GetWallets(ctx.EffectiveUser.Id)
+	wallets, err := h.db.GetChatWallets(reqCtx, ctx.EffectiveChat.Id)
 	if err != nil {
-		h.logger.Errorw("Failed to get wallets", "error", err)
+		h.log(ctx).Errorw("Failed to get wallets", "error", err)
 		_, err := ctx.EffectiveMessage.Reply(b, "Failed to retrieve wallets. Please try again later.", &gotgbot.SendMessageOpts{})
 		return err
 	}
@@ -178,113 +337,385 @@ func (h *BotHandlers) handleListWallets(b *gotgbot.Bot, ctx *ext.Context) error
 	} else {
 		msg = "Your tracked wallets:\n\n"
 		for i, wallet := range wallets {
-			msg += fmt.Sprintf("%d. %s\n", i+1, wallet)
+			msg += fmt.Sprintf("%d. %s", i+1, wallet.DisplayName())
+			if wallet.Version != "" {
+				msg += fmt.Sprintf(" (%s only)", wallet.Version)
+			}
+			// Group members share the list, so point out their own additions
+			if ctx.EffectiveChat.Type != gotgbot.ChatTypePrivate && wallet.UserID == ctx.EffectiveUser.Id {
+				msg += " - added by you"
+			}
+			msg += "\n"
 		}
-		msg += "\nUse /status to check positions for these wallets."
+		msg += "\nUse /status to check positions for these wallets, or tap a QR button to get a wallet's QR code."
 	}
 
-	_, err = ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
+	opts := &gotgbot.SendMessageOpts{}
+	if len(wallets) > 0 {
+		opts.ReplyMarkup = walletQRKeyboard(wallets)
+	}
+	_, err = ctx.EffectiveMessage.Reply(b, msg, opts)
 	return err
 }
 
-func (h *BotHandlers) handleStatus(b *gotgbot.Bot, ctx *ext.Context) error {
-	h.logger.Infow("Received status command", "user_id", ctx.EffectiveUser.Id)
+func (h *BotHandlers) handleTrackPosition(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received track_position command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	// Only administrators may change what a group chat tracks
+	if allowed, err := h.requireChatAdmin(b, ctx); !allowed {
+		return err
+	}
+
+	args := ctx.Args()
+	h.log(ctx).Debugw("Command arguments for track_position", "args", args)
+
+	if len(args) < 2 {
+		_, err := ctx.EffectiveMessage.Reply(b, "Please provide a position ID: /track_position <id> [v3|v4]", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	return h.trackPosition(b, ctx, args[1:])
+}
+
+// handleTrackPositionCallback retries a /track_position lookup that failed
+func (h *BotHandlers) handleTrackPositionCallback(b *gotgbot.Bot, ctx *ext.Context) error {
+	cb := ctx.CallbackQuery
+	h.log(ctx).Infow("Received track_position retry", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id, "data", cb.Data)
+
+	if _, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: "Retrying..."}); err != nil {
+		return err
+	}
+
+	// Only administrators may change what a group chat tracks
+	if allowed, err := h.requireChatAdmin(b, ctx); !allowed {
+		return err
+	}
+
+	args := strings.Split(strings.TrimPrefix(cb.Data, trackPositionCallbackPrefix), ":")
+	return h.trackPosition(b, ctx, args)
+}
+
+// trackPosition looks up the position described by "<id> [v3|v4]" arguments and adds it
+// to the chat's tracked positions. Lookup failures get a Retry button.
+func (h *BotHandlers) trackPosition(b *gotgbot.Bot, ctx *ext.Context, args []string) error {
+	id, versions, ok := parsePositionArgs(args)
+	if !ok {
+		_, err := ctx.EffectiveMessage.Reply(b, "Invalid position. Usage: /track_position <id> [v3|v4]", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
 
-	// Send initial message
-	statusMsg, err := ctx.EffectiveMessage.Reply(b, "Fetching Uniswap positions... This may take a moment.", &gotgbot.SendMessageOpts{})
+	// Look the position up to make sure it exists, trying each candidate version in turn
+	var pos *uniswap.Position
+	for _, version := range versions {
+		p, err := h.uniswapClient.GetPosition(reqCtx, version, id)
+		if errors.Is(err, uniswap.ErrPositionNotFound) {
+			continue
+		}
+		if err != nil {
+			h.log(ctx).Errorw("Failed to fetch position", "position_id", id.String(), "version", version, "error", err)
+			_, err := ctx.EffectiveMessage.Reply(b, "Failed to look up position. Please try again later.", &gotgbot.SendMessageOpts{
+				ReplyMarkup: retryKeyboard(trackPositionCallbackPrefix + strings.Join(args, ":")),
+			})
+			return err
+		}
+		pos = p
+		break
+	}
+
+	if pos == nil {
+		_, err := ctx.EffectiveMessage.Reply(b, fmt.Sprintf("Position %s not found.", id.String()), &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	err := h.db.TrackPosition(reqCtx, ctx.EffectiveChat.Id, ctx.EffectiveUser.Id, pos.ID.String(), string(pos.Version))
 	if err != nil {
+		h.log(ctx).Errorw("Failed to track position", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to track position. Please try again later.", &gotgbot.SendMessageOpts{})
 		return err
 	}
 
-	// Get wallets from database
-	wallets, err := h.db.GetWallets(ctx.EffectiveUser.Id)
+	msg := fmt.Sprintf("Now tracking position:\n\n%s", formatPositionDetails(1, *pos))
+	_, err = ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
+	return err
+}
+
+func (h *BotHandlers) handleUntrackPosition(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received untrack_position command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	// Only administrators may change what a group chat tracks
+	if allowed, err := h.requireChatAdmin(b, ctx); !allowed {
+		return err
+	}
+
+	args := ctx.Args()
+	h.log(ctx).Debugw("Command arguments for untrack_position", "args", args)
+
+	if len(args) < 2 {
+		_, err := ctx.EffectiveMessage.Reply(b, "Please provide a position ID: /untrack_position <id> [v3|v4]", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	id, versions, ok := parsePositionArgs(args[1:])
+	if !ok {
+		_, err := ctx.EffectiveMessage.Reply(b, "Invalid position. Usage: /untrack_position <id> [v3|v4]", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	// Only restrict by version if the user explicitly asked for one
+	version := ""
+	if len(versions) == 1 {
+		version = string(versions[0])
+	}
+
+	removed, err := h.db.UntrackPosition(reqCtx, ctx.EffectiveChat.Id, id.String(), version)
 	if err != nil {
-		h.logger.Errorw("Failed to get wallets", "error", err)
-		_, _, err = statusMsg.EditText(b, "Failed to retrieve wallets. Please try again later.", &gotgbot.EditMessageTextOpts{})
+		h.log(ctx).Errorw("Failed to untrack position", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to untrack position. Please try again later.", &gotgbot.SendMessageOpts{})
 		return err
 	}
 
-	if len(wallets) == 0 {
-		_, _, err = statusMsg.EditText(b, "You don't have any wallets added yet. Use /add_wallet <address> to add one.", &gotgbot.EditMessageTextOpts{})
+	if !removed {
+		_, err = ctx.EffectiveMessage.Reply(b, fmt.Sprintf("Position %s is not being tracked.", id.String()), &gotgbot.SendMessageOpts{})
 		return err
 	}
 
-	// Create context with timeout
-	bgCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	_, err = ctx.EffectiveMessage.Reply(b, fmt.Sprintf("Position %s is no longer tracked.", id.String()), &gotgbot.SendMessageOpts{})
+	return err
+}
+
+func (h *BotHandlers) handleSwapAlerts(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received swap_alerts command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	reqCtx, cancel := newRequestContext(ctx)
 	defer cancel()
 
-	// Fetch positions for each wallet
-	var allPositions []uniswap.Position
-	for _, wallet := range wallets {
-		// Update status message
-		_, _, err = statusMsg.EditText(b, fmt.Sprintf("Fetching positions for wallet %s...", wallet), &gotgbot.EditMessageTextOpts{})
-		if err != nil {
-			h.logger.Warnw("Failed to update status message", "error", err)
+	settings, err := h.db.GetChatSettings(reqCtx, ctx.EffectiveChat.Id)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get chat settings", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to retrieve settings. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	args := ctx.Args()
+	if len(args) < 2 {
+		msg := "Swap alerts are off. Use /swap_alerts <usd> to get alerted about swaps of at least that size in the pools you provide liquidity to."
+		if settings.SwapAlertUSD > 0 {
+			msg = fmt.Sprintf("You get alerts for swaps of at least $%s in your pools. Use /swap_alerts off to disable them.", strconv.FormatFloat(settings.SwapAlertUSD, 'f', -1, 64))
 		}
+		_, err := ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	// Only administrators may change a group chat's alerts
+	if allowed, err := h.requireChatAdmin(b, ctx); !allowed {
+		return err
+	}
 
-		// Create position request
-		req := uniswap.PositionRequest{
-			WalletAddress: common.HexToAddress(wallet),
-			IncludeV3:     true,
-			IncludeV4:     true,
+	var threshold float64
+	if !strings.EqualFold(args[1], "off") {
+		threshold, err = strconv.ParseFloat(strings.TrimPrefix(args[1], "$"), 64)
+		if err != nil || threshold <= 0 {
+			_, err := ctx.EffectiveMessage.Reply(b, "Please provide a positive USD amount or 'off': /swap_alerts <usd|off>", &gotgbot.SendMessageOpts{})
+			return err
 		}
+	}
 
-		// Fetch positions
-		positions, err := h.uniswapClient.GetPositions(bgCtx, req)
-		if err != nil {
-			h.logger.Errorw("Failed to fetch positions", "wallet", wallet, "error", err)
-			continue
+	settings.SwapAlertUSD = threshold
+	if err := h.db.SaveChatSettings(reqCtx, ctx.EffectiveChat.Id, settings); err != nil {
+		h.log(ctx).Errorw("Failed to save chat settings", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to save settings. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	msg := "Swap alerts disabled."
+	if threshold > 0 {
+		msg = fmt.Sprintf("You'll be alerted about swaps of at least $%s in the V3 pools you provide liquidity to.", strconv.FormatFloat(threshold, 'f', -1, 64))
+		if !settings.AlertsEnabled {
+			msg += " Note that notifications are currently disabled for this chat, run /setup to enable them."
 		}
+	}
+	_, err = ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
+	return err
+}
 
-		allPositions = append(allPositions, positions...)
+// parseWalletAddress validates a user supplied Ethereum address. The returned error
+// is meant to be shown to the user as is.
+func parseWalletAddress(walletAddress string) (common.Address, error) {
+	// Check if address has 0x prefix
+	if len(walletAddress) < 2 || walletAddress[:2] != "0x" {
+		return common.Address{}, errors.New("Ethereum address must start with '0x'. Please provide a valid address.")
 	}
 
-	// Format response
-	var msg string
-	if len(allPositions) == 0 {
-		msg = "No Uniswap positions found for your wallets."
+	// Check if address has correct length
+	if len(walletAddress) != 42 {
+		return common.Address{}, errors.New("Ethereum address must be 42 characters long (including '0x' prefix). Please provide a valid address.")
+	}
+
+	// Use go-ethereum's validation
+	if !common.IsHexAddress(walletAddress) {
+		return common.Address{}, errors.New("Invalid Ethereum address format. Please provide a valid address.")
+	}
+
+	return common.HexToAddress(walletAddress), nil
+}
+
+// requireChatAdmin reports whether the sender may manage the chat's tracking lists.
+// Anyone may do so in a private chat, while in groups only administrators can. When
+// the sender is not allowed, a reply explaining why is sent.
+func (h *BotHandlers) requireChatAdmin(b *gotgbot.Bot, ctx *ext.Context) (bool, error) {
+	chat := ctx.EffectiveChat
+	if chat.Type == gotgbot.ChatTypePrivate || chat.Type == gotgbot.ChatTypeChannel {
+		return true, nil
+	}
+
+	// Anonymous administrators post on behalf of the group itself
+	if sender := ctx.EffectiveMessage.SenderChat; sender != nil && sender.Id == chat.Id {
+		return true, nil
+	}
+
+	member, err := b.GetChatMember(chat.Id, ctx.EffectiveUser.Id, nil)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get chat member", "chat_id", chat.Id, "user_id", ctx.EffectiveUser.Id, "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to verify your permissions. Please try again later.", &gotgbot.SendMessageOpts{})
+		return false, err
+	}
+
+	switch member.GetStatus() {
+	case "creator", "administrator":
+		return true, nil
+	}
+
+	h.log(ctx).Debugw("Rejected non-admin group command", "chat_id", chat.Id, "user_id", ctx.EffectiveUser.Id)
+	_, err = ctx.EffectiveMessage.Reply(b, "Only group administrators can change what this chat tracks.", &gotgbot.SendMessageOpts{})
+	return false, err
+}
+
+// parsePositionArgs parses "<id> [v3|v4]" command arguments. When no version is given
+// all supported versions are returned as candidates.
+func parsePositionArgs(args []string) (*big.Int, []uniswap.PositionVersion, bool) {
+	id, ok := new(big.Int).SetString(args[0], 10)
+	if !ok || id.Sign() < 0 {
+		return nil, nil, false
+	}
+
+	if len(args) < 2 {
+		return id, []uniswap.PositionVersion{uniswap.VersionV3, uniswap.VersionV4}, true
+	}
+
+	version, ok := parseVersionArg(args[1])
+	if !ok {
+		return nil, nil, false
+	}
+	return id, []uniswap.PositionVersion{version}, true
+}
+
+// parseVersionArg parses a "v3" or "v4" command argument
+func parseVersionArg(arg string) (uniswap.PositionVersion, bool) {
+	switch strings.ToUpper(arg) {
+	case string(uniswap.VersionV3):
+		return uniswap.VersionV3, true
+	case string(uniswap.VersionV4):
+		return uniswap.VersionV4, true
+	default:
+		return "", false
+	}
+}
+
+// formatPosition formats a single position according to the chat's display mode
+func formatPosition(n int, pos uniswap.Position, mode DisplayMode) string {
+	if mode == DisplayCompact {
+		return formatPositionLine(n, pos)
+	}
+	return formatPositionDetails(n, pos)
+}
+
+// formatPositionLine formats a single position as one numbered line, e.g.
+// "1. WETH/USDC 0.05% V3 #123, in range, fees 0.1 WETH, 250 USDC"
+func formatPositionLine(n int, pos uniswap.Position) string {
+	if warning := formatTokenWarning(pos); warning != "" {
+		return fmt.Sprintf("%d. %s, warning: %s\n", n, uniswap.FormatPositionCompact(pos), warning)
+	}
+	return fmt.Sprintf("%d. %s\n", n, uniswap.FormatPositionCompact(pos))
+}
+
+// formatPositionDetails formats a single position as a numbered multi-line block
+func formatPositionDetails(n int, pos uniswap.Position) string {
+	summary := uniswap.FormatPositionSummary(pos)
+
+	msg := fmt.Sprintf("%d. %s %s\n", n, summary.TokenPair, summary.Version)
+	msg += fmt.Sprintf("   ID: %s\n", summary.ID)
+	msg += fmt.Sprintf("   Created: %s\n", summary.CreatedAt)
+	msg += fmt.Sprintf("   Amounts: %s\n", summary.Amounts)
+	msg += fmt.Sprintf("   Price Range: %s\n", summary.PriceRange)
+	if utilization, ok := pos.RangeUtilization(); ok && summary.InRange {
+		msg += fmt.Sprintf("   In Range: true, price at %.0f%% of the range\n", utilization*100)
 	} else {
-		msg = fmt.Sprintf("Found %d Uniswap positions:\n\n", len(allPositions))
+		msg += fmt.Sprintf("   In Range: %v\n", summary.InRange)
+	}
+	msg += fmt.Sprintf("   Unclaimed Fees: %s\n", summary.UnclaimedFees)
+	if url := uniswap.PoolURL(pos); url != "" {
+		msg += fmt.Sprintf("   Pool: %s\n", url)
+	}
+	if warning := formatTokenWarning(pos); warning != "" {
+		msg += fmt.Sprintf("   Warning: %s\n", warning)
+	}
+	return msg + "\n"
+}
 
-		// Create a map to store positions by wallet
-		positionsByWallet := make(map[string][]uniswap.Position)
+// maxWalletLabelLength bounds wallet labels so listings stay readable
+const maxWalletLabelLength = 32
 
-		// Initialize the map with all wallets, even those with no positions
-		for _, wallet := range wallets {
-			positionsByWallet[wallet] = []uniswap.Position{}
-		}
+func (h *BotHandlers) handleLabel(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received label command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
 
-		// Group positions by wallet
-		for _, pos := range allPositions {
-			// Find which wallet this position belongs to
-			for _, wallet := range wallets {
-				if strings.EqualFold(pos.Owner.Hex(), wallet) {
-					positionsByWallet[wallet] = append(positionsByWallet[wallet], pos)
-					break
-				}
-			}
-		}
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
 
-		// Format each wallet's positions
-		for wallet, positions := range positionsByWallet {
-			msg += fmt.Sprintf("Wallet: %s\n", wallet)
-			msg += "--------------------\n"
-
-			for i, pos := range positions {
-				summary := uniswap.FormatPositionSummary(pos)
-
-				msg += fmt.Sprintf("%d. %s %s\n", i+1, summary.TokenPair, summary.Version)
-				msg += fmt.Sprintf("   ID: %s\n", summary.ID)
-				msg += fmt.Sprintf("   Created: %s\n", summary.CreatedAt)
-				msg += fmt.Sprintf("   Amounts: %s\n", summary.Amounts)
-				msg += fmt.Sprintf("   Price Range: %s\n", summary.PriceRange)
-				msg += fmt.Sprintf("   In Range: %v\n", summary.InRange)
-				msg += fmt.Sprintf("   Unclaimed Fees: %s\n\n", summary.UnclaimedFees)
-			}
-		}
+	// Only administrators may change what a group chat tracks
+	if allowed, err := h.requireChatAdmin(b, ctx); !allowed {
+		return err
+	}
+
+	args := ctx.Args()
+	if len(args) < 2 {
+		_, err := ctx.EffectiveMessage.Reply(b, "Please provide a wallet address and a label: /label <address> <label>, or just the address to remove its label.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	address, err := parseWalletAddress(args[1])
+	if err != nil {
+		_, err := ctx.EffectiveMessage.Reply(b, err.Error(), &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	label := strings.Join(args[2:], " ")
+	if len([]rune(label)) > maxWalletLabelLength {
+		_, err := ctx.EffectiveMessage.Reply(b, fmt.Sprintf("Labels can be at most %d characters long.", maxWalletLabelLength), &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	found, err := h.db.SetWalletLabel(reqCtx, ctx.EffectiveChat.Id, address.Hex(), label)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to set wallet label", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to save the label. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
 	}
 
-	// Update final message
-	_, _, err = statusMsg.EditText(b, msg, &gotgbot.EditMessageTextOpts{})
+	var msg string
+	switch {
+	case !found:
+		msg = fmt.Sprintf("Wallet %s is not tracked in this chat. Add it with /add_wallet first.", address.Hex())
+	case label == "":
+		msg = fmt.Sprintf("Label removed from %s.", address.Hex())
+	default:
+		msg = fmt.Sprintf("Wallet %s is now labeled %q.", address.Hex(), label)
+	}
+	_, err = ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
 	return err
 }
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"go.uber.org/zap"
+)
+
+// Paths of the health endpoints
+const (
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
+)
+
+// healthCheckTimeout bounds a single run of a health check
+const healthCheckTimeout = 10 * time.Second
+
+// healthFailureGrace is how long a critical check may fail before /healthz reports the bot as
+// unhealthy, so a short outage doesn't get the bot restarted
+const healthFailureGrace = 5 * time.Minute
+
+// Intervals of the health checks. Subgraph checks count against the Graph API quota, so they run rarely.
+const (
+	localHealthCheckInterval    = 30 * time.Second
+	subgraphHealthCheckInterval = 5 * time.Minute
+)
+
+// HealthCheck checks one dependency of the bot
+type HealthCheck struct {
+	Name string
+	// Interval is how often the check runs. Probes are answered with the latest result, so
+	// frequent probing doesn't add load on the dependency.
+	Interval time.Duration
+	// Critical checks are those restarting the bot may fix, e.g. a wedged connection. When one
+	// fails for longer than healthFailureGrace or stops running, /healthz fails.
+	Critical bool
+	Check    func(ctx context.Context) error
+}
+
+type healthResult struct {
+	OK        bool
+	Error     string
+	CheckedAt time.Time
+	// FailingSince is when the check started failing, zero while it passes
+	FailingSince time.Time
+}
+
+// HealthMonitor runs health checks in the background and serves their results for orchestrators:
+// /healthz fails when the bot should be restarted, /readyz whenever a dependency is failing.
+type HealthMonitor struct {
+	checks  []HealthCheck
+	started time.Time
+	logger  *zap.SugaredLogger
+
+	mu      sync.Mutex
+	results map[string]healthResult
+}
+
+func NewHealthMonitor(logger *zap.SugaredLogger, checks ...HealthCheck) *HealthMonitor {
+	return &HealthMonitor{
+		checks:  checks,
+		started: time.Now(),
+		logger:  logger,
+		results: make(map[string]healthResult),
+	}
+}
+
+// telegramHealthCheck checks every bot can reach the Telegram Bot API
+func telegramHealthCheck(bots *botSet) HealthCheck {
+	return HealthCheck{
+		Name:     "telegram",
+		Interval: localHealthCheckInterval,
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			for _, bot := range bots.bots {
+				if _, err := bot.GetMe(&gotgbot.GetMeOpts{RequestOpts: &gotgbot.RequestOpts{Timeout: healthCheckTimeout}}); err != nil {
+					return fmt.Errorf("%s: %w", bot.Username, err)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// databaseHealthCheck checks the database can be queried
+func databaseHealthCheck(db HealthStore) HealthCheck {
+	return HealthCheck{Name: "database", Interval: localHealthCheckInterval, Critical: true, Check: db.Ping}
+}
+
+// subgraphHealthCheck checks the subgraphs positions are fetched from respond. Restarting the bot
+// doesn't fix The Graph, so the check is not critical.
+func subgraphHealthCheck(client uniswap.HealthChecker) HealthCheck {
+	return HealthCheck{Name: "subgraphs", Interval: subgraphHealthCheckInterval, Check: client.CheckHealth}
+}
+
+// Jobs returns a scheduler job for each health check
+func (m *HealthMonitor) Jobs() []Job {
+	jobs := make([]Job, 0, len(m.checks))
+	for _, check := range m.checks {
+		jobs = append(jobs, Job{
+			Name:     "health check " + check.Name,
+			Interval: check.Interval,
+			Run: func(ctx context.Context) error {
+				m.run(ctx, check)
+				return nil
+			},
+		})
+	}
+	return jobs
+}
+
+// run runs check once and records the result. A failing check is a state to report, not an
+// error of the job.
+func (m *HealthMonitor) run(ctx context.Context, check HealthCheck) {
+	checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	err := check.Check(checkCtx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, checked := m.results[check.Name]
+	result := healthResult{OK: err == nil, CheckedAt: time.Now()}
+	if err != nil {
+		result.Error = err.Error()
+		result.FailingSince = previous.FailingSince
+		if result.FailingSince.IsZero() {
+			result.FailingSince = result.CheckedAt
+			requestLogger(ctx, m.logger).Warnw("Health check failing", "check", check.Name, "error", err)
+		}
+	} else if checked && !previous.OK {
+		requestLogger(ctx, m.logger).Infow("Health check recovered", "check", check.Name, "failed_for", result.CheckedAt.Sub(previous.FailingSince))
+	}
+	m.results[check.Name] = result
+}
+
+// Register adds the health endpoints to mux
+func (m *HealthMonitor) Register(mux *http.ServeMux) {
+	mux.HandleFunc(healthzPath, m.handleHealthz)
+	mux.HandleFunc(readyzPath, m.handleReadyz)
+}
+
+type healthResponse struct {
+	Status string                         `json:"status"`
+	Checks map[string]healthCheckResponse `json:"checks"`
+	Build  BuildInfo                      `json:"build"`
+}
+
+type healthCheckResponse struct {
+	OK           bool       `json:"ok"`
+	Error        string     `json:"error,omitempty"`
+	CheckedAt    time.Time  `json:"checked_at"`
+	FailingSince *time.Time `json:"failing_since,omitempty"`
+}
+
+func (m *HealthMonitor) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	healthy := true
+	results := m.snapshot()
+	for _, check := range m.checks {
+		if !check.Critical {
+			continue
+		}
+		result, checked := results[check.Name]
+		// A check that stopped reporting is as bad as a failing one, e.g. when the scheduler is stuck
+		lastSeen := result.CheckedAt
+		if !checked {
+			lastSeen = m.started
+		}
+		if now.Sub(lastSeen) > 3*check.Interval+healthCheckTimeout {
+			healthy = false
+		}
+		if checked && !result.OK && now.Sub(result.FailingSince) > healthFailureGrace {
+			healthy = false
+		}
+	}
+	m.writeResponse(w, healthy, results)
+}
+
+func (m *HealthMonitor) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ready := true
+	results := m.snapshot()
+	for _, check := range m.checks {
+		// Not ready until every dependency was seen working
+		if result, checked := results[check.Name]; !checked || !result.OK {
+			ready = false
+		}
+	}
+	m.writeResponse(w, ready, results)
+}
+
+func (m *HealthMonitor) snapshot() map[string]healthResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results := make(map[string]healthResult, len(m.results))
+	for name, result := range m.results {
+		results[name] = result
+	}
+	return results
+}
+
+func (m *HealthMonitor) writeResponse(w http.ResponseWriter, ok bool, results map[string]healthResult) {
+	resp := healthResponse{Status: "ok", Checks: make(map[string]healthCheckResponse, len(results)), Build: currentBuildInfo()}
+	for name, result := range results {
+		check := healthCheckResponse{OK: result.OK, Error: result.Error, CheckedAt: result.CheckedAt}
+		if !result.FailingSince.IsZero() {
+			check.FailingSince = &result.FailingSince
+		}
+		resp.Checks[name] = check
+	}
+	status := http.StatusOK
+	if !ok {
+		resp.Status = "unavailable"
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		m.logger.Warnw("Failed to write health response", "error", err)
+	}
+}
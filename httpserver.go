@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// HTTPServerConfig configures the bot's HTTP server, which serves the Telegram webhook and share pages.
+type HTTPServerConfig struct {
+	// ListenAddr is the local address the server binds to
+	ListenAddr string
+	// CertFile and KeyFile enable serving HTTPS directly instead of behind a TLS-terminating proxy
+	CertFile string
+	KeyFile  string
+}
+
+func (c HTTPServerConfig) validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("WEBHOOK_CERT_FILE and WEBHOOK_KEY_FILE must be set together")
+	}
+	return nil
+}
+
+// startHTTPServer starts serving handler in the background. Handlers may still be added to a
+// ServeMux after the server was started.
+func startHTTPServer(cfg HTTPServerConfig, handler http.Handler, logger *zap.SugaredLogger) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", cfg.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", cfg.ListenAddr, err)
+	}
+
+	server := &http.Server{
+		Handler:           handler,
+		ReadTimeout:       10 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		var err error
+		if cfg.CertFile != "" {
+			err = server.ServeTLS(ln, cfg.CertFile, cfg.KeyFile)
+		} else {
+			err = server.Serve(ln)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatalf("HTTP server failed: %v", err)
+		}
+	}()
+	return nil
+}
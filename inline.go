@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+)
+
+// inlineQueryTimeout bounds the subgraph lookup for an inline query. Telegram drops
+// inline queries that aren't answered within a few seconds, so this is much shorter
+// than the /status budget.
+const inlineQueryTimeout = 10 * time.Second
+
+// handleInlineQuery answers "@bot 0x..." queries with a compact summary of the wallet's
+// positions that can be shared into any chat without adding the wallet to tracking.
+func (h *BotHandlers) handleInlineQuery(b *gotgbot.Bot, ctx *ext.Context) error {
+	query := ctx.InlineQuery
+	h.log(ctx).Infow("Received inline query", "user_id", query.From.Id, "query", query.Query)
+
+	walletAddress := strings.TrimSpace(query.Query)
+
+	// Show a usage hint until the user has typed a complete address
+	if len(walletAddress) != 42 || !common.IsHexAddress(walletAddress) {
+		_, err := b.AnswerInlineQuery(query.Id, []gotgbot.InlineQueryResult{}, &gotgbot.AnswerInlineQueryOpts{
+			CacheTime: 1,
+			Button: &gotgbot.InlineQueryResultsButton{
+				Text:           "Type a wallet address (0x...)",
+				StartParameter: "inline",
+			},
+		})
+		return err
+	}
+
+	wallet := common.HexToAddress(walletAddress)
+
+	bgCtx, cancel := context.WithTimeout(context.Background(), inlineQueryTimeout)
+	defer cancel()
+
+	// Inline queries aren't tied to a chat, so the user's own preferences apply
+	settings, err := h.db.GetUserSettings(bgCtx, query.From.Id)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get user settings", "user_id", query.From.Id, "error", err)
+	}
+
+	positions, err := h.uniswapClient.GetPositions(bgCtx, uniswap.PositionRequest{
+		WalletAddress: wallet,
+		IncludeV3:     settings.IncludeV3,
+		IncludeV4:     settings.IncludeV4,
+		IncludeClosed: true,
+	})
+	if err != nil && !errors.Is(err, uniswap.ErrWalletHasNoPositions) && !errors.Is(err, uniswap.ErrPartialPositions) {
+		h.log(ctx).Errorw("Failed to fetch positions for inline query", "wallet", wallet.Hex(), "error", err)
+		_, err := b.AnswerInlineQuery(query.Id, []gotgbot.InlineQueryResult{}, &gotgbot.AnswerInlineQueryOpts{CacheTime: 1})
+		return err
+	}
+
+	title := fmt.Sprintf("%d Uniswap positions for %s", len(positions), shortAddress(wallet.Hex()))
+
+	var description string
+	if len(positions) == 0 {
+		description = "No open positions"
+	} else {
+		pairs := make([]string, 0, len(positions))
+		for _, pos := range positions {
+			pairs = append(pairs, fmt.Sprintf("%s/%s", pos.Token0.Symbol, pos.Token1.Symbol))
+		}
+		description = strings.Join(pairs, ", ")
+	}
+
+	result := gotgbot.InlineQueryResultArticle{
+		Id:          wallet.Hex(),
+		Title:       title,
+		Description: description,
+		InputMessageContent: gotgbot.InputTextMessageContent{
+			MessageText: formatWalletCard(wallet.Hex(), positions, settings.DisplayMode),
+		},
+	}
+
+	_, err = b.AnswerInlineQuery(query.Id, []gotgbot.InlineQueryResult{result}, &gotgbot.AnswerInlineQueryOpts{
+		CacheTime: 60,
+	})
+	return err
+}
+
+// formatWalletCard formats a compact, shareable summary of a wallet's positions
+func formatWalletCard(wallet string, positions []uniswap.Position, mode DisplayMode) string {
+	msg := fmt.Sprintf("Uniswap positions for %s\n", wallet)
+	if len(positions) == 0 {
+		return msg + "\nNo positions found."
+	}
+
+	msg += "\n"
+	for i, pos := range positions {
+		if mode == DisplayCompact {
+			msg += formatPosition(i+1, pos, mode)
+			continue
+		}
+
+		summary := uniswap.FormatPositionSummary(pos)
+
+		rangeMark := "out of range"
+		if summary.InRange {
+			rangeMark = "in range"
+		}
+		msg += fmt.Sprintf("%d. %s %s #%s, %s\n", i+1, summary.TokenPair, summary.Version, summary.ID, rangeMark)
+		msg += fmt.Sprintf("   %s | fees %s\n", summary.Amounts, summary.UnclaimedFees)
+	}
+	return msg
+}
+
+// shortAddress abbreviates an address to 0x1234...abcd
+func shortAddress(address string) string {
+	if len(address) < 10 {
+		return address
+	}
+	return address[:6] + "..." + address[len(address)-4:]
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Locker coordinates replicas of the bot, so work that must happen once, like refreshing tracked
+// wallets and alerting about changes, runs on a single replica
+type Locker interface {
+	// TryLock takes the lock name for ttl and reports whether it did. It fails to while another
+	// replica holds the lock, and extends the lock if this replica already holds it.
+	TryLock(ctx context.Context, name string, ttl time.Duration) (bool, error)
+	// Unlock releases the lock name if this replica holds it, so another can take it right away
+	Unlock(ctx context.Context, name string) error
+	// Close releases the connection to the lock service
+	Close() error
+}
+
+// redisLockPrefix namespaces the bot's locks among other keys in Redis
+const redisLockPrefix = "uniswapfetcher:lock:"
+
+// redisTryLock sets the lock to this replica's ID unless another replica holds it. Locks this
+// replica holds are extended instead, so the replica that took a lock keeps it while it runs.
+var redisTryLock = redis.NewScript(`
+local holder = redis.call("GET", KEYS[1])
+if holder == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if holder then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+// redisUnlock deletes the lock only if this replica holds it
+var redisUnlock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisLocker keeps locks in Redis, as keys that expire unless the replica holding them extends them
+type RedisLocker struct {
+	client *redis.Client
+	// id identifies this replica as the holder of its locks
+	id string
+}
+
+// NewRedisLocker connects to the Redis server at url, e.g. redis://localhost:6379/0
+func NewRedisLocker(ctx context.Context, url string) (*RedisLocker, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return &RedisLocker{client: client, id: newInstanceID()}, nil
+}
+
+func (l *RedisLocker) TryLock(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	n, err := redisTryLock.Run(ctx, l.client, []string{redisLockPrefix + name}, l.id, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to take lock %s: %w", name, err)
+	}
+	return n == 1, nil
+}
+
+func (l *RedisLocker) Unlock(ctx context.Context, name string) error {
+	if err := redisUnlock.Run(ctx, l.client, []string{redisLockPrefix + name}, l.id).Err(); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", name, err)
+	}
+	return nil
+}
+
+func (l *RedisLocker) Close() error {
+	return l.client.Close()
+}
+
+// newInstanceID returns an ID for this replica that is unique even among replicas on one host
+func newInstanceID() string {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Log formats
+const (
+	// logFormatJSON writes one JSON object per line for log collectors, the format for production
+	logFormatJSON = "json"
+	// logFormatConsole writes human readable lines, the format for development
+	logFormatConsole = "console"
+)
+
+// newLogger builds the root logger. JSON logs use zap's production settings, console logs its
+// development settings, which add stack traces to warnings. Subsystems log through named children
+// of it, e.g. "monitor", so their lines can be told apart.
+func newLogger(level, format string) (*zap.Logger, error) {
+	var cfg zap.Config
+	switch format {
+	case logFormatJSON:
+		cfg = zap.NewProductionConfig()
+	case logFormatConsole:
+		cfg = zap.NewDevelopmentConfig()
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Level = zap.NewAtomicLevelAt(lvl)
+	return cfg.Build()
+}
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/PaulSonOfLars/gotgbot/v2"
@@ -11,68 +16,328 @@ import (
 )
 
 func main() {
+	// Load and validate the configuration before touching anything
+	cfg, err := LoadConfig()
+	if err != nil {
+		// The configured logger can't be built without a valid configuration
+		bootstrap, _ := zap.NewDevelopment()
+		bootstrap.Sugar().Fatalf("Invalid configuration:\n%v", err)
+	}
+
 	// Initialize logger
-	logger, _ := zap.NewDevelopment()
+	logger, err := newLogger(cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		panic(err)
+	}
 	defer logger.Sync()
 	sugar := logger.Sugar()
+	sugar.Infow("Starting", "version", currentBuildInfo().String())
 
-	// Get environment variables
-	token := os.Getenv("TELEGRAM_TOKEN")
-	graphApiKey := os.Getenv("GRAPH_API_KEY")
-	if token == "" {
-		sugar.Fatal("TELEGRAM_TOKEN environment variable is required")
+	// Report errors to Sentry, so problems are noticed before users complain
+	if cfg.SentryDSN != "" {
+		flush, err := initErrorReporting(cfg.SentryDSN)
+		if err != nil {
+			sugar.Fatalf("Failed to initialize error reporting: %v", err)
+		}
+		defer flush()
+		sugar.Info("Reporting errors to Sentry")
 	}
-	if graphApiKey == "" {
-		sugar.Fatal("GRAPH_API_KEY environment variable is required")
+
+	// Trace commands, scheduled jobs and queries to The Graph
+	if cfg.OTLPEndpoint != "" {
+		shutdown, err := initTracing(context.Background(), cfg.OTLPEndpoint)
+		if err != nil {
+			sugar.Fatalf("Failed to initialize tracing: %v", err)
+		}
+		defer shutdown()
+		sugar.Infow("Exporting traces", "endpoint", cfg.OTLPEndpoint)
 	}
 
 	// Initialize database
-	db, err := initDB()
+	// Replace the database with a backup first if asked to, e.g. one sent by /backup
+	if cfg.RestoreFrom != "" {
+		if err := restoreDB(cfg.DBPath, cfg.RestoreFrom); err != nil {
+			sugar.Fatalf("Failed to restore database: %v", err)
+		}
+		sugar.Infow("Restored database from backup", "backup", cfg.RestoreFrom, "db_path", cfg.DBPath)
+	}
+	db, err := initDB(cfg.DBPath)
 	if err != nil {
 		sugar.Fatalf("Failed to initialize database: %v", err)
 	}
+	if cfg.DBPath == memoryDBPath {
+		sugar.Warn("Using an in-memory database, all data is lost when the bot stops")
+	}
+	db.SetWalletLimit(cfg.MaxWalletsPerUser)
+	if key := cfg.EncryptionKey(); key != nil {
+		if err := db.EncryptAddresses(context.Background(), key); err != nil {
+			sugar.Fatalf("Failed to enable wallet address encryption: %v", err)
+		}
+		sugar.Info("Wallet address encryption enabled")
+	}
 
 	// Initialize Uniswap client with API calls instead of Infura
-	uniswapClient, err := uniswap.NewAPIClient(sugar, graphApiKey)
+	apiClient, err := uniswap.NewAPIClient(sugar.Named("uniswap"), cfg.GraphAPIKey)
 	if err != nil {
 		sugar.Fatalf("Failed to initialize Uniswap client: %v", err)
 	}
+	defer apiClient.Close()
+	apiClient.SetQueryTimeout(cfg.SubgraphTimeout)
+	requestTimeout = cfg.CommandTimeout
+	lookupTimeout = cfg.LookupTimeout
+
+	// Fetch positions from the configured backends, e.g. fixture files instead of the subgraphs
+	uniswapClient, err := newBackend(cfg.Backends, backendDeps{cfg: cfg, logger: sugar, subgraph: apiClient})
+	if err != nil {
+		sugar.Fatalf("Failed to initialize backends: %v", err)
+	}
 	defer uniswapClient.Close()
+	sugar.Infow("Fetching positions", "backends", cfg.Backends)
 
-	// Initialize bot with increased timeout
-	bot, err := gotgbot.NewBot(token, &gotgbot.BotOpts{
-		RequestOpts: &gotgbot.RequestOpts{
-			Timeout: 60 * time.Second, // Increase timeout to 60 seconds
-		},
+	// Background jobs are added as their components are set up and started once the bot is
+	scheduler := NewScheduler(sugar.Named("scheduler"))
+
+	// Configure chains and known tokens from a file and token lists that can change while the bot runs
+	var registry *RegistryLoader
+	if cfg.RegistryFile != "" || len(cfg.TokenLists) > 0 {
+		registry = NewRegistryLoader(cfg.RegistryFile, cfg.TokenLists, apiClient, sugar.Named("registry"))
+		chains, tokens, err := registry.Load(context.Background())
+		if errors.Is(err, errStaleTokenLists) {
+			// A list host being down shouldn't keep the bot from starting, the lists are
+			// read again soon
+			sugar.Warnw("Failed to load token lists", "error", err)
+		} else if err != nil {
+			sugar.Fatalf("Failed to load registry: %v", err)
+		}
+		scheduler.Add(registry.Job())
+		sugar.Infow("Loaded registry", "path", cfg.RegistryFile, "token_lists", len(cfg.TokenLists), "chains", chains, "tokens", tokens)
+	}
+	if cfg.RedisURL != "" {
+		// Let only one replica of the bot refresh tracked wallets and send alerts
+		locker, err := NewRedisLocker(context.Background(), cfg.RedisURL)
+		if err != nil {
+			sugar.Fatalf("Failed to initialize locks: %v", err)
+		}
+		defer locker.Close()
+		scheduler.SetLocker(locker)
+	}
+
+	// Count users, commands and Graph API requests to plan the API quota
+	usage := NewUsageTracker(db, sugar.Named("usage"))
+	apiClient.SetRequestObserver(usage.CountGraphRequest)
+	failureReporter := newGraphFailureReporter()
+	var sourceAlerter *dataSourceAlerter
+	if len(cfg.AdminUserIDs) > 0 {
+		// Tell the administrators on Telegram when a subgraph keeps failing
+		sourceAlerter = newDataSourceAlerter(cfg.AdminUserIDs, cfg.GraphAPIKey, sugar.Named("alerts"))
+	}
+	apiClient.SetResponseObserver(func(subgraph string, err error) {
+		failureReporter.ObserveResponse(subgraph, err)
+		if sourceAlerter != nil {
+			sourceAlerter.ObserveResponse(subgraph, err)
+		}
 	})
+	scheduler.Add(usage.Job())
+
+	// Remember token metadata across restarts
+	if err := apiClient.SetTokenCache(context.Background(), db); err != nil {
+		sugar.Warnw("Failed to load token metadata", "error", err)
+	}
+
+	// Read position NFTs from an Ethereum node, if one is configured
+	var rpcClient *uniswap.RPCClient
+	if cfg.EthRPCURL != "" {
+		rpcClient = uniswap.NewRPCClient(sugar.Named("rpc"), cfg.EthRPCURL, cfg.RPCTimeout)
+	}
+
+	// Check the bot can use Telegram, The Graph and the Ethereum node with its credentials before relying on them
+	if cfg.SkipSelfTest {
+		sugar.Warn("Skipping the self-test")
+	} else if err := runSelfTest(sugar.Named("selftest"), selfTestChecks(cfg, apiClient, rpcClient)...); err != nil {
+		sugar.Fatalf("Self-test failed:\n%v", err)
+	}
+
+	// Serve the primary bot and any further ones, e.g. a beta bot, with the same handlers and storage
+	tokens := append([]string{cfg.TelegramToken}, cfg.ExtraTelegramTokens...)
+	botList, err := newBots(tokens, cfg.DryRun, cfg.TelegramTimeout, sugar)
 	if err != nil {
 		sugar.Fatalf("Failed to create bot: %v", err)
 	}
+	bots := newBotSet(botList)
+	if cfg.DryRun {
+		sugar.Warn("Dry run, messages are logged instead of sent")
+	}
 
 	// Create dispatcher
 	dispatcher := ext.NewDispatcher(&ext.DispatcherOpts{
 		Error: func(b *gotgbot.Bot, ctx *ext.Context, err error) ext.DispatcherAction {
-			sugar.Errorw("Error in handler", "error", err)
+			updateLogger(ctx, sugar).Errorw("Error in handler", "error", err)
+			reportHandlerError(ctx, err)
 			return ext.DispatcherActionNoop
 		},
+		Panic: handlePanic(sugar),
 	})
 
 	// Create updater
 	updater := ext.NewUpdater(dispatcher, &ext.UpdaterOpts{})
 
+	// Tag every update with a correlation ID before anything logs about it
+	dispatcher.AddHandlerToGroup(correlationHandler{}, correlationGroup)
+
+	// Remember which bot each chat talks to, so notifications reach it through that bot
+	if len(botList) > 1 {
+		dispatcher.AddHandlerToGroup(newChatBotTracker(db, sugar.Named("bots")), chatBotGroup)
+		sugar.Infow("Serving several bots", "bots", len(botList))
+	}
+
+	// Refuse strangers before any other handler runs if this is a private deployment
+	accessGuard := NewAccessGuard(cfg.AllowedUserIDs, cfg.InviteCode, db, sugar.Named("access"))
+	if accessGuard.Enabled() {
+		dispatcher.AddHandlerToGroup(accessGuard, -1)
+		sugar.Infow("Access control enabled", "allowed_users", len(cfg.AllowedUserIDs), "invite_code", cfg.InviteCode != "")
+	}
+
 	// Setup handlers
-	handlers := NewBotHandlers(bot, db, uniswapClient, sugar)
+	handlers := NewBotHandlers(bots.Primary(), db, uniswapClient, sugar.Named("handlers"), cfg.PublicURL, cfg.AdminUserIDs, usage)
+	handlers.SetFetchConcurrency(cfg.FetchConcurrency)
+	handlers.SetRegistry(registry)
+	if rpcClient != nil {
+		handlers.SetPositionMetadataSource(rpcClient)
+	}
+	if len(cfg.TokenBlocklists) > 0 || cfg.EtherscanAPIKey != "" {
+		// Flag honeypot tokens, and unlisted ones whose contracts aren't verified
+		screener := uniswap.NewTokenScreener(sugar.Named("safety"), apiClient.IsKnownToken)
+		if cfg.EtherscanAPIKey != "" {
+			screener.SetVerifier(uniswap.NewEtherscanVerifier(cfg.EtherscanAPIKey, uniswap.ChainIDEthereum))
+		}
+		if len(cfg.TokenBlocklists) > 0 {
+			blocklists := NewTokenBlocklistLoader(cfg.TokenBlocklists, screener, sugar.Named("safety"))
+			if tokens, err := blocklists.Load(context.Background()); err != nil {
+				sugar.Warnw("Failed to load token blocklists", "error", err)
+			} else {
+				sugar.Infow("Loaded token blocklists", "tokens", tokens)
+			}
+			scheduler.Add(blocklists.Job())
+		}
+		handlers.SetTokenScreener(screener)
+	}
 	handlers.RegisterHandlers(dispatcher)
+	for _, bot := range botList {
+		if err := handlers.syncBotCommands(bot); err != nil {
+			sugar.Warnw("Failed to sync bot commands", "bot", bot.Username, "error", err)
+		}
+	}
 
-	// Start bot
-	sugar.Info("Bot started successfully")
-	err = updater.StartPolling(bot, &ext.PollingOpts{
-		DropPendingUpdates: true,
-	})
-	if err != nil {
-		sugar.Fatalf("Failed to start polling: %v", err)
+	// Report the health of the bot's dependencies to orchestrators on a listener of its own, which
+	// unlike the public HTTP server shouldn't be exposed
+	if cfg.HealthListenAddr != "" {
+		health := NewHealthMonitor(sugar.Named("health"), telegramHealthCheck(bots), databaseHealthCheck(db), subgraphHealthCheck(uniswapClient))
+		for _, job := range health.Jobs() {
+			scheduler.Add(job)
+		}
+
+		healthMux := http.NewServeMux()
+		health.Register(healthMux)
+		if err := startHTTPServer(HTTPServerConfig{ListenAddr: cfg.HealthListenAddr}, healthMux, sugar); err != nil {
+			sugar.Fatalf("Failed to start health server: %v", err)
+		}
+		sugar.Infow("Health server started", "listen_addr", cfg.HealthListenAddr)
+	}
+
+	// Expose runtime profiles for debugging if asked to, never on the public HTTP server
+	if cfg.PprofListenAddr != "" {
+		if err := startHTTPServer(HTTPServerConfig{ListenAddr: cfg.PprofListenAddr}, newPprofMux(), sugar); err != nil {
+			sugar.Fatalf("Failed to start pprof server: %v", err)
+		}
+		sugar.Warnw("Serving pprof profiles, keep this address private", "listen_addr", cfg.PprofListenAddr)
 	}
 
-	// Keep the bot running
-	updater.Idle()
+	// Serve the REST API's data over gRPC for internal services
+	if cfg.GRPCListenAddr != "" {
+		if err := NewGRPCServer(uniswapClient, cfg.APIKeys, sugar.Named("grpc")).Start(cfg.GRPCListenAddr); err != nil {
+			sugar.Fatalf("Failed to start gRPC server: %v", err)
+		}
+		sugar.Infow("gRPC server started", "listen_addr", cfg.GRPCListenAddr)
+	}
+
+	// Deliver notifications through a queue in the database, so they survive Telegram outages
+	notifier := NewNotifier(bots, db, sugar.Named("notifier"))
+	scheduler.Add(notifier.Job())
+	if sourceAlerter != nil {
+		scheduler.Add(sourceAlerter.Job(notifier))
+	}
+	// Watch tracked wallets in the background and notify chats about changes
+	scheduler.Add(NewPositionMonitor(notifier, db, uniswapClient, sugar.Named("monitor"), cfg.FetchConcurrency).Job(cfg.MonitorInterval))
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		scheduler.Run(schedulerCtx)
+	}()
+
+	// Serve the webhook, share pages, Mini App, metrics and REST API over HTTP if any is in use
+	mux := http.NewServeMux()
+	if cfg.HTTPServerEnabled() {
+		mux.Handle(sharePathPrefix, NewShareServer(db, uniswapClient, sugar.Named("share")))
+		NewWebAppServer(tokens, db, uniswapClient, sugar.Named("webapp")).Register(mux)
+		if cfg.MetricsToken != "" {
+			mux.Handle(metricsPath, NewMetricsServer(usage, cfg.MetricsToken, sugar.Named("metrics")))
+		}
+		if len(cfg.APIKeys) > 0 {
+			if err := checkAPISpec(); err != nil {
+				sugar.Fatalf("REST API doesn't match openapi.yaml:\n%v", err)
+			}
+			NewAPIServer(uniswapClient, cfg.APIKeys, sugar.Named("api")).Register(mux)
+		}
+		if err := startHTTPServer(cfg.HTTPServer(), mux, sugar); err != nil {
+			sugar.Fatalf("Failed to start HTTP server: %v", err)
+		}
+		sugar.Infow("HTTP server started", "listen_addr", cfg.HTTPListenAddr, "public_url", cfg.PublicURL)
+	}
+
+	// Start bot, using a webhook if one is configured and long polling otherwise
+	if cfg.WebhookURL != "" {
+		if err := startWebhook(updater, bots, cfg.Webhook(), mux); err != nil {
+			sugar.Fatalf("Failed to start webhook: %v", err)
+		}
+		sugar.Infow("Bot started successfully in webhook mode", "url", cfg.WebhookURL)
+	} else {
+		for _, bot := range botList {
+			// Make sure a webhook left over from a previous deployment doesn't block polling
+			if _, err := bot.DeleteWebhook(&gotgbot.DeleteWebhookOpts{}); err != nil {
+				sugar.Warnw("Failed to delete webhook", "bot", bot.Username, "error", err)
+			}
+
+			err = updater.StartPolling(bot, &ext.PollingOpts{
+				DropPendingUpdates: true,
+			})
+			if err != nil {
+				sugar.Fatalf("Failed to start polling: %v", err)
+			}
+		}
+		sugar.Info("Bot started successfully")
+	}
+
+	// Keep the bot running until it is asked to stop
+	stopCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-stopCtx.Done()
+
+	// Wind down rather than die, so a redeploy loses nothing in flight: handlers finish the updates
+	// they are handling, the monitor saves what it fetched and jobs save state they keep in memory
+	sugar.Info("Shutting down")
+	if err := updater.Stop(); err != nil {
+		sugar.Warnw("Failed to stop receiving updates", "error", err)
+	}
+	stopScheduler()
+	select {
+	case <-schedulerDone:
+	case <-time.After(shutdownTimeout):
+		sugar.Warnw("Background jobs didn't stop in time", "timeout", shutdownTimeout)
+	}
 }
+
+// shutdownTimeout bounds waiting for background jobs to stop, well within the 30 seconds
+// container runtimes usually give before killing the process
+const shutdownTimeout = 20 * time.Second
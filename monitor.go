@@ -0,0 +1,732 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"go.uber.org/zap"
+)
+
+// defaultMonitorInterval is how often tracked wallets are checked for changes
+const defaultMonitorInterval = 10 * time.Minute
+
+// PositionMonitor fetches the positions of all tracked wallets, compares them with the previous
+// snapshot, notifies the chats tracking a wallet about changes, records snapshots and fires alert
+// rules. The Scheduler runs it periodically.
+type PositionMonitor struct {
+	notifier      *Notifier
+	db            Store
+	uniswapClient uniswap.Client
+	logger        *zap.SugaredLogger
+
+	// concurrency is how many wallets and positions are fetched at the same time
+	concurrency int
+
+	mu        sync.Mutex
+	snapshots map[string][]uniswap.Position
+	tracked   map[string]uniswap.Position
+
+	// swapsSince is the timestamp of the newest swap already alerted about. It is persisted as
+	// the swapCursor and loaded at the first check after a restart.
+	swapsSince  time.Time
+	swapsLoaded bool
+}
+
+const (
+	// swapCursor names the monitor cursor of swaps already alerted about
+	swapCursor = "swaps"
+	// maxSwapCatchUp is how far back swaps are alerted about after the bot was down
+	maxSwapCatchUp = 6 * time.Hour
+)
+
+func NewPositionMonitor(notifier *Notifier, db Store, uniswapClient uniswap.Client, logger *zap.SugaredLogger, concurrency int) *PositionMonitor {
+	return &PositionMonitor{
+		notifier:      notifier,
+		db:            db,
+		uniswapClient: uniswapClient,
+		logger:        logger,
+		concurrency:   concurrency,
+		snapshots:     make(map[string][]uniswap.Position),
+		tracked:       make(map[string]uniswap.Position),
+		swapsSince:    time.Now(),
+	}
+}
+
+// Job returns the scheduler job checking all tracked wallets and positions every interval
+func (m *PositionMonitor) Job(interval time.Duration) Job {
+	return Job{Name: "position monitor", Interval: interval, Run: m.CheckAll, Resume: m.forget, Exclusive: true}
+}
+
+// forget drops the positions and swap cursor kept in memory, so the next check compares with
+// what was persisted instead. Once another replica ran the monitor, what this one last saw is
+// older than what that replica already reported.
+func (m *PositionMonitor) forget() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshots = make(map[string][]uniswap.Position)
+	m.tracked = make(map[string]uniswap.Position)
+	m.swapsSince = time.Now()
+	m.swapsLoaded = false
+}
+
+// CheckAll refreshes every tracked wallet and position once. Failures to fetch a single wallet or
+// position are logged and skipped, only failing to list what is tracked fails the check.
+func (m *PositionMonitor) CheckAll(ctx context.Context) error {
+	// Fetch every wallet once, no matter how many chats track it
+	wallets, err := m.db.GetAllTrackedWallets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list wallets: %w", err)
+	}
+	chatsByWallet := make(map[string][]int64, len(wallets))
+	for _, w := range wallets {
+		chatsByWallet[w.WalletAddress] = w.ChatIDs
+	}
+
+	// Every position fetched during this run, keyed by version and ID so positions that are
+	// both tracked and owned by a tracked wallet are only recorded once
+	fetched := make(map[string]uniswap.Position)
+	defer func() {
+		m.recordSnapshots(ctx, fetched)
+		m.checkAlertRules(ctx, fetched)
+	}()
+
+	walletPositions := make([][]uniswap.Position, len(wallets))
+	forEachConcurrently(ctx, m.concurrency, wallets, func(i int, w TrackedWallet) {
+		walletPositions[i] = m.checkWallet(ctx, w.WalletAddress, w.ChatIDs)
+	})
+	for _, positions := range walletPositions {
+		for _, pos := range positions {
+			fetched[uniswap.PositionKey(pos)] = pos
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	m.checkSwaps(ctx, chatsByWallet)
+
+	tracked, err := m.db.ListAllTrackedPositions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tracked positions: %w", err)
+	}
+
+	// Likewise, fetch every tracked position once, whoever tracks it
+	chatsByPosition := make(map[TrackedPosition][]int64)
+	var positions []TrackedPosition
+	for _, tp := range tracked {
+		key := TrackedPosition{PositionID: tp.PositionID, Version: tp.Version}
+		if _, ok := chatsByPosition[key]; !ok {
+			positions = append(positions, key)
+		}
+		chatsByPosition[key] = append(chatsByPosition[key], tp.ChatID)
+	}
+
+	trackedPositions := make([]*uniswap.Position, len(positions))
+	forEachConcurrently(ctx, m.concurrency, positions, func(i int, tp TrackedPosition) {
+		trackedPositions[i] = m.checkTrackedPosition(ctx, tp, chatsByPosition[tp])
+	})
+	for _, pos := range trackedPositions {
+		if pos != nil {
+			fetched[uniswap.PositionKey(*pos)] = *pos
+		}
+	}
+	return ctx.Err()
+}
+
+// checkAlertRules fires the enabled alert rules whose position was fetched during this run
+func (m *PositionMonitor) checkAlertRules(ctx context.Context, positions map[string]uniswap.Position) {
+	if len(positions) == 0 {
+		return
+	}
+
+	rules, err := m.db.ListEnabledAlertRules(ctx)
+	if err != nil {
+		requestLogger(ctx, m.logger).Errorw("Failed to list alert rules", "error", err)
+		return
+	}
+
+	deliveries, err := m.db.GetLatestAlertDeliveries(ctx)
+	if err != nil {
+		requestLogger(ctx, m.logger).Errorw("Failed to get alert deliveries", "error", err)
+		return
+	}
+	// triggered holds the rules whose condition held for their position at the last alert and
+	// hasn't been seen to stop since, so the alert isn't sent again after restarts
+	triggered := make(map[alertDeliveryKey]bool)
+	for _, delivery := range deliveries {
+		if delivery.Triggered {
+			triggered[alertDeliveryKey{delivery.RuleID, delivery.Position}] = true
+		}
+	}
+
+	now := time.Now()
+	var due []AlertRule
+	var priced []uniswap.Position
+	for _, rule := range rules {
+		pos, ok := positions[rule.Target]
+		if !ok {
+			continue
+		}
+		// Rules already triggered are evaluated to notice their condition stopping, the others only
+		// once their cooldown has passed, so flapping prices don't alert more often than that
+		if !triggered[alertDeliveryKey{rule.ID, rule.Target}] && now.Sub(rule.LastFiredAt) < rule.Cooldown {
+			continue
+		}
+		due = append(due, rule)
+		if rule.Type == AlertFees {
+			priced = append(priced, pos)
+		}
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	var prices map[common.Address]float64
+	if len(priced) > 0 {
+		fetchCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+		prices = priceTokens(fetchCtx, m.uniswapClient, priced, m.logger)
+		cancel()
+	}
+
+	alertsEnabled := make(map[int64]bool)
+	for _, rule := range due {
+		enabled, ok := alertsEnabled[rule.ChatID]
+		if !ok {
+			settings, err := m.db.GetChatSettings(ctx, rule.ChatID)
+			if err != nil {
+				requestLogger(ctx, m.logger).Errorw("Failed to get chat settings", "chat_id", rule.ChatID, "error", err)
+				continue
+			}
+			enabled = settings.AlertsEnabled
+			alertsEnabled[rule.ChatID] = enabled
+		}
+		if !enabled {
+			continue
+		}
+
+		msg, fire, known := evaluateAlertRule(rule, positions[rule.Target], prices)
+		key := alertDeliveryKey{rule.ID, rule.Target}
+		if !known || fire == triggered[key] {
+			continue
+		}
+
+		if fire {
+			m.send(ctx, rule.ChatID, msg)
+		}
+		err := m.db.WithTx(ctx, func(tx Store) error {
+			if fire {
+				if err := tx.MarkAlertRuleFired(ctx, rule.ID, now); err != nil {
+					return err
+				}
+			}
+			return tx.RecordAlertDelivery(ctx, AlertDelivery{RuleID: rule.ID, Position: rule.Target, Triggered: fire, At: now})
+		})
+		if err != nil {
+			requestLogger(ctx, m.logger).Errorw("Failed to record alert delivery", "rule_id", rule.ID, "error", err)
+		}
+	}
+}
+
+// alertDeliveryKey identifies the alerts of a rule for a position in the delivery ledger
+type alertDeliveryKey struct {
+	ruleID   int64
+	position string
+}
+
+// evaluateAlertRule returns the alert to send if the rule's condition holds for pos. known is false
+// if the condition can't be evaluated, e.g. because token prices are missing.
+func evaluateAlertRule(rule AlertRule, pos uniswap.Position, prices map[common.Address]float64) (msg string, fire, known bool) {
+	title := fmt.Sprintf("%s position #%s (%s)", pos.Pair(), pos.ID.String(), pos.Version)
+
+	switch rule.Type {
+	case AlertOutOfRange:
+		if !uniswap.HasLiquidity(pos) || pos.InRange() {
+			return "", false, true
+		}
+		return fmt.Sprintf("%s is out of range\nAlert #%d, manage with /alerts", title, rule.ID), true, true
+	case AlertFees:
+		price0, ok0 := prices[pos.Token0.Address]
+		price1, ok1 := prices[pos.Token1.Address]
+		if !ok0 || !ok1 {
+			return "", false, false
+		}
+		fees := uniswap.TokenAmountUSD(pos.UnclaimedFees0, pos.Token0, price0) + uniswap.TokenAmountUSD(pos.UnclaimedFees1, pos.Token1, price1)
+		if fees < rule.Threshold {
+			return "", false, true
+		}
+		return fmt.Sprintf("%s collected %s in fees, above your %s alert\nAlert #%d, manage with /alerts",
+			title, formatUSD(fees, true), formatUSD(rule.Threshold, true), rule.ID), true, true
+	default:
+		return "", false, false
+	}
+}
+
+// recordSnapshots persists the positions fetched during a run for diffs, charts and PnL, and
+// the fees they accrued since the previous run for APR calculations
+func (m *PositionMonitor) recordSnapshots(ctx context.Context, positions map[string]uniswap.Position) {
+	if len(positions) == 0 {
+		return
+	}
+
+	previous, err := m.db.GetLatestSnapshots(ctx)
+	if err != nil {
+		requestLogger(ctx, m.logger).Errorw("Failed to get latest position snapshots", "error", err)
+	}
+
+	takenAt := time.Now().UTC()
+	snapshots := make([]PositionSnapshot, 0, len(positions))
+	for _, pos := range positions {
+		snapshots = append(snapshots, newPositionSnapshot(pos, takenAt))
+	}
+
+	// The ledger is derived from the latest snapshots, so both are saved or neither
+	err = m.db.WithTx(ctx, func(tx Store) error {
+		if err := tx.SaveSnapshots(ctx, snapshots); err != nil {
+			return fmt.Errorf("failed to save snapshots: %w", err)
+		}
+		if entries := newFeeLedgerEntries(previous, snapshots); len(entries) > 0 {
+			if err := tx.SaveFeeLedger(ctx, entries); err != nil {
+				return fmt.Errorf("failed to save fee ledger: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		requestLogger(ctx, m.logger).Errorw("Failed to record position snapshots", "count", len(snapshots), "error", err)
+	}
+}
+
+func newPositionSnapshot(pos uniswap.Position, takenAt time.Time) PositionSnapshot {
+	snapshot := PositionSnapshot{
+		PositionID:    pos.ID.String(),
+		Version:       string(pos.Version),
+		WalletAddress: pos.Owner.Hex(),
+		Liquidity:     bigIntString(pos.Liquidity),
+		Amount0:       bigIntString(pos.Amount0),
+		Amount1:       bigIntString(pos.Amount1),
+		Fees0:         bigIntString(pos.UnclaimedFees0),
+		Fees1:         bigIntString(pos.UnclaimedFees1),
+		TakenAt:       takenAt,
+	}
+	if pos.CurrentPrice != nil {
+		snapshot.Price = pos.CurrentPrice.Text('g', -1)
+	}
+	return snapshot
+}
+
+// bigIntString formats x in base 10, or returns "" if x is unknown
+func bigIntString(x *big.Int) string {
+	if x == nil {
+		return ""
+	}
+	return x.String()
+}
+
+// checkWallet notifies the chats about changes to the wallet's positions and returns the fetched positions
+func (m *PositionMonitor) checkWallet(ctx context.Context, wallet string, chatIDs []int64) []uniswap.Position {
+	fetchCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	positions, err := m.uniswapClient.GetPositions(fetchCtx, uniswap.PositionRequest{
+		WalletAddress: common.HexToAddress(wallet),
+		IncludeV3:     true,
+		IncludeV4:     true,
+		IncludeClosed: true,
+	})
+	if errors.Is(err, uniswap.ErrPartialPositions) {
+		// The missing version's positions would look burned or transferred away, and new once
+		// its subgraph is back, so keep the baseline until all of them are fetched again
+		requestLogger(ctx, m.logger).Warnw("Fetched only some positions, not comparing", "wallet", wallet, "error", err)
+		return positions
+	}
+	if err != nil && !errors.Is(err, uniswap.ErrWalletHasNoPositions) {
+		requestLogger(ctx, m.logger).Errorw("Failed to fetch positions", "wallet", wallet, "error", err)
+		return nil
+	}
+	// Once fetched, see the wallet through even if the bot is stopping, so a redeploy doesn't
+	// lose its changes halfway
+	ctx = context.WithoutCancel(ctx)
+	// Keep the cache warm so /status can show these right away
+	if err := m.db.SaveCachedPositions(ctx, wallet, positions, time.Now()); err != nil {
+		requestLogger(ctx, m.logger).Warnw("Failed to cache positions", "wallet", wallet, "error", err)
+	}
+
+	m.mu.Lock()
+	previous, seen := m.snapshots[wallet]
+	m.snapshots[wallet] = positions
+	m.mu.Unlock()
+
+	// After a restart, compare with what was seen before it
+	if !seen {
+		previous, seen = m.lastSeenPositions(ctx, wallet)
+	}
+
+	// Nothing to report or to move the baseline to if no position changed
+	if seen && !uniswap.PositionsChanged(previous, positions) {
+		return positions
+	}
+
+	// The first snapshot of a wallet only establishes the baseline
+	if seen {
+		diff := uniswap.DiffPositions(previous, positions)
+		collectedUSD := m.priceCollections(fetchCtx, diff.Collected)
+		for _, chatID := range chatIDs {
+			m.notifyDiff(ctx, chatID, wallet, diff, collectedUSD)
+		}
+	}
+
+	// Move the baseline only once the changes are queued, so if the bot dies in between they are
+	// reported again after the restart rather than never
+	if err := m.db.SaveLastSeenPositions(ctx, wallet, positions, time.Now()); err != nil {
+		requestLogger(ctx, m.logger).Warnw("Failed to save last seen positions", "wallet", wallet, "error", err)
+	}
+	return positions
+}
+
+// lastSeenPositions returns the positions persisted by an earlier check of the wallet
+func (m *PositionMonitor) lastSeenPositions(ctx context.Context, wallet string) ([]uniswap.Position, bool) {
+	positions, _, ok, err := m.db.GetLastSeenPositions(ctx, wallet)
+	if err != nil {
+		requestLogger(ctx, m.logger).Warnw("Failed to get last seen positions", "wallet", wallet, "error", err)
+		return nil, false
+	}
+	return positions, ok
+}
+
+// checkTrackedPosition notifies the chats about changes to a tracked position and returns it, or nil if it
+// could not be fetched
+func (m *PositionMonitor) checkTrackedPosition(ctx context.Context, tp TrackedPosition, chatIDs []int64) *uniswap.Position {
+	id, ok := new(big.Int).SetString(tp.PositionID, 10)
+	if !ok {
+		return nil
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	key := tp.Version + ":" + tp.PositionID
+	pos, err := m.uniswapClient.GetPosition(fetchCtx, uniswap.PositionVersion(tp.Version), id)
+	if errors.Is(err, uniswap.ErrPositionNotFound) {
+		ctx = context.WithoutCancel(ctx)
+
+		m.mu.Lock()
+		previous, seen := m.tracked[key]
+		delete(m.tracked, key)
+		m.mu.Unlock()
+
+		if !seen {
+			previous, seen = m.lastSeenTrackedPosition(ctx, tp)
+		}
+		if seen {
+			for _, chatID := range chatIDs {
+				m.notifyDiff(ctx, chatID, "", uniswap.PositionDiff{Removed: []uniswap.Position{previous}}, nil)
+			}
+			if err := m.db.DeleteLastSeenTrackedPosition(ctx, tp.PositionID, tp.Version); err != nil {
+				requestLogger(ctx, m.logger).Warnw("Failed to delete last seen tracked position", "position_id", tp.PositionID, "version", tp.Version, "error", err)
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		requestLogger(ctx, m.logger).Errorw("Failed to fetch tracked position", "position_id", tp.PositionID, "version", tp.Version, "error", err)
+		return nil
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	m.mu.Lock()
+	previous, seen := m.tracked[key]
+	m.tracked[key] = *pos
+	m.mu.Unlock()
+
+	// After a restart, compare with what was seen before it
+	if !seen {
+		previous, seen = m.lastSeenTrackedPosition(ctx, tp)
+	}
+
+	transferred := seen && previous.Owner != pos.Owner
+	if seen && !transferred && !uniswap.PositionsChanged([]uniswap.Position{previous}, []uniswap.Position{*pos}) {
+		return pos
+	}
+
+	// The first sight of a position only establishes the baseline
+	if seen {
+		diff := uniswap.DiffPositions([]uniswap.Position{previous}, []uniswap.Position{*pos})
+		collectedUSD := m.priceCollections(fetchCtx, diff.Collected)
+		for _, chatID := range chatIDs {
+			m.notifyDiff(ctx, chatID, "", diff, collectedUSD)
+
+			// A tracked position stays visible after changing hands, so report transfers explicitly
+			if transferred {
+				m.send(ctx, chatID, fmt.Sprintf("%s position #%s (%s) was transferred\nFrom: %s\nTo: %s",
+					pos.Pair(), pos.ID.String(), pos.Version, previous.Owner.Hex(), pos.Owner.Hex()))
+			}
+		}
+	}
+
+	// As with wallets, move the baseline only once the changes are queued
+	if err := m.db.SaveLastSeenTrackedPosition(ctx, *pos, time.Now()); err != nil {
+		requestLogger(ctx, m.logger).Warnw("Failed to save last seen tracked position", "position_id", tp.PositionID, "version", tp.Version, "error", err)
+	}
+	return pos
+}
+
+// lastSeenTrackedPosition returns the tracked position as persisted by an earlier check of it
+func (m *PositionMonitor) lastSeenTrackedPosition(ctx context.Context, tp TrackedPosition) (uniswap.Position, bool) {
+	pos, ok, err := m.db.GetLastSeenTrackedPosition(ctx, tp.PositionID, tp.Version)
+	if err != nil {
+		requestLogger(ctx, m.logger).Warnw("Failed to get last seen tracked position", "position_id", tp.PositionID, "version", tp.Version, "error", err)
+		return uniswap.Position{}, false
+	}
+	if !ok {
+		return uniswap.Position{}, false
+	}
+	return *pos, true
+}
+
+// checkSwaps alerts chats that opted in about large swaps in the pools they provide liquidity to
+func (m *PositionMonitor) checkSwaps(ctx context.Context, chatsByWallet map[string][]int64) {
+	source, ok := m.uniswapClient.(uniswap.SwapSource)
+	if !ok {
+		return
+	}
+
+	thresholds := make(map[int64]float64)
+	poolsByChat := make(map[int64]map[common.Address]bool)
+	pools := make(map[common.Address]bool)
+	minUSD := 0.0
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.swapsLoaded {
+		m.loadSwapCursor(ctx)
+	}
+
+	for wallet, chatIDs := range chatsByWallet {
+		for _, chatID := range chatIDs {
+			threshold, ok := thresholds[chatID]
+			if !ok {
+				settings, err := m.db.GetChatSettings(ctx, chatID)
+				if err != nil {
+					requestLogger(ctx, m.logger).Errorw("Failed to get chat settings", "chat_id", chatID, "error", err)
+					continue
+				}
+				if settings.AlertsEnabled {
+					threshold = settings.SwapAlertUSD
+				}
+				thresholds[chatID] = threshold
+			}
+			if threshold <= 0 {
+				continue
+			}
+
+			for _, pos := range m.snapshots[wallet] {
+				if pos.Version != uniswap.VersionV3 || !uniswap.HasLiquidity(pos) {
+					continue
+				}
+				if poolsByChat[chatID] == nil {
+					poolsByChat[chatID] = make(map[common.Address]bool)
+				}
+				poolsByChat[chatID][pos.PoolAddress] = true
+				pools[pos.PoolAddress] = true
+				if minUSD == 0 || threshold < minUSD {
+					minUSD = threshold
+				}
+			}
+		}
+	}
+
+	if len(pools) == 0 {
+		return
+	}
+
+	poolList := make([]common.Address, 0, len(pools))
+	for pool := range pools {
+		poolList = append(poolList, pool)
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	swaps, err := source.GetLargeSwaps(fetchCtx, poolList, minUSD, m.swapsSince)
+	if err != nil {
+		requestLogger(ctx, m.logger).Errorw("Failed to fetch swaps", "pools", len(poolList), "error", err)
+		return
+	}
+
+	// Queue every alert and save how far they got even if the bot is stopping meanwhile
+	ctx = context.WithoutCancel(ctx)
+	since := m.swapsSince
+	for _, swap := range swaps {
+		if swap.Timestamp.After(m.swapsSince) {
+			m.swapsSince = swap.Timestamp
+		}
+
+		for chatID, chatPools := range poolsByChat {
+			if chatPools[swap.Pool] && swap.AmountUSD >= thresholds[chatID] {
+				m.send(ctx, chatID, formatSwapAlert(swap))
+			}
+		}
+	}
+	if m.swapsSince.After(since) {
+		if err := m.db.SaveMonitorCursor(ctx, swapCursor, m.swapsSince); err != nil {
+			requestLogger(ctx, m.logger).Warnw("Failed to save swap cursor", "error", err)
+		}
+	}
+}
+
+// loadSwapCursor continues alerting about swaps where the monitor left off before a restart, but
+// not further back than maxSwapCatchUp. m.mu must be held.
+func (m *PositionMonitor) loadSwapCursor(ctx context.Context) {
+	since, ok, err := m.db.GetMonitorCursor(ctx, swapCursor)
+	if err != nil {
+		requestLogger(ctx, m.logger).Warnw("Failed to load swap cursor", "error", err)
+		return
+	}
+	m.swapsLoaded = true
+	if !ok {
+		return
+	}
+	if earliest := time.Now().Add(-maxSwapCatchUp); since.Before(earliest) {
+		since = earliest
+	}
+	m.swapsSince = since
+}
+
+// notifyDiff tells a chat about opened and closed positions and collected fees. wallet is
+// empty for individually tracked positions. collectedUSD holds the USD value of the fee
+// collections that could be priced, keyed by their index in diff.Collected.
+func (m *PositionMonitor) notifyDiff(ctx context.Context, chatID int64, wallet string, diff uniswap.PositionDiff, collectedUSD map[int]float64) {
+	if len(diff.Opened) == 0 && len(diff.Closed) == 0 && len(diff.Removed) == 0 && len(diff.Collected) == 0 {
+		return
+	}
+
+	settings, err := m.db.GetChatSettings(ctx, chatID)
+	if err != nil {
+		requestLogger(ctx, m.logger).Errorw("Failed to get chat settings", "chat_id", chatID, "error", err)
+		return
+	}
+	if !settings.AlertsEnabled {
+		return
+	}
+
+	wanted := func(pos uniswap.Position) bool {
+		return (pos.Version != uniswap.VersionV3 || settings.IncludeV3) && (pos.Version != uniswap.VersionV4 || settings.IncludeV4)
+	}
+
+	walletLine := ""
+	if wallet != "" {
+		name := ChatWallet{WalletAddress: wallet}
+		if name.Label, err = m.db.GetWalletLabel(ctx, chatID, wallet); err != nil {
+			requestLogger(ctx, m.logger).Warnw("Failed to get wallet label", "chat_id", chatID, "wallet", wallet, "error", err)
+		}
+		walletLine = fmt.Sprintf("\nWallet: %s", name.DisplayName())
+	}
+
+	for _, pos := range diff.Opened {
+		if !wanted(pos) {
+			continue
+		}
+		m.send(ctx, chatID, fmt.Sprintf("New %s position opened%s\nID: %s (%s)",
+			pos.Pair(), walletLine, pos.ID.String(), pos.Version))
+	}
+
+	for _, pos := range diff.Closed {
+		if !wanted(pos) {
+			continue
+		}
+		m.send(ctx, chatID, fmt.Sprintf("%s position closed%s\nID: %s (%s)\n%s",
+			pos.Pair(), walletLine, pos.ID.String(), pos.Version, formatFinalAmounts(pos)))
+	}
+
+	for _, pos := range diff.Removed {
+		if !wanted(pos) {
+			continue
+		}
+		m.send(ctx, chatID, fmt.Sprintf("%s position was burned or transferred away%s\nID: %s (%s)\nLast known state:\n%s",
+			pos.Pair(), walletLine, pos.ID.String(), pos.Version, formatFinalAmounts(pos)))
+	}
+
+	for i, c := range diff.Collected {
+		if !wanted(c.Position) {
+			continue
+		}
+		value := ""
+		if usd, ok := collectedUSD[i]; ok {
+			value = fmt.Sprintf(" (~$%.2f)", usd)
+		}
+		m.send(ctx, chatID, fmt.Sprintf("Fees collected from %s position%s\nID: %s (%s)\nAmount: %s, %s%s",
+			c.Position.Pair(), walletLine, c.Position.ID.String(), c.Position.Version,
+			uniswap.FormatTokenAmount(c.Amount0, c.Position.Token0),
+			uniswap.FormatTokenAmount(c.Amount1, c.Position.Token1),
+			value))
+	}
+}
+
+// priceCollections returns the USD value of the fee collections the data source can price
+func (m *PositionMonitor) priceCollections(ctx context.Context, collections []uniswap.FeeCollection) map[int]float64 {
+	pricer, ok := m.uniswapClient.(uniswap.PriceProvider)
+	if !ok || len(collections) == 0 {
+		return nil
+	}
+
+	values := make(map[int]float64)
+	for i, c := range collections {
+		pos := c.Position
+		prices, err := pricer.GetTokenPricesUSD(ctx, pos.Version, []common.Address{pos.Token0.Address, pos.Token1.Address})
+		if err != nil {
+			requestLogger(ctx, m.logger).Warnw("Failed to price collected fees", "position_id", pos.ID.String(), "error", err)
+			continue
+		}
+
+		price0, ok0 := prices[pos.Token0.Address]
+		price1, ok1 := prices[pos.Token1.Address]
+		if (c.Amount0.Sign() > 0 && !ok0) || (c.Amount1.Sign() > 0 && !ok1) {
+			continue
+		}
+		values[i] = uniswap.TokenAmountUSD(c.Amount0, pos.Token0, price0) + uniswap.TokenAmountUSD(c.Amount1, pos.Token1, price1)
+	}
+	return values
+}
+
+// send queues a notification for the chat, which the Notifier delivers
+func (m *PositionMonitor) send(ctx context.Context, chatID int64, msg string) {
+	if err := m.notifier.Enqueue(ctx, chatID, msg); err != nil {
+		requestLogger(ctx, m.logger).Errorw("Failed to queue notification", "chat_id", chatID, "error", err)
+	}
+}
+
+// formatFinalAmounts formats what was withdrawn from and collected by a position
+func formatFinalAmounts(pos uniswap.Position) string {
+	return fmt.Sprintf("Withdrawn: %s, %s\nFees collected: %s, %s",
+		uniswap.FormatTokenAmount(pos.WithdrawnToken0, pos.Token0),
+		uniswap.FormatTokenAmount(pos.WithdrawnToken1, pos.Token1),
+		uniswap.FormatTokenAmount(pos.UnclaimedFees0, pos.Token0),
+		uniswap.FormatTokenAmount(pos.UnclaimedFees1, pos.Token1))
+}
+
+// formatSwapAlert describes a large swap, e.g. "Sold 500 WETH for 1000000 USDC"
+func formatSwapAlert(swap uniswap.Swap) string {
+	sold, bought := swap.Token0, swap.Token1
+	soldAmount, boughtAmount := swap.Amount0, swap.Amount1
+	if swap.Amount0.Sign() < 0 {
+		sold, bought = swap.Token1, swap.Token0
+		soldAmount, boughtAmount = swap.Amount1, swap.Amount0
+	}
+
+	msg := fmt.Sprintf("Large swap in your %s/%s %s pool: $%.0f\nSold %s %s for %s %s",
+		swap.Token0.Symbol, swap.Token1.Symbol, uniswap.FormatFeeTier(swap.FeeTier), swap.AmountUSD,
+		new(big.Float).Abs(soldAmount).Text('f', 4), sold.Symbol,
+		new(big.Float).Abs(boughtAmount).Text('f', 4), bought.Symbol)
+	// Link the transaction on the explorer of the chain the pool is on
+	if url := swap.Token0.ChainID.TxURL(swap.TxHash); url != "" {
+		msg += "\nTx: " + url
+	}
+	return msg
+}
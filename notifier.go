@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	// notificationInterval is how often queued notifications are delivered
+	notificationInterval = 5 * time.Second
+	// notificationBatchSize is how many notifications are delivered at most per run, which keeps
+	// well below Telegram's limit of 30 messages per second
+	notificationBatchSize = 50
+	// notificationLease is how long notifications claimed by a run are withheld from other runs and
+	// replicas. A run stops sending halfway through it, which leaves a send time to finish, and
+	// hands back what it didn't send.
+	notificationLease = 5 * time.Minute
+	// maxNotificationAttempts is how often delivering a notification is tried before it is
+	// dead-lettered. With the backoff below the last attempt is about 8 hours after the first.
+	maxNotificationAttempts = 15
+	notificationMinBackoff  = 30 * time.Second
+	notificationMaxBackoff  = time.Hour
+)
+
+// Notifier delivers notifications through a queue in the database, so notifications generated
+// while Telegram can't be reached are delivered once it can, rather than lost. Failed deliveries
+// are retried with exponential backoff. Notifications Telegram refuses for good, e.g. because
+// the user blocked the bot, or that keep failing are dead-lettered: kept in the queue but never
+// tried again.
+type Notifier struct {
+	bots   *botSet
+	db     notifierStore
+	logger *zap.SugaredLogger
+}
+
+// notifierStore is what the Notifier persists: the queue, and which bot each chat talks to
+type notifierStore interface {
+	NotificationStore
+	ChatBotStore
+}
+
+func NewNotifier(bots *botSet, db notifierStore, logger *zap.SugaredLogger) *Notifier {
+	return &Notifier{bots: bots, db: db, logger: logger}
+}
+
+// Enqueue queues text for delivery to the chat
+func (n *Notifier) Enqueue(ctx context.Context, chatID int64, text string) error {
+	return n.db.EnqueueNotification(ctx, chatID, text)
+}
+
+// Job returns the scheduler job delivering queued notifications. It is exclusive, so replicas
+// don't race for the queue; claiming notifications keeps them from delivering one twice if a run
+// outlasts the lock.
+func (n *Notifier) Job() Job {
+	return Job{Name: "notification delivery", Interval: notificationInterval, Run: n.DeliverDue, Exclusive: true}
+}
+
+// DeliverDue tries to deliver the notifications that are due. Once delivering to a chat failed,
+// the chat's later notifications wait until the failed one is delivered or dead-lettered, so a
+// chat receives them in order.
+func (n *Notifier) DeliverDue(ctx context.Context) error {
+	claimedAt := time.Now()
+	notifications, err := n.db.ClaimDueNotifications(ctx, claimedAt, claimedAt.Add(notificationLease), notificationBatchSize)
+	if err != nil {
+		return err
+	}
+
+	// Hand back the notifications not attempted, so they needn't wait for the lease to expire
+	attempted := make(map[int64]bool, len(notifications))
+	defer func() {
+		var unsent []int64
+		for _, notification := range notifications {
+			if !attempted[notification.ID] {
+				unsent = append(unsent, notification.ID)
+			}
+		}
+		if len(unsent) == 0 {
+			return
+		}
+		if err := n.db.ReleaseNotifications(context.WithoutCancel(ctx), unsent, time.Now()); err != nil {
+			n.logger.Warnw("Failed to release notifications, they are delivered once their lease expires", "count", len(unsent), "error", err)
+		}
+	}()
+
+	failedChats := make(map[int64]bool)
+	for _, notification := range notifications {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if time.Since(claimedAt) > notificationLease/2 {
+			return nil
+		}
+		if failedChats[notification.ChatID] {
+			continue
+		}
+
+		bot, err := n.chatBot(ctx, notification.ChatID)
+		if err != nil {
+			return err
+		}
+		attempted[notification.ID] = true
+		_, err = bot.SendMessage(notification.ChatID, notification.Text, &gotgbot.SendMessageOpts{})
+		if err == nil {
+			if err := n.db.DeleteNotification(ctx, notification.ID); err != nil {
+				// Stop rather than deliver the notification again at the next run
+				return err
+			}
+			continue
+		}
+		failedChats[notification.ChatID] = true
+		if err := n.handleFailure(ctx, notification, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chatBot returns the bot the chat talks to, the primary bot unless it talked to another one
+func (n *Notifier) chatBot(ctx context.Context, chatID int64) (*gotgbot.Bot, error) {
+	botID, ok, err := n.db.GetChatBot(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return n.bots.Primary(), nil
+	}
+	return n.bots.Get(botID), nil
+}
+
+// handleFailure schedules the next attempt to deliver notification after sendErr, or dead-letters it
+func (n *Notifier) handleFailure(ctx context.Context, notification Notification, sendErr error) error {
+	attempts := notification.Attempts + 1
+	retryAfter, retry := notificationRetryDelay(sendErr, attempts)
+	if !retry || attempts >= maxNotificationAttempts {
+		n.logger.Errorw("Giving up on notification", "id", notification.ID, "chat_id", notification.ChatID, "attempts", attempts, "error", sendErr)
+		return n.db.DeadLetterNotification(ctx, notification.ID, time.Now(), sendErr.Error())
+	}
+
+	n.logger.Warnw("Failed to send notification, will retry", "id", notification.ID, "chat_id", notification.ChatID, "attempts", attempts, "retry_after", retryAfter, "error", sendErr)
+	return n.db.RetryNotification(ctx, notification.ID, time.Now().Add(retryAfter), sendErr.Error())
+}
+
+// notificationRetryDelay returns how long to wait before attempt number attempts+1 after err,
+// and false if delivering won't ever succeed: Telegram rejected the message or chat, e.g. as
+// the bot was blocked or removed from the chat
+func notificationRetryDelay(err error, attempts int) (time.Duration, bool) {
+	var tgErr *gotgbot.TelegramError
+	if errors.As(err, &tgErr) {
+		switch {
+		case tgErr.Code == http.StatusTooManyRequests && tgErr.ResponseParams != nil && tgErr.ResponseParams.RetryAfter > 0:
+			return time.Duration(tgErr.ResponseParams.RetryAfter) * time.Second, true
+		case tgErr.Code == http.StatusBadRequest, tgErr.Code == http.StatusForbidden:
+			return 0, false
+		}
+	}
+
+	backoff := notificationMinBackoff
+	for i := 1; i < attempts && backoff < notificationMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, notificationMaxBackoff), true
+}
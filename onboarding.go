@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers/filters/callbackquery"
+)
+
+// Onboarding conversation states
+const (
+	onboardingStateWallet   = "onboarding_wallet"
+	onboardingStateVersions = "onboarding_versions"
+	onboardingStateAlerts   = "onboarding_alerts"
+)
+
+// Callback data prefixes of the onboarding inline keyboards
+const (
+	onboardingVersionsPrefix = "onboard_versions:"
+	onboardingAlertsPrefix   = "onboard_alerts:"
+)
+
+// newOnboardingConversation builds the guided setup flow: add a wallet, pick which
+// Uniswap deployments to query, then pick alert preferences. /start enters the flow for
+// chats that don't track anything yet and /setup enters it explicitly.
+func (h *BotHandlers) newOnboardingConversation() ext.Handler {
+	return handlers.NewConversation(
+		[]ext.Handler{
+			handlers.NewCommand("start", h.handleStart),
+			handlers.NewCommand("setup", h.handleSetup),
+		},
+		map[string][]ext.Handler{
+			onboardingStateWallet: {
+				handlers.NewMessage(isPlainText, h.handleOnboardingWallet),
+			},
+			onboardingStateVersions: {
+				handlers.NewCallback(callbackquery.Prefix(onboardingVersionsPrefix), h.handleOnboardingVersions),
+			},
+			onboardingStateAlerts: {
+				handlers.NewCallback(callbackquery.Prefix(onboardingAlertsPrefix), h.handleOnboardingAlerts),
+			},
+		},
+		&handlers.ConversationOpts{
+			Exits:        []ext.Handler{handlers.NewCommand("cancel", h.handleCancelOnboarding)},
+			AllowReEntry: true,
+		},
+	)
+}
+
+func (h *BotHandlers) handleSetup(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received setup command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	// Only administrators may change what a group chat tracks
+	if allowed, err := h.requireChatAdmin(b, ctx); !allowed {
+		return err
+	}
+
+	return h.promptOnboardingWallet(b, ctx)
+}
+
+func (h *BotHandlers) promptOnboardingWallet(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, err := ctx.EffectiveMessage.Reply(b, "Let's get you set up. Send me the wallet address (0x...) you want to track, or /cancel to stop.", &gotgbot.SendMessageOpts{})
+	if err != nil {
+		return err
+	}
+	return handlers.NextConversationState(onboardingStateWallet)
+}
+
+func (h *BotHandlers) handleOnboardingWallet(b *gotgbot.Bot, ctx *ext.Context) error {
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	walletAddress := strings.TrimSpace(ctx.EffectiveMessage.Text)
+	h.log(ctx).Infow("Received onboarding wallet", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id, "address", walletAddress)
+
+	address, err := parseWalletAddress(walletAddress)
+	if err != nil {
+		// Stay in the current state so the user can simply try again
+		_, err := ctx.EffectiveMessage.Reply(b, err.Error()+" Or send /cancel to stop.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	added, err := h.db.AddWallet(reqCtx, ctx.EffectiveChat.Id, ctx.EffectiveUser.Id, address.Hex(), "")
+	if err != nil {
+		_, err := ctx.EffectiveMessage.Reply(b, h.addWalletFailure(ctx, err), &gotgbot.SendMessageOpts{})
+		if err != nil {
+			return err
+		}
+		return handlers.EndConversation()
+	}
+
+	msg := fmt.Sprintf("Wallet %s added.\n\nWhich Uniswap deployments should I check for your positions?", address.Hex())
+	if !added {
+		msg = fmt.Sprintf("Wallet %s is already tracked.\n\nWhich Uniswap deployments should I check for your positions?", address.Hex())
+	}
+	_, err = ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{
+			InlineKeyboard: [][]gotgbot.InlineKeyboardButton{{
+				{Text: "Ethereum V3", CallbackData: onboardingVersionsPrefix + "v3"},
+				{Text: "Ethereum V4", CallbackData: onboardingVersionsPrefix + "v4"},
+				{Text: "Both", CallbackData: onboardingVersionsPrefix + "all"},
+			}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return handlers.NextConversationState(onboardingStateVersions)
+}
+
+func (h *BotHandlers) handleOnboardingVersions(b *gotgbot.Bot, ctx *ext.Context) error {
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	cb := ctx.CallbackQuery
+	choice := strings.TrimPrefix(cb.Data, onboardingVersionsPrefix)
+	h.log(ctx).Infow("Received onboarding versions", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id, "choice", choice)
+
+	settings, err := h.db.GetChatSettings(reqCtx, ctx.EffectiveChat.Id)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get chat settings", "error", err)
+		return h.abortOnboarding(b, ctx)
+	}
+
+	var label string
+	switch choice {
+	case "v3":
+		settings.IncludeV3, settings.IncludeV4 = true, false
+		label = "Ethereum V3"
+	case "v4":
+		settings.IncludeV3, settings.IncludeV4 = false, true
+		label = "Ethereum V4"
+	default:
+		settings.IncludeV3, settings.IncludeV4 = true, true
+		label = "Ethereum V3 and V4"
+	}
+
+	if err := h.db.SaveChatSettings(reqCtx, ctx.EffectiveChat.Id, settings); err != nil {
+		h.log(ctx).Errorw("Failed to save chat settings", "error", err)
+		return h.abortOnboarding(b, ctx)
+	}
+
+	if _, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{}); err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("I'll check %s.\n\nDo you want notifications when your positions change?", label)
+	_, _, err = cb.Message.EditText(b, msg, &gotgbot.EditMessageTextOpts{
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{
+			InlineKeyboard: [][]gotgbot.InlineKeyboardButton{{
+				{Text: "Yes, notify me", CallbackData: onboardingAlertsPrefix + "on"},
+				{Text: "No thanks", CallbackData: onboardingAlertsPrefix + "off"},
+			}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return handlers.NextConversationState(onboardingStateAlerts)
+}
+
+func (h *BotHandlers) handleOnboardingAlerts(b *gotgbot.Bot, ctx *ext.Context) error {
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	cb := ctx.CallbackQuery
+	choice := strings.TrimPrefix(cb.Data, onboardingAlertsPrefix)
+	h.log(ctx).Infow("Received onboarding alerts", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id, "choice", choice)
+
+	settings, err := h.db.GetChatSettings(reqCtx, ctx.EffectiveChat.Id)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get chat settings", "error", err)
+		return h.abortOnboarding(b, ctx)
+	}
+
+	settings.AlertsEnabled = choice == "on"
+	if err := h.db.SaveChatSettings(reqCtx, ctx.EffectiveChat.Id, settings); err != nil {
+		h.log(ctx).Errorw("Failed to save chat settings", "error", err)
+		return h.abortOnboarding(b, ctx)
+	}
+
+	if _, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{}); err != nil {
+		return err
+	}
+
+	msg := "You're all set! Use /status to see your positions, /add_wallet to track more wallets or /setup to run this again."
+	if _, _, err := cb.Message.EditText(b, msg, &gotgbot.EditMessageTextOpts{}); err != nil {
+		return err
+	}
+	return handlers.EndConversation()
+}
+
+func (h *BotHandlers) handleCancelOnboarding(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received cancel command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	_, err := ctx.EffectiveMessage.Reply(b, "Setup cancelled. Send /setup whenever you want to continue.", &gotgbot.SendMessageOpts{})
+	if err != nil {
+		return err
+	}
+	return handlers.EndConversation()
+}
+
+// abortOnboarding tells the user something went wrong and ends the conversation
+func (h *BotHandlers) abortOnboarding(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, err := ctx.EffectiveMessage.Reply(b, "Something went wrong while saving your preferences. Please try /setup again later.", &gotgbot.SendMessageOpts{})
+	if err != nil {
+		return err
+	}
+	return handlers.EndConversation()
+}
+
+// isPlainText matches text messages that aren't commands or quick actions
+func isPlainText(msg *gotgbot.Message) bool {
+	return msg.Text != "" && !strings.HasPrefix(msg.Text, "/") && !isQuickAction(msg)
+}
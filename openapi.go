@@ -0,0 +1,123 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPISpec documents the REST API. It is the source of truth for the API's request and
+// response shapes, which checkAPISpec holds the types below to.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// apiSchemas maps the schemas of the OpenAPI document to the types the API encodes them with
+var apiSchemas = map[string]reflect.Type{
+	"Error":             reflect.TypeFor[apiError](),
+	"Token":             reflect.TypeFor[apiToken](),
+	"PositionsResponse": reflect.TypeFor[apiPositionsResponse](),
+	"Position":          reflect.TypeFor[apiPosition](),
+	"Pool":              reflect.TypeFor[apiPool](),
+}
+
+type openAPISchema struct {
+	Type       string                   `yaml:"type"`
+	Ref        string                   `yaml:"$ref"`
+	Required   []string                 `yaml:"required"`
+	Properties map[string]openAPISchema `yaml:"properties"`
+	Items      *openAPISchema           `yaml:"items"`
+}
+
+// checkAPISpec reports every difference between the schemas of the OpenAPI document and the
+// types in apiSchemas: properties without a field or fields without a property, mismatching
+// types, and required properties whose field may be omitted.
+func checkAPISpec() error {
+	var spec struct {
+		Components struct {
+			Schemas map[string]openAPISchema `yaml:"schemas"`
+		} `yaml:"components"`
+	}
+	if err := yaml.Unmarshal(openAPISpec, &spec); err != nil {
+		return fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+
+	var errs []error
+	for name := range spec.Components.Schemas {
+		if _, ok := apiSchemas[name]; !ok {
+			errs = append(errs, fmt.Errorf("schema %s has no Go type", name))
+		}
+	}
+	for name, typ := range apiSchemas {
+		schema, ok := spec.Components.Schemas[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s has no schema", typ.Name()))
+			continue
+		}
+
+		fields := make(map[string]reflect.StructField)
+		for _, field := range reflect.VisibleFields(typ) {
+			if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+				fields[tag] = field
+			}
+		}
+		for prop, propSchema := range schema.Properties {
+			field, ok := fields[prop]
+			if !ok {
+				errs = append(errs, fmt.Errorf("schema %s: property %s has no field in %s", name, prop, typ.Name()))
+				continue
+			}
+			if !schemaMatchesType(propSchema, field.Type) {
+				errs = append(errs, fmt.Errorf("schema %s: property %s doesn't match the type of %s.%s", name, prop, typ.Name(), field.Name))
+			}
+			if slices.Contains(schema.Required, prop) && strings.Contains(field.Tag.Get("json"), ",omitempty") {
+				errs = append(errs, fmt.Errorf("schema %s: property %s is required, but %s.%s is omitted when empty", name, prop, typ.Name(), field.Name))
+			}
+		}
+		for prop, field := range fields {
+			if _, ok := schema.Properties[prop]; !ok {
+				errs = append(errs, fmt.Errorf("schema %s: field %s.%s is not documented as property %s", name, typ.Name(), field.Name, prop))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// schemaMatchesType reports whether values of typ are encoded as the schema describes
+func schemaMatchesType(schema openAPISchema, typ reflect.Type) bool {
+	if schema.Ref != "" {
+		ref := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		return apiSchemas[ref] == typ
+	}
+	switch schema.Type {
+	case "string":
+		return typ.Kind() == reflect.String
+	case "integer":
+		switch typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		}
+		return false
+	case "number":
+		return typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64
+	case "boolean":
+		return typ.Kind() == reflect.Bool
+	case "array":
+		return typ.Kind() == reflect.Slice && schema.Items != nil && schemaMatchesType(*schema.Items, typ.Elem())
+	default:
+		return false
+	}
+}
+
+// handleOpenAPISpec serves the OpenAPI document, which doesn't need an API key
+func handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPISpec)
+}
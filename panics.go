@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"go.uber.org/zap"
+)
+
+// panicApology is the reply to an update whose handler panicked
+const panicApology = "Sorry, something went wrong on our side. It has been reported, please try again later."
+
+// goroutinePanic is a panic recovered on another goroutine and raised again on the one waiting
+// for it, keeping the stack of where it happened
+type goroutinePanic struct {
+	value any
+	stack []byte
+}
+
+func (p *goroutinePanic) String() string {
+	return fmt.Sprint(p.value)
+}
+
+// panicStack returns the stack of where the panic with value r happened. It must be called while
+// recovering, so the stack still has the panicking frames.
+func panicStack(r any) []byte {
+	if p, ok := r.(*goroutinePanic); ok {
+		return p.stack
+	}
+	return debug.Stack()
+}
+
+// handlePanic is the dispatcher's panic handler. The dispatcher recovers from a handler that
+// panics, so one bad update doesn't take the bot down; this logs the panic with its stack,
+// reports it, and apologizes to the user, who would otherwise be left without an answer.
+func handlePanic(logger *zap.SugaredLogger) func(b *gotgbot.Bot, ctx *ext.Context, r any) {
+	return func(b *gotgbot.Bot, ctx *ext.Context, r any) {
+		updateLogger(ctx, logger).Errorw("Panic in handler", "panic", r, "stack", string(panicStack(r)))
+		reportHandlerPanic(ctx, r)
+		if err := apologize(b, ctx); err != nil {
+			updateLogger(ctx, logger).Warnw("Failed to apologize for panic", "error", err)
+		}
+	}
+}
+
+// apologize tells the user the update they sent couldn't be handled. Inline queries get no
+// answer, which Telegram shows as no results.
+func apologize(b *gotgbot.Bot, ctx *ext.Context) error {
+	switch {
+	case ctx.CallbackQuery != nil:
+		_, err := ctx.CallbackQuery.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: panicApology, ShowAlert: true})
+		return err
+	case ctx.EffectiveChat != nil:
+		_, err := b.SendMessage(ctx.EffectiveChat.Id, panicApology, &gotgbot.SendMessageOpts{})
+		return err
+	}
+	return nil
+}
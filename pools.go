@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+)
+
+// maxListedPools bounds how many pools /pools fetches, those with the most open positions first
+const maxListedPools = 10
+
+// chatPool is a pool the chat has open positions in
+type chatPool struct {
+	version   uniswap.PositionVersion
+	key       string
+	positions []uniswap.Position
+	stats     *uniswap.PoolStats
+}
+
+func (h *BotHandlers) handlePools(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received pools command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	source, ok := h.uniswapClient.(uniswap.PoolSource)
+	if !ok {
+		_, err := ctx.EffectiveMessage.Reply(b, "Pool statistics are not supported by the configured data source.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	statusMsg, err := ctx.EffectiveMessage.Reply(b, "Fetching pools...", &gotgbot.SendMessageOpts{})
+	if err != nil {
+		return err
+	}
+
+	bgCtx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	positions, failed, err := fetchChatPositions(bgCtx, h.db, h.uniswapClient, h.logger, ctx.EffectiveChat.Id)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to fetch positions", "chat_id", ctx.EffectiveChat.Id, "error", err)
+		_, _, err := statusMsg.EditText(b, "Failed to fetch pools. Please try again later.", &gotgbot.EditMessageTextOpts{})
+		return err
+	}
+
+	pools := groupByPool(positions)
+	if len(pools) > maxListedPools {
+		pools = pools[:maxListedPools]
+	}
+	for _, pool := range pools {
+		lookupCtx, cancel := newLookupContext(bgCtx)
+		pool.stats, err = source.GetPoolStats(lookupCtx, pool.version, pool.key)
+		cancel()
+		if err != nil {
+			h.log(ctx).Errorw("Failed to fetch pool stats", "pool", pool.key, "version", pool.version, "error", err)
+			failed++
+		}
+	}
+
+	_, _, err = statusMsg.EditText(b, formatPools(pools, failed), &gotgbot.EditMessageTextOpts{
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true},
+	})
+	return err
+}
+
+// groupByPool groups the open positions by pool, the pools with the most positions first.
+// Positions whose pool the data source didn't tell are left out.
+func groupByPool(positions []uniswap.Position) []*chatPool {
+	var pools []*chatPool
+	byKey := make(map[string]*chatPool)
+	for _, pos := range positions {
+		key := pos.PoolKey()
+		if key == "" || uniswap.IsClosed(pos) {
+			continue
+		}
+		pool, ok := byKey[string(pos.Version)+":"+key]
+		if !ok {
+			pool = &chatPool{version: pos.Version, key: key}
+			byKey[string(pos.Version)+":"+key] = pool
+			pools = append(pools, pool)
+		}
+		pool.positions = append(pool.positions, pos)
+	}
+	sort.SliceStable(pools, func(i, j int) bool {
+		return len(pools[i].positions) > len(pools[j].positions)
+	})
+	return pools
+}
+
+// formatPools lists the pools with their TVL, volume, fees and APR, the highest APR first, and
+// what each of the chat's positions in them would earn while in range
+func formatPools(pools []*chatPool, failed int) string {
+	var fetched []*chatPool
+	for _, pool := range pools {
+		if pool.stats != nil {
+			fetched = append(fetched, pool)
+		}
+	}
+	if len(fetched) == 0 {
+		if failed > 0 {
+			return "Failed to fetch pools. Please try again later."
+		}
+		return "No open positions found. Add a wallet with /add_wallet or a position with /track_position."
+	}
+	sort.SliceStable(fetched, func(i, j int) bool {
+		return fetched[i].stats.APR.APR > fetched[j].stats.APR.APR
+	})
+
+	var sb strings.Builder
+	sb.WriteString("Your pools\n\n")
+	for i, pool := range fetched {
+		stats := pool.stats
+		fmt.Fprintf(&sb, "%d. %s/%s %s %s\n", i+1, stats.Token0.Symbol, stats.Token1.Symbol, uniswap.FormatFeeTier(stats.FeeTier), stats.Version)
+		fmt.Fprintf(&sb, "   TVL: %s\n", formatUSD(stats.TVLUSD, true))
+		fmt.Fprintf(&sb, "   24h Volume: %s\n", formatUSD(stats.VolumeUSD24h, true))
+		fmt.Fprintf(&sb, "   24h Fees: %s\n", formatUSD(stats.APR.FeesUSD24h, true))
+		fmt.Fprintf(&sb, "   APR: %s\n", formatAPR(stats.APR.APR))
+		for _, pos := range pool.positions {
+			fmt.Fprintf(&sb, "   #%s: %s\n", pos.ID.String(), formatPositionAPR(stats.APR, pos))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("APR annualizes the pool's fees over the last 24 hours. A position's APR while in range is an estimate from how concentrated its liquidity is.")
+	if failed > 0 {
+		fmt.Fprintf(&sb, "\n%d lookups failed, some pools may be missing.", failed)
+	}
+	return sb.String()
+}
+
+// formatAPR formats an APR given as a fraction as a percentage, e.g. "12.3%"
+func formatAPR(apr float64) string {
+	return fmt.Sprintf("%.1f%%", apr*100)
+}
+
+// formatPositionAPR describes what a position in a pool with apr earns, e.g.
+// "~41.2% APR while in range, out of range now"
+func formatPositionAPR(apr uniswap.PoolAPR, pos uniswap.Position) string {
+	msg := "~" + formatAPR(apr.InRangeAPR(pos.TickLower, pos.TickUpper)) + " APR while in range"
+	if !pos.InRange() {
+		msg += ", out of range now"
+	}
+	return msg
+}
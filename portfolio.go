@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"go.uber.org/zap"
+)
+
+// priceTokens returns USD prices for the tokens of the positions, or nil if the client can't price tokens.
+// Tokens that couldn't be priced are missing from the result.
+func priceTokens(ctx context.Context, client uniswap.Client, positions []uniswap.Position, logger *zap.SugaredLogger) map[common.Address]float64 {
+	pricer, ok := client.(uniswap.PriceProvider)
+	if !ok {
+		return nil
+	}
+
+	tokens := make(map[uniswap.PositionVersion][]common.Address)
+	for _, pos := range positions {
+		tokens[pos.Version] = append(tokens[pos.Version], pos.Token0.Address, pos.Token1.Address)
+	}
+
+	prices := make(map[common.Address]float64)
+	for version, addresses := range tokens {
+		versionPrices, err := pricer.GetTokenPricesUSD(ctx, version, addresses)
+		if err != nil {
+			logger.Warnw("Failed to price tokens", "version", version, "error", err)
+			continue
+		}
+		for address, price := range versionPrices {
+			prices[address] = price
+		}
+	}
+	return prices
+}
+
+// fetchErrorMessage tells the user why positions couldn't be fetched, as far as the error says
+func fetchErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, uniswap.ErrRateLimited):
+		return "The Graph is limiting how often the bot may query it right now. Please try again in a minute."
+	case errors.Is(err, uniswap.ErrSubgraphStale):
+		return "The Uniswap subgraph is behind the chain right now, so positions can't be fetched. Please try again later."
+	case errors.Is(err, uniswap.ErrUnsupportedChain):
+		return fmt.Sprintf("Positions are only fetched on %s so far.", uniswap.ChainEthereum)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "The Graph didn't answer in time. Please try again later."
+	}
+	return "Failed to fetch positions. Please try again later."
+}
+
+// fetchChatPositions returns the positions of the chat's wallets and tracked positions, along
+// with the number of lookups that failed. Each lookup gets at most lookupTimeout of ctx's time.
+func fetchChatPositions(ctx context.Context, db Store, client uniswap.Client, logger *zap.SugaredLogger, chatID int64) ([]uniswap.Position, int, error) {
+	wallets, err := db.GetChatWallets(ctx, chatID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get wallets: %w", err)
+	}
+	tracked, err := db.GetTrackedPositions(ctx, chatID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get tracked positions: %w", err)
+	}
+	settings, err := db.GetChatSettings(ctx, chatID)
+	if err != nil {
+		logger.Errorw("Failed to get chat settings", "error", err)
+		settings = DefaultChatSettings
+	}
+
+	var positions []uniswap.Position
+	var failed int
+	for _, wallet := range wallets {
+		req := uniswap.PositionRequest{
+			WalletAddress: common.HexToAddress(wallet.WalletAddress),
+			IncludeV3:     settings.IncludeV3 && wallet.Version != string(uniswap.VersionV4),
+			IncludeV4:     settings.IncludeV4 && wallet.Version != string(uniswap.VersionV3),
+			IncludeClosed: true,
+		}
+		if !req.IncludeV3 && !req.IncludeV4 {
+			continue
+		}
+
+		lookupCtx, cancel := newLookupContext(ctx)
+		walletPositions, err := client.GetPositions(lookupCtx, req)
+		cancel()
+		if err != nil && !errors.Is(err, uniswap.ErrWalletHasNoPositions) && !errors.Is(err, uniswap.ErrPartialPositions) {
+			logger.Errorw("Failed to fetch positions", "wallet", wallet.WalletAddress, "error", err)
+			failed++
+			continue
+		}
+		positions = append(positions, walletPositions...)
+	}
+
+	// A tracked position may belong to one of the wallets too, it is only counted once
+	fetched := make(map[string]bool, len(positions))
+	for _, pos := range positions {
+		fetched[string(pos.Version)+":"+pos.ID.String()] = true
+	}
+
+	for _, tp := range tracked {
+		id, ok := new(big.Int).SetString(tp.PositionID, 10)
+		if !ok || fetched[tp.Version+":"+id.String()] {
+			continue
+		}
+		lookupCtx, cancel := newLookupContext(ctx)
+		pos, err := client.GetPosition(lookupCtx, uniswap.PositionVersion(tp.Version), id)
+		cancel()
+		if err != nil {
+			logger.Errorw("Failed to fetch tracked position", "position_id", tp.PositionID, "version", tp.Version, "error", err)
+			failed++
+			continue
+		}
+		positions = append(positions, *pos)
+	}
+	return positions, failed, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+)
+
+const positionUsage = "Usage: /position <position id> [v3|v4] [image]"
+
+func (h *BotHandlers) handlePosition(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received position command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	args := ctx.Args()
+	if len(args) < 2 {
+		_, err := ctx.EffectiveMessage.Reply(b, positionUsage, &gotgbot.SendMessageOpts{})
+		return err
+	}
+	args = args[1:]
+	withImage := strings.EqualFold(args[len(args)-1], "image")
+	if withImage {
+		args = args[:len(args)-1]
+	}
+	if len(args) == 0 {
+		_, err := ctx.EffectiveMessage.Reply(b, positionUsage, &gotgbot.SendMessageOpts{})
+		return err
+	}
+	id, versions, ok := parsePositionArgs(args)
+	if !ok {
+		_, err := ctx.EffectiveMessage.Reply(b, "Invalid position. "+positionUsage, &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	var pos *uniswap.Position
+	for _, version := range versions {
+		p, err := h.uniswapClient.GetPosition(reqCtx, version, id)
+		if errors.Is(err, uniswap.ErrPositionNotFound) {
+			continue
+		}
+		if err != nil {
+			h.log(ctx).Errorw("Failed to fetch position", "position_id", id.String(), "version", version, "error", err)
+			_, err := ctx.EffectiveMessage.Reply(b, "Failed to look up position. Please try again later.", &gotgbot.SendMessageOpts{})
+			return err
+		}
+		pos = p
+		break
+	}
+	if pos == nil {
+		_, err := ctx.EffectiveMessage.Reply(b, fmt.Sprintf("Position %s not found.", id.String()), &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	screened := []uniswap.Position{*pos}
+	h.screenTokens(reqCtx, screened)
+	pos = &screened[0]
+	msg := strings.TrimRight(formatPositionDetails(1, *pos), "\n") + "\n"
+	if source, ok := h.uniswapClient.(uniswap.PoolSource); ok && pos.PoolKey() != "" {
+		lookupCtx, cancel := newLookupContext(reqCtx)
+		stats, err := source.GetPoolStats(lookupCtx, pos.Version, pos.PoolKey())
+		cancel()
+		if err != nil {
+			h.log(ctx).Warnw("Failed to fetch pool stats", "pool", pos.PoolKey(), "version", pos.Version, "error", err)
+		} else {
+			msg += fmt.Sprintf("   Pool APR: %s (24h), %s\n", formatAPR(stats.APR.APR), formatPositionAPR(stats.APR, *pos))
+		}
+	}
+	if withImage {
+		switch {
+		case h.metadata == nil:
+			msg += "\nPosition images need an Ethereum node, which this bot isn't configured with."
+		case pos.Version != uniswap.VersionV3:
+			msg += "\nPosition images are only available for V3 positions."
+		}
+	}
+	if _, err := ctx.EffectiveMessage.Reply(b, strings.TrimRight(msg, "\n"), &gotgbot.SendMessageOpts{
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true},
+	}); err != nil {
+		return err
+	}
+	if !withImage || h.metadata == nil || pos.Version != uniswap.VersionV3 {
+		return nil
+	}
+
+	metadata, err := h.metadata.GetPositionMetadata(reqCtx, pos.Version, pos.ID)
+	var svg []byte
+	if err == nil {
+		svg, err = metadata.SVG()
+	}
+	if err != nil {
+		h.log(ctx).Errorw("Failed to fetch position image", "position_id", pos.ID.String(), "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to fetch the position's image. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	// Telegram doesn't show SVG as a photo, so the image is sent as a file that opens in a browser
+	_, err = b.SendDocument(ctx.EffectiveChat.Id, gotgbot.NamedFile{
+		File:     bytes.NewReader(svg),
+		FileName: fmt.Sprintf("position-%s.svg", pos.ID.String()),
+	}, &gotgbot.SendDocumentOpts{
+		Caption: metadata.Name,
+	})
+	return err
+}
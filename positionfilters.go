@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"github.com/korjavin/uniswapfetcher/uniswap/filter"
+)
+
+// positionFilters are the position filters a user or API client asked for, as the query
+// parameters of the REST API name them:
+//
+//	in_range=true       only positions in range (false: only those out of range)
+//	min_value_usd=1000  only positions worth at least $1,000
+//	token=WETH          only positions with one of the tokens, by symbol or address; repeatable
+//	version=v3          only positions of one Uniswap version
+//	chain=ethereum      only positions on the chain
+type positionFilters struct {
+	inRange     *bool
+	minValueUSD float64
+	tokens      []string
+	version     uniswap.PositionVersion
+	chain       string
+}
+
+// parsePositionFilters reads the filters from query parameters. Parameters that aren't filters
+// are ignored.
+func parsePositionFilters(params url.Values) (positionFilters, error) {
+	var f positionFilters
+	if s := params.Get("in_range"); s != "" {
+		inRange, err := strconv.ParseBool(s)
+		if err != nil {
+			return positionFilters{}, errors.New("in_range must be true or false")
+		}
+		f.inRange = &inRange
+	}
+	if s := params.Get("min_value_usd"); s != "" {
+		usd, ok := parseUSDArg(s)
+		if !ok {
+			return positionFilters{}, errors.New("min_value_usd must be a positive USD amount")
+		}
+		f.minValueUSD = usd
+	}
+	for _, token := range params["token"] {
+		for _, t := range strings.Split(token, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				f.tokens = append(f.tokens, t)
+			}
+		}
+	}
+	switch strings.ToLower(params.Get("version")) {
+	case "":
+	case "v3":
+		f.version = uniswap.VersionV3
+	case "v4":
+		f.version = uniswap.VersionV4
+	default:
+		return positionFilters{}, errors.New("version must be v3 or v4")
+	}
+	if chain := strings.ToLower(params.Get("chain")); chain != "" {
+		if _, ok := uniswap.ChainIDByName(chain); !ok {
+			return positionFilters{}, fmt.Errorf("%w %q", uniswap.ErrUnsupportedChain, chain)
+		}
+		f.chain = chain
+	}
+	return f, nil
+}
+
+// parsePositionFilterArgs reads the filters from command arguments, which are the query
+// parameters written as name=value, e.g. "token=WETH", or as a shorthand: "in_range",
+// "out_of_range", "v3", "v4" and "$1000" for min_value_usd=1000
+func parsePositionFilterArgs(args []string) (positionFilters, error) {
+	params := make(url.Values)
+	for _, arg := range args {
+		switch lower := strings.ToLower(arg); {
+		case lower == "in_range":
+			params.Set("in_range", "true")
+		case lower == "out_of_range":
+			params.Set("in_range", "false")
+		case lower == "v3" || lower == "v4":
+			params.Set("version", lower)
+		case strings.HasPrefix(arg, "$"):
+			params.Set("min_value_usd", arg)
+		default:
+			name, value, ok := strings.Cut(arg, "=")
+			if !ok || value == "" {
+				return positionFilters{}, fmt.Errorf("unknown filter %q", arg)
+			}
+			switch name = strings.ToLower(name); name {
+			case "in_range", "min_value_usd", "version", "chain":
+				params.Set(name, value)
+			case "token":
+				params.Add(name, value)
+			default:
+				return positionFilters{}, fmt.Errorf("unknown filter %q", name)
+			}
+		}
+	}
+	return parsePositionFilters(params)
+}
+
+// empty reports whether no filter was asked for
+func (f positionFilters) empty() bool {
+	return f.inRange == nil && f.minValueUSD == 0 && len(f.tokens) == 0 && f.version == "" && f.chain == ""
+}
+
+// needsPrices reports whether filter needs the USD prices of the positions' tokens
+func (f positionFilters) needsPrices() bool {
+	return f.minValueUSD > 0
+}
+
+// filter combines the filters asked for, pricing positions at pricesUSD
+func (f positionFilters) filter(pricesUSD map[common.Address]float64) filter.Filter {
+	var filters []filter.Filter
+	if f.inRange != nil {
+		inRange := filter.InRangeOnly()
+		if !*f.inRange {
+			inRange = filter.Not(inRange)
+		}
+		filters = append(filters, inRange)
+	}
+	if f.minValueUSD > 0 {
+		filters = append(filters, filter.MinValueUSD(f.minValueUSD, pricesUSD))
+	}
+	if len(f.tokens) > 0 {
+		tokens := make([]filter.Filter, 0, len(f.tokens))
+		for _, token := range f.tokens {
+			tokens = append(tokens, filter.Token(token))
+		}
+		filters = append(filters, filter.Any(tokens...))
+	}
+	if f.version != "" {
+		filters = append(filters, filter.Version(f.version))
+	}
+	if f.chain != "" {
+		filters = append(filters, filter.Chain(f.chain))
+	}
+	return filter.All(filters...)
+}
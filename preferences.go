@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+)
+
+// Values the bot supports for the user preferences that have a fixed set of options
+var (
+	supportedLanguages  = []string{"en"}
+	supportedCurrencies = []string{"USD"}
+	supportedChains     = []string{uniswap.ChainEthereum}
+)
+
+const preferencesUsage = `Usage:
+/preferences - show your preferences
+/preferences timezone <zone, e.g. Europe/Berlin>
+/preferences language <en>
+/preferences currency <USD>
+/preferences chains <ethereum>
+/preferences display <detailed|compact>
+/preferences versions <v3|v4|all>`
+
+func (h *BotHandlers) handlePreferences(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received preferences command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	settings, err := h.db.GetUserSettings(reqCtx, ctx.EffectiveUser.Id)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get user settings", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to retrieve preferences. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	args := ctx.Args()
+	if len(args) < 2 {
+		_, err := ctx.EffectiveMessage.Reply(b, formatUserSettings(settings)+"\n\n"+preferencesUsage, &gotgbot.SendMessageOpts{})
+		return err
+	}
+	if len(args) < 3 {
+		_, err := ctx.EffectiveMessage.Reply(b, preferencesUsage, &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	name := strings.ToLower(args[1])
+	if msg := applyUserSetting(&settings, name, args[2]); msg != "" {
+		_, err := ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	if name == "chains" {
+		entitlements, err := h.db.GetEntitlements(reqCtx, ctx.EffectiveUser.Id)
+		if err != nil {
+			h.log(ctx).Errorw("Failed to get entitlements", "error", err)
+			_, err := ctx.EffectiveMessage.Reply(b, "Failed to save preferences. Please try again later.", &gotgbot.SendMessageOpts{})
+			return err
+		}
+		for _, chain := range settings.Chains {
+			if !entitlements.AllowsChain(chain) {
+				_, err := ctx.EffectiveMessage.Reply(b, fmt.Sprintf("Your tier doesn't include %s.", chain), &gotgbot.SendMessageOpts{})
+				return err
+			}
+		}
+	}
+
+	if err := h.db.SaveUserSettings(reqCtx, ctx.EffectiveUser.Id, settings); err != nil {
+		h.log(ctx).Errorw("Failed to save user settings", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to save preferences. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	_, err = ctx.EffectiveMessage.Reply(b, "Preferences saved.\n\n"+formatUserSettings(settings), &gotgbot.SendMessageOpts{})
+	return err
+}
+
+// applyUserSetting sets one preference from its command argument. If the value is invalid it returns a message for the user instead.
+func applyUserSetting(settings *UserSettings, name, value string) string {
+	switch name {
+	case "timezone", "tz":
+		loc, err := time.LoadLocation(value)
+		if err != nil {
+			return fmt.Sprintf("Unknown time zone %q, please use a name such as Europe/Berlin or UTC.", value)
+		}
+		settings.Timezone = loc.String()
+	case "language":
+		language, ok := matchSupported(value, supportedLanguages)
+		if !ok {
+			return "Supported languages: " + strings.Join(supportedLanguages, ", ")
+		}
+		settings.Language = language
+	case "currency":
+		currency, ok := matchSupported(value, supportedCurrencies)
+		if !ok {
+			return "Supported currencies: " + strings.Join(supportedCurrencies, ", ")
+		}
+		settings.Currency = currency
+	case "chains":
+		var chains []string
+		for _, name := range strings.Split(value, ",") {
+			chain, ok := matchSupported(strings.TrimSpace(name), supportedChains)
+			if !ok {
+				return "Supported chains: " + strings.Join(supportedChains, ", ")
+			}
+			chains = append(chains, chain)
+		}
+		settings.Chains = chains
+	case "display":
+		switch DisplayMode(strings.ToLower(value)) {
+		case DisplayDetailed:
+			settings.DisplayMode = DisplayDetailed
+		case DisplayCompact:
+			settings.DisplayMode = DisplayCompact
+		default:
+			return "Please choose detailed or compact: /preferences display <detailed|compact>"
+		}
+	case "versions":
+		if strings.EqualFold(value, "all") {
+			settings.IncludeV3, settings.IncludeV4 = true, true
+			break
+		}
+		version, ok := parseVersionArg(value)
+		if !ok {
+			return "Please choose v3, v4 or all: /preferences versions <v3|v4|all>"
+		}
+		settings.IncludeV3, settings.IncludeV4 = version == uniswap.VersionV3, version == uniswap.VersionV4
+	default:
+		return preferencesUsage
+	}
+	return ""
+}
+
+// matchSupported returns the supported option equal to value ignoring case
+func matchSupported(value string, options []string) (string, bool) {
+	for _, option := range options {
+		if strings.EqualFold(value, option) {
+			return option, true
+		}
+	}
+	return "", false
+}
+
+func formatUserSettings(settings UserSettings) string {
+	versions := "V3 and V4"
+	switch {
+	case settings.IncludeV3 && !settings.IncludeV4:
+		versions = "V3"
+	case !settings.IncludeV3 && settings.IncludeV4:
+		versions = "V4"
+	}
+
+	return fmt.Sprintf(`Your preferences
+Language: %s
+Time zone: %s
+Currency: %s
+Chains: %s
+Inline mode: %s display, %s`, settings.Language, settings.Timezone, settings.Currency, strings.Join(settings.Chains, ", "), settings.DisplayMode, versions)
+}
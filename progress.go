@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"go.uber.org/zap"
+)
+
+// progressEditInterval is the minimum time between two progress edits of the same message,
+// keeping long fetches well clear of Telegram's flood limits.
+const progressEditInterval = 2 * time.Second
+
+// progressReporter edits a status message with "3/10 wallets done" style progress,
+// dropping updates that come in faster than progressEditInterval.
+type progressReporter struct {
+	mu     sync.Mutex
+	bot    *gotgbot.Bot
+	msg    gotgbot.MaybeInaccessibleMessage
+	total  int
+	unit   string
+	done   int
+	last   time.Time
+	logger *zap.SugaredLogger
+}
+
+func newProgressReporter(b *gotgbot.Bot, msg gotgbot.MaybeInaccessibleMessage, total int, unit string, logger *zap.SugaredLogger) *progressReporter {
+	return &progressReporter{
+		bot:    b,
+		msg:    msg,
+		total:  total,
+		unit:   unit,
+		last:   time.Now(),
+		logger: logger,
+	}
+}
+
+// Done marks one more item as finished and edits the message if enough time has passed
+func (p *progressReporter) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	if p.done >= p.total || time.Since(p.last) < progressEditInterval {
+		return
+	}
+	p.last = time.Now()
+
+	text := fmt.Sprintf("Fetching Uniswap positions... %d/%d %s done", p.done, p.total, p.unit)
+	if _, _, err := p.msg.EditText(p.bot, text, &gotgbot.EditMessageTextOpts{}); err != nil && !isMessageNotModified(err) {
+		p.logger.Warnw("Failed to update status message", "error", err)
+	}
+}
@@ -0,0 +1,7 @@
+// Package uniswapfetcherv1 holds the messages of the bot's gRPC service, generated by protoc-gen-go
+// from uniswapfetcher.proto. Regenerate them with go generate after changing it, which needs
+// protoc on the PATH.
+package uniswapfetcherv1
+
+//go:generate go install google.golang.org/protobuf/cmd/protoc-gen-go@v1.36.8
+//go:generate protoc --proto_path=../.. --go_out=../.. --go_opt=paths=source_relative uniswapfetcher/v1/uniswapfetcher.proto
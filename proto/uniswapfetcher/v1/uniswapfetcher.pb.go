@@ -0,0 +1,761 @@
+// The gRPC service of the bot, see "gRPC" in the README. The messages mirror the REST API's
+// schemas in openapi.yaml field for field, and grpcconv.go converts between the two: keep the
+// three in step, and never reuse a field number. Run go generate after changing this file.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v5.29.3
+// source: uniswapfetcher/v1/uniswapfetcher.proto
+
+package uniswapfetcherv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetPositionsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Wallet string                 `protobuf:"bytes,1,opt,name=wallet,proto3" json:"wallet,omitempty"`
+	// version is v3 or v4 to only list positions of that version
+	Version       string `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPositionsRequest) Reset() {
+	*x = GetPositionsRequest{}
+	mi := &file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPositionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPositionsRequest) ProtoMessage() {}
+
+func (x *GetPositionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPositionsRequest.ProtoReflect.Descriptor instead.
+func (*GetPositionsRequest) Descriptor() ([]byte, []int) {
+	return file_uniswapfetcher_v1_uniswapfetcher_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetPositionsRequest) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+func (x *GetPositionsRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type GetPoolRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPoolRequest) Reset() {
+	*x = GetPoolRequest{}
+	mi := &file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPoolRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPoolRequest) ProtoMessage() {}
+
+func (x *GetPoolRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPoolRequest.ProtoReflect.Descriptor instead.
+func (*GetPoolRequest) Descriptor() ([]byte, []int) {
+	return file_uniswapfetcher_v1_uniswapfetcher_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetPoolRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type StreamPositionUpdatesRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Wallet  string                 `protobuf:"bytes,1,opt,name=wallet,proto3" json:"wallet,omitempty"`
+	Version string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	// interval_seconds is how often to check the positions, at least 60, 300 if unset
+	IntervalSeconds int32 `protobuf:"varint,3,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StreamPositionUpdatesRequest) Reset() {
+	*x = StreamPositionUpdatesRequest{}
+	mi := &file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamPositionUpdatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamPositionUpdatesRequest) ProtoMessage() {}
+
+func (x *StreamPositionUpdatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamPositionUpdatesRequest.ProtoReflect.Descriptor instead.
+func (*StreamPositionUpdatesRequest) Descriptor() ([]byte, []int) {
+	return file_uniswapfetcher_v1_uniswapfetcher_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StreamPositionUpdatesRequest) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+func (x *StreamPositionUpdatesRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *StreamPositionUpdatesRequest) GetIntervalSeconds() int32 {
+	if x != nil {
+		return x.IntervalSeconds
+	}
+	return 0
+}
+
+type Token struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Symbol        string                 `protobuf:"bytes,2,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Decimals      uint32                 `protobuf:"varint,3,opt,name=decimals,proto3" json:"decimals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Token) Reset() {
+	*x = Token{}
+	mi := &file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Token) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Token) ProtoMessage() {}
+
+func (x *Token) ProtoReflect() protoreflect.Message {
+	mi := &file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Token.ProtoReflect.Descriptor instead.
+func (*Token) Descriptor() ([]byte, []int) {
+	return file_uniswapfetcher_v1_uniswapfetcher_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Token) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Token) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Token) GetDecimals() uint32 {
+	if x != nil {
+		return x.Decimals
+	}
+	return 0
+}
+
+// Position is a V3 or V4 position. Token amounts are raw integer amounts and, like other big
+// numbers, decimal strings so clients don't lose precision. Unknown numbers are empty.
+type Position struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version        string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Owner          string                 `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"`
+	Token0         *Token                 `protobuf:"bytes,4,opt,name=token0,proto3" json:"token0,omitempty"`
+	Token1         *Token                 `protobuf:"bytes,5,opt,name=token1,proto3" json:"token1,omitempty"`
+	FeeTier        uint32                 `protobuf:"varint,6,opt,name=fee_tier,json=feeTier,proto3" json:"fee_tier,omitempty"`
+	TickLower      int32                  `protobuf:"zigzag32,7,opt,name=tick_lower,json=tickLower,proto3" json:"tick_lower,omitempty"`
+	TickUpper      int32                  `protobuf:"zigzag32,8,opt,name=tick_upper,json=tickUpper,proto3" json:"tick_upper,omitempty"`
+	Liquidity      string                 `protobuf:"bytes,9,opt,name=liquidity,proto3" json:"liquidity,omitempty"`
+	Amount0        string                 `protobuf:"bytes,10,opt,name=amount0,proto3" json:"amount0,omitempty"`
+	Amount1        string                 `protobuf:"bytes,11,opt,name=amount1,proto3" json:"amount1,omitempty"`
+	UnclaimedFees0 string                 `protobuf:"bytes,12,opt,name=unclaimed_fees0,json=unclaimedFees0,proto3" json:"unclaimed_fees0,omitempty"`
+	UnclaimedFees1 string                 `protobuf:"bytes,13,opt,name=unclaimed_fees1,json=unclaimedFees1,proto3" json:"unclaimed_fees1,omitempty"`
+	PriceLower     string                 `protobuf:"bytes,14,opt,name=price_lower,json=priceLower,proto3" json:"price_lower,omitempty"`
+	PriceUpper     string                 `protobuf:"bytes,15,opt,name=price_upper,json=priceUpper,proto3" json:"price_upper,omitempty"`
+	CurrentPrice   string                 `protobuf:"bytes,16,opt,name=current_price,json=currentPrice,proto3" json:"current_price,omitempty"`
+	InRange        bool                   `protobuf:"varint,17,opt,name=in_range,json=inRange,proto3" json:"in_range,omitempty"`
+	// pool is the V3 pool's address or the V4 pool's ID
+	Pool          string `protobuf:"bytes,18,opt,name=pool,proto3" json:"pool,omitempty"`
+	ChainId       uint64 `protobuf:"varint,19,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Position) Reset() {
+	*x = Position{}
+	mi := &file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Position) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Position) ProtoMessage() {}
+
+func (x *Position) ProtoReflect() protoreflect.Message {
+	mi := &file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Position.ProtoReflect.Descriptor instead.
+func (*Position) Descriptor() ([]byte, []int) {
+	return file_uniswapfetcher_v1_uniswapfetcher_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Position) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Position) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Position) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Position) GetToken0() *Token {
+	if x != nil {
+		return x.Token0
+	}
+	return nil
+}
+
+func (x *Position) GetToken1() *Token {
+	if x != nil {
+		return x.Token1
+	}
+	return nil
+}
+
+func (x *Position) GetFeeTier() uint32 {
+	if x != nil {
+		return x.FeeTier
+	}
+	return 0
+}
+
+func (x *Position) GetTickLower() int32 {
+	if x != nil {
+		return x.TickLower
+	}
+	return 0
+}
+
+func (x *Position) GetTickUpper() int32 {
+	if x != nil {
+		return x.TickUpper
+	}
+	return 0
+}
+
+func (x *Position) GetLiquidity() string {
+	if x != nil {
+		return x.Liquidity
+	}
+	return ""
+}
+
+func (x *Position) GetAmount0() string {
+	if x != nil {
+		return x.Amount0
+	}
+	return ""
+}
+
+func (x *Position) GetAmount1() string {
+	if x != nil {
+		return x.Amount1
+	}
+	return ""
+}
+
+func (x *Position) GetUnclaimedFees0() string {
+	if x != nil {
+		return x.UnclaimedFees0
+	}
+	return ""
+}
+
+func (x *Position) GetUnclaimedFees1() string {
+	if x != nil {
+		return x.UnclaimedFees1
+	}
+	return ""
+}
+
+func (x *Position) GetPriceLower() string {
+	if x != nil {
+		return x.PriceLower
+	}
+	return ""
+}
+
+func (x *Position) GetPriceUpper() string {
+	if x != nil {
+		return x.PriceUpper
+	}
+	return ""
+}
+
+func (x *Position) GetCurrentPrice() string {
+	if x != nil {
+		return x.CurrentPrice
+	}
+	return ""
+}
+
+func (x *Position) GetInRange() bool {
+	if x != nil {
+		return x.InRange
+	}
+	return false
+}
+
+func (x *Position) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+func (x *Position) GetChainId() uint64 {
+	if x != nil {
+		return x.ChainId
+	}
+	return 0
+}
+
+// Portfolio is a wallet's positions, the PositionsResponse of the REST API
+type Portfolio struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Wallet        string                 `protobuf:"bytes,1,opt,name=wallet,proto3" json:"wallet,omitempty"`
+	Positions     []*Position            `protobuf:"bytes,2,rep,name=positions,proto3" json:"positions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Portfolio) Reset() {
+	*x = Portfolio{}
+	mi := &file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Portfolio) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Portfolio) ProtoMessage() {}
+
+func (x *Portfolio) ProtoReflect() protoreflect.Message {
+	mi := &file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Portfolio.ProtoReflect.Descriptor instead.
+func (*Portfolio) Descriptor() ([]byte, []int) {
+	return file_uniswapfetcher_v1_uniswapfetcher_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Portfolio) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+func (x *Portfolio) GetPositions() []*Position {
+	if x != nil {
+		return x.Positions
+	}
+	return nil
+}
+
+type Pool struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Token0        *Token                 `protobuf:"bytes,3,opt,name=token0,proto3" json:"token0,omitempty"`
+	Token1        *Token                 `protobuf:"bytes,4,opt,name=token1,proto3" json:"token1,omitempty"`
+	FeeTier       uint32                 `protobuf:"varint,5,opt,name=fee_tier,json=feeTier,proto3" json:"fee_tier,omitempty"`
+	Liquidity     string                 `protobuf:"bytes,6,opt,name=liquidity,proto3" json:"liquidity,omitempty"`
+	SqrtPrice     string                 `protobuf:"bytes,7,opt,name=sqrt_price,json=sqrtPrice,proto3" json:"sqrt_price,omitempty"`
+	Tick          int32                  `protobuf:"zigzag32,8,opt,name=tick,proto3" json:"tick,omitempty"`
+	Token0Price   string                 `protobuf:"bytes,9,opt,name=token0_price,json=token0Price,proto3" json:"token0_price,omitempty"`
+	Token1Price   string                 `protobuf:"bytes,10,opt,name=token1_price,json=token1Price,proto3" json:"token1_price,omitempty"`
+	TvlUsd        float64                `protobuf:"fixed64,11,opt,name=tvl_usd,json=tvlUsd,proto3" json:"tvl_usd,omitempty"`
+	VolumeUsd     float64                `protobuf:"fixed64,12,opt,name=volume_usd,json=volumeUsd,proto3" json:"volume_usd,omitempty"`
+	FeesUsd       float64                `protobuf:"fixed64,13,opt,name=fees_usd,json=feesUsd,proto3" json:"fees_usd,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Pool) Reset() {
+	*x = Pool{}
+	mi := &file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pool) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pool) ProtoMessage() {}
+
+func (x *Pool) ProtoReflect() protoreflect.Message {
+	mi := &file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pool.ProtoReflect.Descriptor instead.
+func (*Pool) Descriptor() ([]byte, []int) {
+	return file_uniswapfetcher_v1_uniswapfetcher_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Pool) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Pool) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Pool) GetToken0() *Token {
+	if x != nil {
+		return x.Token0
+	}
+	return nil
+}
+
+func (x *Pool) GetToken1() *Token {
+	if x != nil {
+		return x.Token1
+	}
+	return nil
+}
+
+func (x *Pool) GetFeeTier() uint32 {
+	if x != nil {
+		return x.FeeTier
+	}
+	return 0
+}
+
+func (x *Pool) GetLiquidity() string {
+	if x != nil {
+		return x.Liquidity
+	}
+	return ""
+}
+
+func (x *Pool) GetSqrtPrice() string {
+	if x != nil {
+		return x.SqrtPrice
+	}
+	return ""
+}
+
+func (x *Pool) GetTick() int32 {
+	if x != nil {
+		return x.Tick
+	}
+	return 0
+}
+
+func (x *Pool) GetToken0Price() string {
+	if x != nil {
+		return x.Token0Price
+	}
+	return ""
+}
+
+func (x *Pool) GetToken1Price() string {
+	if x != nil {
+		return x.Token1Price
+	}
+	return ""
+}
+
+func (x *Pool) GetTvlUsd() float64 {
+	if x != nil {
+		return x.TvlUsd
+	}
+	return 0
+}
+
+func (x *Pool) GetVolumeUsd() float64 {
+	if x != nil {
+		return x.VolumeUsd
+	}
+	return 0
+}
+
+func (x *Pool) GetFeesUsd() float64 {
+	if x != nil {
+		return x.FeesUsd
+	}
+	return 0
+}
+
+var File_uniswapfetcher_v1_uniswapfetcher_proto protoreflect.FileDescriptor
+
+const file_uniswapfetcher_v1_uniswapfetcher_proto_rawDesc = "" +
+	"\n" +
+	"&uniswapfetcher/v1/uniswapfetcher.proto\x12\x11uniswapfetcher.v1\"G\n" +
+	"\x13GetPositionsRequest\x12\x16\n" +
+	"\x06wallet\x18\x01 \x01(\tR\x06wallet\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\" \n" +
+	"\x0eGetPoolRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"{\n" +
+	"\x1cStreamPositionUpdatesRequest\x12\x16\n" +
+	"\x06wallet\x18\x01 \x01(\tR\x06wallet\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12)\n" +
+	"\x10interval_seconds\x18\x03 \x01(\x05R\x0fintervalSeconds\"U\n" +
+	"\x05Token\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x16\n" +
+	"\x06symbol\x18\x02 \x01(\tR\x06symbol\x12\x1a\n" +
+	"\bdecimals\x18\x03 \x01(\rR\bdecimals\"\xdc\x04\n" +
+	"\bPosition\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12\x14\n" +
+	"\x05owner\x18\x03 \x01(\tR\x05owner\x120\n" +
+	"\x06token0\x18\x04 \x01(\v2\x18.uniswapfetcher.v1.TokenR\x06token0\x120\n" +
+	"\x06token1\x18\x05 \x01(\v2\x18.uniswapfetcher.v1.TokenR\x06token1\x12\x19\n" +
+	"\bfee_tier\x18\x06 \x01(\rR\afeeTier\x12\x1d\n" +
+	"\n" +
+	"tick_lower\x18\a \x01(\x11R\ttickLower\x12\x1d\n" +
+	"\n" +
+	"tick_upper\x18\b \x01(\x11R\ttickUpper\x12\x1c\n" +
+	"\tliquidity\x18\t \x01(\tR\tliquidity\x12\x18\n" +
+	"\aamount0\x18\n" +
+	" \x01(\tR\aamount0\x12\x18\n" +
+	"\aamount1\x18\v \x01(\tR\aamount1\x12'\n" +
+	"\x0funclaimed_fees0\x18\f \x01(\tR\x0eunclaimedFees0\x12'\n" +
+	"\x0funclaimed_fees1\x18\r \x01(\tR\x0eunclaimedFees1\x12\x1f\n" +
+	"\vprice_lower\x18\x0e \x01(\tR\n" +
+	"priceLower\x12\x1f\n" +
+	"\vprice_upper\x18\x0f \x01(\tR\n" +
+	"priceUpper\x12#\n" +
+	"\rcurrent_price\x18\x10 \x01(\tR\fcurrentPrice\x12\x19\n" +
+	"\bin_range\x18\x11 \x01(\bR\ainRange\x12\x12\n" +
+	"\x04pool\x18\x12 \x01(\tR\x04pool\x12\x19\n" +
+	"\bchain_id\x18\x13 \x01(\x04R\achainId\"^\n" +
+	"\tPortfolio\x12\x16\n" +
+	"\x06wallet\x18\x01 \x01(\tR\x06wallet\x129\n" +
+	"\tpositions\x18\x02 \x03(\v2\x1b.uniswapfetcher.v1.PositionR\tpositions\"\x99\x03\n" +
+	"\x04Pool\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x120\n" +
+	"\x06token0\x18\x03 \x01(\v2\x18.uniswapfetcher.v1.TokenR\x06token0\x120\n" +
+	"\x06token1\x18\x04 \x01(\v2\x18.uniswapfetcher.v1.TokenR\x06token1\x12\x19\n" +
+	"\bfee_tier\x18\x05 \x01(\rR\afeeTier\x12\x1c\n" +
+	"\tliquidity\x18\x06 \x01(\tR\tliquidity\x12\x1d\n" +
+	"\n" +
+	"sqrt_price\x18\a \x01(\tR\tsqrtPrice\x12\x12\n" +
+	"\x04tick\x18\b \x01(\x11R\x04tick\x12!\n" +
+	"\ftoken0_price\x18\t \x01(\tR\vtoken0Price\x12!\n" +
+	"\ftoken1_price\x18\n" +
+	" \x01(\tR\vtoken1Price\x12\x17\n" +
+	"\atvl_usd\x18\v \x01(\x01R\x06tvlUsd\x12\x1d\n" +
+	"\n" +
+	"volume_usd\x18\f \x01(\x01R\tvolumeUsd\x12\x19\n" +
+	"\bfees_usd\x18\r \x01(\x01R\afeesUsd2\x97\x02\n" +
+	"\x0eUniswapFetcher\x12T\n" +
+	"\fGetPositions\x12&.uniswapfetcher.v1.GetPositionsRequest\x1a\x1c.uniswapfetcher.v1.Portfolio\x12E\n" +
+	"\aGetPool\x12!.uniswapfetcher.v1.GetPoolRequest\x1a\x17.uniswapfetcher.v1.Pool\x12h\n" +
+	"\x15StreamPositionUpdates\x12/.uniswapfetcher.v1.StreamPositionUpdatesRequest\x1a\x1c.uniswapfetcher.v1.Portfolio0\x01BMZKgithub.com/korjavin/uniswapfetcher/proto/uniswapfetcher/v1;uniswapfetcherv1b\x06proto3"
+
+var (
+	file_uniswapfetcher_v1_uniswapfetcher_proto_rawDescOnce sync.Once
+	file_uniswapfetcher_v1_uniswapfetcher_proto_rawDescData []byte
+)
+
+func file_uniswapfetcher_v1_uniswapfetcher_proto_rawDescGZIP() []byte {
+	file_uniswapfetcher_v1_uniswapfetcher_proto_rawDescOnce.Do(func() {
+		file_uniswapfetcher_v1_uniswapfetcher_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_uniswapfetcher_v1_uniswapfetcher_proto_rawDesc), len(file_uniswapfetcher_v1_uniswapfetcher_proto_rawDesc)))
+	})
+	return file_uniswapfetcher_v1_uniswapfetcher_proto_rawDescData
+}
+
+var file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_uniswapfetcher_v1_uniswapfetcher_proto_goTypes = []any{
+	(*GetPositionsRequest)(nil),          // 0: uniswapfetcher.v1.GetPositionsRequest
+	(*GetPoolRequest)(nil),               // 1: uniswapfetcher.v1.GetPoolRequest
+	(*StreamPositionUpdatesRequest)(nil), // 2: uniswapfetcher.v1.StreamPositionUpdatesRequest
+	(*Token)(nil),                        // 3: uniswapfetcher.v1.Token
+	(*Position)(nil),                     // 4: uniswapfetcher.v1.Position
+	(*Portfolio)(nil),                    // 5: uniswapfetcher.v1.Portfolio
+	(*Pool)(nil),                         // 6: uniswapfetcher.v1.Pool
+}
+var file_uniswapfetcher_v1_uniswapfetcher_proto_depIdxs = []int32{
+	3, // 0: uniswapfetcher.v1.Position.token0:type_name -> uniswapfetcher.v1.Token
+	3, // 1: uniswapfetcher.v1.Position.token1:type_name -> uniswapfetcher.v1.Token
+	4, // 2: uniswapfetcher.v1.Portfolio.positions:type_name -> uniswapfetcher.v1.Position
+	3, // 3: uniswapfetcher.v1.Pool.token0:type_name -> uniswapfetcher.v1.Token
+	3, // 4: uniswapfetcher.v1.Pool.token1:type_name -> uniswapfetcher.v1.Token
+	0, // 5: uniswapfetcher.v1.UniswapFetcher.GetPositions:input_type -> uniswapfetcher.v1.GetPositionsRequest
+	1, // 6: uniswapfetcher.v1.UniswapFetcher.GetPool:input_type -> uniswapfetcher.v1.GetPoolRequest
+	2, // 7: uniswapfetcher.v1.UniswapFetcher.StreamPositionUpdates:input_type -> uniswapfetcher.v1.StreamPositionUpdatesRequest
+	5, // 8: uniswapfetcher.v1.UniswapFetcher.GetPositions:output_type -> uniswapfetcher.v1.Portfolio
+	6, // 9: uniswapfetcher.v1.UniswapFetcher.GetPool:output_type -> uniswapfetcher.v1.Pool
+	5, // 10: uniswapfetcher.v1.UniswapFetcher.StreamPositionUpdates:output_type -> uniswapfetcher.v1.Portfolio
+	8, // [8:11] is the sub-list for method output_type
+	5, // [5:8] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_uniswapfetcher_v1_uniswapfetcher_proto_init() }
+func file_uniswapfetcher_v1_uniswapfetcher_proto_init() {
+	if File_uniswapfetcher_v1_uniswapfetcher_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_uniswapfetcher_v1_uniswapfetcher_proto_rawDesc), len(file_uniswapfetcher_v1_uniswapfetcher_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_uniswapfetcher_v1_uniswapfetcher_proto_goTypes,
+		DependencyIndexes: file_uniswapfetcher_v1_uniswapfetcher_proto_depIdxs,
+		MessageInfos:      file_uniswapfetcher_v1_uniswapfetcher_proto_msgTypes,
+	}.Build()
+	File_uniswapfetcher_v1_uniswapfetcher_proto = out.File
+	file_uniswapfetcher_v1_uniswapfetcher_proto_goTypes = nil
+	file_uniswapfetcher_v1_uniswapfetcher_proto_depIdxs = nil
+}
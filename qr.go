@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/skip2/go-qrcode"
+)
+
+// walletQRCallbackPrefix is the callback data prefix of the QR buttons under /list_wallets
+const walletQRCallbackPrefix = "wallet_qr:"
+
+// qrCodeSize is the width and height of QR code images in pixels
+const qrCodeSize = 512
+
+// walletQRKeyboard offers a QR button per wallet, at most 50 as Telegram limits keyboards to 100 buttons
+func walletQRKeyboard(wallets []ChatWallet) gotgbot.InlineKeyboardMarkup {
+	var rows [][]gotgbot.InlineKeyboardButton
+	for i, wallet := range wallets {
+		if i == 50 {
+			break
+		}
+		name := wallet.Label
+		if name == "" {
+			name = shortAddress(wallet.WalletAddress)
+		}
+		button := gotgbot.InlineKeyboardButton{
+			Text:         "QR " + name,
+			CallbackData: walletQRCallbackPrefix + wallet.WalletAddress,
+		}
+		// Two buttons per row
+		if i%2 == 0 {
+			rows = append(rows, []gotgbot.InlineKeyboardButton{button})
+		} else {
+			rows[len(rows)-1] = append(rows[len(rows)-1], button)
+		}
+	}
+	return gotgbot.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func (h *BotHandlers) handleWalletQRCallback(b *gotgbot.Bot, ctx *ext.Context) error {
+	cb := ctx.CallbackQuery
+	walletAddress := strings.TrimPrefix(cb.Data, walletQRCallbackPrefix)
+	h.log(ctx).Infow("Received wallet QR callback", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id, "address", walletAddress)
+
+	address, err := parseWalletAddress(walletAddress)
+	if err != nil {
+		_, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: "Invalid wallet address."})
+		return err
+	}
+
+	png, err := qrcode.Encode(address.Hex(), qrcode.Medium, qrCodeSize)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to render QR code", "address", address.Hex(), "error", err)
+		_, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      "Failed to render QR code. Please try again later.",
+			ShowAlert: true,
+		})
+		return err
+	}
+
+	if _, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{}); err != nil {
+		return err
+	}
+
+	_, err = b.SendPhoto(ctx.EffectiveChat.Id, gotgbot.NamedFile{
+		File:     bytes.NewReader(png),
+		FileName: "wallet.png",
+	}, &gotgbot.SendPhotoOpts{
+		Caption: address.Hex(),
+	})
+	return err
+}
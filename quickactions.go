@@ -0,0 +1,66 @@
+package main
+
+import (
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+)
+
+// Labels of the quick-action reply keyboard buttons, which arrive as plain text messages
+const (
+	quickActionStatus   = "📊 Status"
+	quickActionFees     = "💰 Fees"
+	quickActionSettings = "⚙️ Settings"
+)
+
+// quickActionKeyboard is the persistent reply keyboard shown while quick actions are enabled
+func quickActionKeyboard() gotgbot.ReplyKeyboardMarkup {
+	return gotgbot.ReplyKeyboardMarkup{
+		Keyboard: [][]gotgbot.KeyboardButton{{
+			{Text: quickActionStatus},
+			{Text: quickActionFees},
+			{Text: quickActionSettings},
+		}},
+		IsPersistent:   true,
+		ResizeKeyboard: true,
+	}
+}
+
+// isQuickAction matches the text sent by a quick-action button
+func isQuickAction(msg *gotgbot.Message) bool {
+	switch msg.Text {
+	case quickActionStatus, quickActionFees, quickActionSettings:
+		return true
+	}
+	return false
+}
+
+func (h *BotHandlers) newQuickActionHandler() ext.Handler {
+	return handlers.NewMessage(isQuickAction, h.handleQuickAction)
+}
+
+func (h *BotHandlers) handleQuickAction(b *gotgbot.Bot, ctx *ext.Context) error {
+	switch ctx.EffectiveMessage.Text {
+	case quickActionStatus:
+		return h.handleStatus(b, ctx)
+	case quickActionFees:
+		return h.handleFees(b, ctx)
+	default:
+		return h.handleSettings(b, ctx)
+	}
+}
+
+// sendQuickActionKeyboard shows or hides the quick-action keyboard. Reply keyboards can only be
+// changed by sending a new message.
+func (h *BotHandlers) sendQuickActionKeyboard(b *gotgbot.Bot, chatID int64, enabled bool) error {
+	if enabled {
+		_, err := b.SendMessage(chatID, "Quick actions enabled, use the buttons below.", &gotgbot.SendMessageOpts{
+			ReplyMarkup: quickActionKeyboard(),
+		})
+		return err
+	}
+	_, err := b.SendMessage(chatID, "Quick actions hidden.", &gotgbot.SendMessageOpts{
+		ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
+	})
+	return err
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// statusCooldown is the minimum time between two expensive refreshes for the same user in the same chat
+const statusCooldown = 30 * time.Second
+
+type throttleKey struct {
+	userID int64
+	chatID int64
+}
+
+type throttleEntry struct {
+	at     time.Time
+	result string
+}
+
+// commandThrottle limits how often a user can run an expensive command and remembers the
+// last result so it can be served again while the user is throttled.
+type commandThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	entries  map[throttleKey]throttleEntry
+}
+
+func newCommandThrottle(interval time.Duration) *commandThrottle {
+	return &commandThrottle{
+		interval: interval,
+		entries:  make(map[throttleKey]throttleEntry),
+	}
+}
+
+// Recent returns the cached result and its age if the user ran the command within the
+// throttle interval.
+func (t *commandThrottle) Recent(userID, chatID int64) (string, time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[throttleKey{userID, chatID}]
+	if !ok {
+		return "", 0, false
+	}
+
+	age := time.Since(entry.at)
+	if age >= t.interval {
+		return "", 0, false
+	}
+	return entry.result, age, true
+}
+
+// Store records a fresh result for the user and drops expired entries.
+func (t *commandThrottle) Store(userID, chatID int64, result string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range t.entries {
+		if now.Sub(entry.at) >= t.interval {
+			delete(t.entries, key)
+		}
+	}
+	t.entries[throttleKey{userID, chatID}] = throttleEntry{at: now, result: result}
+}
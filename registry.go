@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// registryPollInterval is how often the registry file is checked for changes
+const registryPollInterval = 30 * time.Second
+
+// registryFile is the YAML registry of the chains positions are fetched on and the tokens whose
+// symbols and decimals are known, which can be changed while the bot runs
+type registryFile struct {
+	Chains []struct {
+		Name       string `yaml:"name"`
+		SubgraphV3 string `yaml:"subgraph_v3"`
+		SubgraphV4 string `yaml:"subgraph_v4"`
+	} `yaml:"chains"`
+	Tokens []struct {
+		Address  string `yaml:"address"`
+		Chain    string `yaml:"chain"`
+		Symbol   string `yaml:"symbol"`
+		Decimals uint8  `yaml:"decimals"`
+		LogoURI  string `yaml:"logo_uri"`
+	} `yaml:"tokens"`
+}
+
+// registryClient is the part of the Uniswap client the registry configures
+type registryClient interface {
+	SetChain(chain uniswap.Chain) error
+	SetKnownTokens(tokens []uniswap.TokenMetadata)
+}
+
+// tokenListRefreshInterval is how often token lists are read again, to pick up newly listed tokens
+const tokenListRefreshInterval = 6 * time.Hour
+
+// tokenListRetryInterval is how soon token lists are read again after one couldn't be
+const tokenListRetryInterval = 10 * time.Minute
+
+// errStaleTokenLists is returned by RegistryLoader.Load, wrapped, when some token lists couldn't
+// be read and their tokens from before were applied instead
+var errStaleTokenLists = errors.New("token lists could not be read")
+
+// RegistryLoader applies the registry file and the token lists to the Uniswap client, at startup,
+// whenever the file changes or the token lists are due for a refresh, and when an administrator
+// asks for it with /reload_registry. A registry file with mistakes is rejected along with the
+// rest, leaving the registry as it was. A token list that can't be read, e.g. because its host is
+// down, is replaced by its tokens from before, or none if it was never read.
+type RegistryLoader struct {
+	// path is the registry file, empty if there is none
+	path string
+	// tokenLists are the files and URLs of the token lists, whose tokens the registry file's
+	// tokens take precedence over
+	tokenLists []string
+	client     registryClient
+	logger     *zap.SugaredLogger
+
+	mu       sync.Mutex
+	modTime  time.Time
+	loadedAt time.Time
+	// listed are the tokens last read from each token list
+	listed map[string][]uniswap.TokenMetadata
+	// stale is whether some token lists couldn't be read last time
+	stale bool
+}
+
+func NewRegistryLoader(path string, tokenLists []string, client registryClient, logger *zap.SugaredLogger) *RegistryLoader {
+	return &RegistryLoader{
+		path:       path,
+		tokenLists: tokenLists,
+		client:     client,
+		logger:     logger,
+		listed:     make(map[string][]uniswap.TokenMetadata),
+	}
+}
+
+// Load reads the registry file and the token lists and applies them, returning how many chains
+// and known tokens they have. If some token lists can't be read, it applies the rest and returns
+// an error that is errStaleTokenLists.
+func (l *RegistryLoader) Load(ctx context.Context) (chains, tokens int, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var chainList []uniswap.Chain
+	var registryTokens, listedTokens []uniswap.TokenMetadata
+	var modTime time.Time
+	if l.path != "" {
+		info, err := os.Stat(l.path)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to read registry: %w", err)
+		}
+		data, err := os.ReadFile(l.path)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to read registry: %w", err)
+		}
+		if chainList, registryTokens, err = parseRegistry(data); err != nil {
+			return 0, 0, fmt.Errorf("invalid registry %s: %w", l.path, err)
+		}
+		modTime = info.ModTime()
+	}
+	listed := make(map[string][]uniswap.TokenMetadata, len(l.tokenLists))
+	var listErrs []error
+	for _, source := range l.tokenLists {
+		sourceTokens, err := l.readTokenList(ctx, source)
+		if err != nil {
+			listErrs = append(listErrs, err)
+			sourceTokens = l.listed[source]
+		}
+		listed[source] = sourceTokens
+		listedTokens = append(listedTokens, sourceTokens...)
+	}
+
+	for _, chain := range chainList {
+		if err := l.client.SetChain(chain); err != nil {
+			return 0, 0, fmt.Errorf("invalid registry %s: chain %s: %w", l.path, chain.Name, err)
+		}
+	}
+	known := mergeKnownTokens(registryTokens, listedTokens)
+	l.client.SetKnownTokens(known)
+	l.modTime = modTime
+	l.loadedAt = time.Now()
+	l.listed = listed
+	l.stale = len(listErrs) > 0
+	if l.stale {
+		return len(chainList), len(known), fmt.Errorf("%w: %w", errStaleTokenLists, errors.Join(listErrs...))
+	}
+	return len(chainList), len(known), nil
+}
+
+// readTokenList reads and parses the token list at source, logging the invalid tokens it skips
+func (l *RegistryLoader) readTokenList(ctx context.Context, source string) ([]uniswap.TokenMetadata, error) {
+	data, err := readFileOrURL(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token list %s: %w", source, err)
+	}
+	tokens, invalid, err := parseTokenList(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token list %s: %w", source, err)
+	}
+	if len(invalid) > 0 {
+		requestLogger(ctx, l.logger).Warnw("Skipped invalid tokens in token list", "source", source, "tokens", len(invalid), "error", errors.Join(invalid...))
+	}
+	return tokens, nil
+}
+
+// Job reloads the registry file when it changed, and the token lists every
+// tokenListRefreshInterval, or tokenListRetryInterval after some couldn't be read. Every replica
+// runs it, since each has its own client.
+func (l *RegistryLoader) Job() Job {
+	return Job{Name: "registry reload", Interval: registryPollInterval, Run: l.reloadChanged}
+}
+
+func (l *RegistryLoader) reloadChanged(ctx context.Context) error {
+	l.mu.Lock()
+	refreshInterval := tokenListRefreshInterval
+	if l.stale {
+		refreshInterval = tokenListRetryInterval
+	}
+	changed := len(l.tokenLists) > 0 && time.Since(l.loadedAt) >= refreshInterval
+	modTime := l.modTime
+	l.mu.Unlock()
+	if l.path != "" {
+		info, err := os.Stat(l.path)
+		if err != nil {
+			return fmt.Errorf("failed to read registry: %w", err)
+		}
+		changed = changed || !info.ModTime().Equal(modTime)
+	}
+	if !changed {
+		return nil
+	}
+
+	chains, tokens, err := l.Load(ctx)
+	if errors.Is(err, errStaleTokenLists) {
+		requestLogger(ctx, l.logger).Warnw("Reloaded registry with token lists from before", "error", err)
+	} else if err != nil {
+		return err
+	}
+	requestLogger(ctx, l.logger).Infow("Reloaded registry", "path", l.path, "token_lists", len(l.tokenLists), "chains", chains, "tokens", tokens)
+	return nil
+}
+
+// parseRegistry validates a registry file, so that none of it is applied unless all of it is valid
+func parseRegistry(data []byte) ([]uniswap.Chain, []uniswap.TokenMetadata, error) {
+	var file registryFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, err
+	}
+
+	var errs []error
+	chains := make([]uniswap.Chain, 0, len(file.Chains))
+	seenChains := make(map[string]bool)
+	for i, entry := range file.Chains {
+		name := strings.ToLower(strings.TrimSpace(entry.Name))
+		switch {
+		case name != uniswap.ChainEthereum:
+			errs = append(errs, fmt.Errorf("chain %d: unsupported chain %q, positions are only fetched on %s", i+1, entry.Name, uniswap.ChainEthereum))
+		case seenChains[name]:
+			errs = append(errs, fmt.Errorf("chain %d: %s is listed twice", i+1, name))
+		case entry.SubgraphV3 == "" || entry.SubgraphV4 == "":
+			errs = append(errs, fmt.Errorf("chain %d: subgraph_v3 and subgraph_v4 are required", i+1))
+		}
+		seenChains[name] = true
+		chains = append(chains, uniswap.Chain{Name: name, SubgraphV3: entry.SubgraphV3, SubgraphV4: entry.SubgraphV4})
+	}
+
+	tokens := make([]uniswap.TokenMetadata, 0, len(file.Tokens))
+	seenTokens := make(map[common.Address]bool)
+	for i, entry := range file.Tokens {
+		chain := strings.ToLower(strings.TrimSpace(entry.Chain))
+		if chain == "" {
+			chain = uniswap.ChainEthereum
+		}
+		address := common.HexToAddress(entry.Address)
+		switch {
+		case !common.IsHexAddress(entry.Address):
+			errs = append(errs, fmt.Errorf("token %d: invalid address %q", i+1, entry.Address))
+		case chain != uniswap.ChainEthereum:
+			errs = append(errs, fmt.Errorf("token %d: unsupported chain %q", i+1, entry.Chain))
+		case entry.Symbol == "":
+			errs = append(errs, fmt.Errorf("token %d: symbol is required", i+1))
+		case entry.Decimals == 0:
+			errs = append(errs, fmt.Errorf("token %d: decimals is required", i+1))
+		case seenTokens[address]:
+			errs = append(errs, fmt.Errorf("token %d: %s is listed twice", i+1, address.Hex()))
+		}
+		seenTokens[address] = true
+		tokens = append(tokens, uniswap.TokenMetadata{
+			Address:  address,
+			Chain:    chain,
+			Symbol:   entry.Symbol,
+			Decimals: entry.Decimals,
+			LogoURI:  entry.LogoURI,
+		})
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
+	return chains, tokens, nil
+}
+
+// handleReloadRegistry lets a bot administrator apply a changed registry file or token list right away
+func (h *BotHandlers) handleReloadRegistry(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received reload_registry command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	if !h.admins[ctx.EffectiveUser.Id] {
+		_, err := ctx.EffectiveMessage.Reply(b, "Only the bot's administrators can reload the registry.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+	if h.registry == nil {
+		_, err := ctx.EffectiveMessage.Reply(b, "No registry file or token lists are configured, set REGISTRY_FILE or TOKEN_LISTS to use them.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	chains, tokens, err := h.registry.Load(reqCtx)
+	if errors.Is(err, errStaleTokenLists) {
+		h.log(ctx).Warnw("Reloaded registry with token lists from before", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, fmt.Sprintf("Registry reloaded: %d chains, %d tokens. Some token lists kept their tokens from before: %v", chains, tokens, err), &gotgbot.SendMessageOpts{})
+		return err
+	}
+	if err != nil {
+		h.log(ctx).Warnw("Failed to reload registry", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, fmt.Sprintf("The registry was not reloaded: %v", err), &gotgbot.SendMessageOpts{})
+		return err
+	}
+	_, err = ctx.EffectiveMessage.Reply(b, fmt.Sprintf("Registry reloaded: %d chains, %d tokens.", chains, tokens), &gotgbot.SendMessageOpts{})
+	return err
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+)
+
+// unknownCommandGroup is the dispatcher group of the unknown command handler. It runs after
+// the default group, so it sees every command and has to ignore the ones that were handled.
+const unknownCommandGroup = 1
+
+// maxSuggestionDistance is the largest edit distance for which a typo gets a suggestion. Shorter
+// names allow less, so that short aliases like /ls don't match everything.
+const maxSuggestionDistance = 2
+
+// commandRouter resolves command names and aliases against the command registry
+type commandRouter struct {
+	commands []botCommand
+	byName   map[string]*botCommand
+}
+
+func newCommandRouter(commands []botCommand) *commandRouter {
+	r := &commandRouter{
+		commands: commands,
+		byName:   make(map[string]*botCommand),
+	}
+	for i := range r.commands {
+		c := &r.commands[i]
+		r.byName[c.name] = c
+		for _, alias := range c.aliases {
+			r.byName[alias] = c
+		}
+	}
+	return r
+}
+
+// Register adds a handler for every command and alias that has one, plus the handler
+// suggesting commands for typos.
+func (r *commandRouter) Register(dispatcher *ext.Dispatcher, unknown handlers.Response) {
+	for _, c := range r.commands {
+		if c.handler == nil {
+			continue
+		}
+		handler := traceCommand(c.name, c.handler)
+		dispatcher.AddHandler(handlers.NewCommand(c.name, handler))
+		for _, alias := range c.aliases {
+			dispatcher.AddHandler(handlers.NewCommand(alias, handler))
+		}
+	}
+	dispatcher.AddHandlerToGroup(handlers.NewMessage(isCommand, unknown), unknownCommandGroup)
+}
+
+// Known reports whether name is a command or an alias of one
+func (r *commandRouter) Known(name string) bool {
+	_, ok := r.byName[name]
+	return ok
+}
+
+// Resolve returns the name of the command that name is, or is an alias of
+func (r *commandRouter) Resolve(name string) (string, bool) {
+	c, ok := r.byName[name]
+	if !ok {
+		return "", false
+	}
+	return c.name, true
+}
+
+// Suggest returns the command closest to name, if any is close enough to be a likely typo
+func (r *commandRouter) Suggest(name string) (string, bool) {
+	best, bestDistance := "", maxSuggestionDistance+1
+	for _, c := range r.commands {
+		for _, candidate := range append([]string{c.name}, c.aliases...) {
+			d := editDistance(name, candidate)
+			if d <= min(maxSuggestionDistance, len(candidate)/3) && d < bestDistance {
+				best, bestDistance = c.name, d
+			}
+		}
+	}
+	return best, best != ""
+}
+
+func (h *BotHandlers) handleUnknownCommand(b *gotgbot.Bot, ctx *ext.Context) error {
+	name, mention := parseCommandName(ctx.EffectiveMessage.Text)
+	if h.router.Known(name) {
+		return nil
+	}
+
+	// In groups, other bots' commands are none of our business
+	if mention == "" && ctx.EffectiveChat.Type != gotgbot.ChatTypePrivate {
+		return nil
+	}
+	if mention != "" && !strings.EqualFold(mention, b.Username) {
+		return nil
+	}
+
+	h.log(ctx).Infow("Received unknown command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id, "command", name)
+
+	msg := fmt.Sprintf("Unknown command /%s. Send /help to see all commands.", name)
+	if suggestion, ok := h.router.Suggest(name); ok {
+		msg = fmt.Sprintf("Unknown command /%s. Did you mean /%s?", name, suggestion)
+	}
+	_, err := ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
+	return err
+}
+
+// isCommand matches text messages starting with a slash command
+func isCommand(msg *gotgbot.Message) bool {
+	return strings.HasPrefix(msg.Text, "/") && len(msg.Text) > 1
+}
+
+// parseCommandName splits "/name@bot args" into its lowercased name and the bot it was addressed to
+func parseCommandName(text string) (string, string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", ""
+	}
+	name, mention, _ := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+	return strings.ToLower(name), mention
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
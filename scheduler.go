@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"go.uber.org/zap"
+)
+
+// Job is work the Scheduler runs periodically
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// Run does the work once. A failed run is logged and the job runs again at the next interval.
+	Run func(ctx context.Context) error
+	// Stop, if set, runs once when the scheduler stops, e.g. to save state kept in memory
+	Stop func(ctx context.Context) error
+	// Resume, if set, runs before an exclusive job runs on this replica when it didn't run the
+	// job last time, e.g. to drop state kept in memory that another replica has moved on from
+	// meanwhile
+	Resume func()
+	// Exclusive jobs run on only one replica of the bot if the scheduler has a Locker, e.g. so
+	// changes aren't alerted about twice. Other jobs, like flushing counters kept in memory, run
+	// on every replica.
+	Exclusive bool
+}
+
+// Scheduler runs background jobs, such as refreshing tracked positions, each at its own interval.
+// A job runs once right away, and never overlaps with itself: a run taking longer than the interval
+// delays the next one.
+type Scheduler struct {
+	logger *zap.SugaredLogger
+	jobs   []Job
+	locker Locker
+}
+
+func NewScheduler(logger *zap.SugaredLogger) *Scheduler {
+	return &Scheduler{logger: logger}
+}
+
+// SetLocker makes exclusive jobs run on a single replica: a replica only runs one after taking
+// its lock, which it holds for two intervals so it keeps the job while running it on time, and
+// another replica takes over once it stops.
+func (s *Scheduler) SetLocker(locker Locker) {
+	s.locker = locker
+}
+
+// Add schedules job. Jobs with an interval of 0 or less are disabled.
+func (s *Scheduler) Add(job Job) {
+	if job.Interval <= 0 {
+		s.logger.Infow("Scheduled job disabled", "job", job.Name)
+		return
+	}
+	s.jobs = append(s.jobs, job)
+}
+
+// Run runs the jobs until ctx is cancelled, then stops them and returns
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runJob(ctx, job)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) runJob(ctx context.Context, job Job) {
+	s.logger.Infow("Scheduled job started", "job", job.Name, "interval", job.Interval)
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	// ranLast is whether this replica ran the job last time
+	ranLast := false
+	for {
+		held := s.holdsLock(ctx, job)
+		if held && !ranLast && job.Exclusive && s.locker != nil && job.Resume != nil {
+			job.Resume()
+		}
+		ranLast = held
+		if held {
+			// Correlate what is logged and requested during the run
+			runCtx := uniswap.WithCorrelationID(ctx, uniswap.NewCorrelationID())
+			start := time.Now()
+			if err := runRecovering(runCtx, job); err != nil && ctx.Err() == nil {
+				requestLogger(runCtx, s.logger).Errorw("Scheduled job failed", "job", job.Name, "error", err)
+				reportError(err, map[string]string{"job": job.Name})
+			} else {
+				s.logger.Debugw("Scheduled job ran", "job", job.Name, "duration", time.Since(start))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			// ctx is already cancelled, give stopping a context of its own
+			stopCtx, cancel := newRequestContext(nil)
+			if job.Stop != nil {
+				if err := job.Stop(stopCtx); err != nil {
+					s.logger.Warnw("Failed to stop scheduled job", "job", job.Name, "error", err)
+				}
+			}
+			// Let another replica, or this one once restarted, take over without waiting for the lock to expire
+			if job.Exclusive && s.locker != nil {
+				if err := s.locker.Unlock(stopCtx, "job:"+job.Name); err != nil {
+					s.logger.Warnw("Failed to release scheduled job", "job", job.Name, "error", err)
+				}
+			}
+			cancel()
+			s.logger.Infow("Scheduled job stopped", "job", job.Name)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runRecovering runs job once, turning a panic into an error, so one bad run is reported like a
+// failed one rather than taking the bot down
+func runRecovering(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, panicStack(r))
+		}
+	}()
+	return traceJob(ctx, job.Name, job.Run)
+}
+
+// holdsLock reports whether this replica may run job now. If the lock can't be taken the run is
+// skipped, since running it on several replicas at once is what the lock prevents.
+func (s *Scheduler) holdsLock(ctx context.Context, job Job) bool {
+	if !job.Exclusive || s.locker == nil {
+		return true
+	}
+	ok, err := s.locker.TryLock(ctx, "job:"+job.Name, 2*job.Interval)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Errorw("Skipping scheduled job, failed to take its lock", "job", job.Name, "error", err)
+		}
+		return false
+	}
+	if !ok {
+		s.logger.Debugw("Skipping scheduled job, another replica runs it", "job", job.Name)
+	}
+	return ok
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// vaultTimeout bounds reading the secrets from Vault at startup
+const vaultTimeout = 10 * time.Second
+
+// secretSettings returns a setter for each setting holding a credential, by environment variable
+// name. These can also be read from a file named by <name>_FILE, e.g. a Docker secret, or from Vault.
+func (c *Config) secretSettings() map[string]func(value string) {
+	str := func(dst *string) func(string) {
+		return func(value string) { *dst = value }
+	}
+	list := func(dst *[]string) func(string) {
+		return func(value string) {
+			*dst = nil
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*dst = append(*dst, item)
+				}
+			}
+		}
+	}
+	return map[string]func(string){
+		"TELEGRAM_TOKEN":        str(&c.TelegramToken),
+		"EXTRA_TELEGRAM_TOKENS": list(&c.ExtraTelegramTokens),
+		"GRAPH_API_KEY":         str(&c.GraphAPIKey),
+		"DB_ENCRYPTION_KEY":     str(&c.DBEncryptionKey),
+		"INVITE_CODE":           str(&c.InviteCode),
+		"WEBHOOK_SECRET":        str(&c.WebhookSecret),
+		"METRICS_TOKEN":         str(&c.MetricsToken),
+		"API_KEYS":              list(&c.APIKeys),
+		"SENTRY_DSN":            str(&c.SentryDSN),
+		"REDIS_URL":             str(&c.RedisURL),
+		"ETH_RPC_URL":           str(&c.EthRPCURL),
+		"ETHERSCAN_API_KEY":     str(&c.EtherscanAPIKey),
+	}
+}
+
+// loadSecretFiles reads the secrets whose <name>_FILE environment variable is set from that file.
+// Setting both <name> and <name>_FILE is an error, as it isn't clear which is meant.
+func (c *Config) loadSecretFiles() error {
+	var errs []error
+	for name, set := range c.secretSettings() {
+		path := os.Getenv(name + "_FILE")
+		if path == "" {
+			continue
+		}
+		if os.Getenv(name) != "" {
+			errs = append(errs, fmt.Errorf("set only one of %s and %s_FILE", name, name))
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read %s_FILE: %w", name, err))
+			continue
+		}
+		// Files written by editors and echo end with a newline that isn't part of the secret
+		set(strings.TrimSpace(string(data)))
+	}
+	return errors.Join(errs...)
+}
+
+// loadVault reads secrets from the Vault KV version 2 secret at path, given as <mount>/<path>,
+// e.g. secret/uniswapfetcher. The secret's keys are the lower case names of the settings, e.g.
+// telegram_token. The Vault server and token are taken from VAULT_ADDR, VAULT_TOKEN and the
+// other environment variables the Vault CLI reads.
+func (c *Config) loadVault(path string) error {
+	mount, secretPath, ok := strings.Cut(strings.Trim(path, "/"), "/")
+	if !ok || mount == "" || secretPath == "" {
+		return fmt.Errorf("VAULT_SECRET_PATH must be <mount>/<path>, e.g. secret/uniswapfetcher, got %q", path)
+	}
+
+	vaultCfg := vault.DefaultConfig()
+	if vaultCfg.Error != nil {
+		return fmt.Errorf("invalid Vault configuration: %w", vaultCfg.Error)
+	}
+	client, err := vault.NewClient(vaultCfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Vault client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), vaultTimeout)
+	defer cancel()
+	secret, err := client.KVv2(mount).Get(ctx, secretPath)
+	if err != nil {
+		return fmt.Errorf("failed to read secrets from Vault: %w", err)
+	}
+
+	settings := c.secretSettings()
+	var errs []error
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		set, ok := settings[strings.ToUpper(key)]
+		if !ok {
+			errs = append(errs, fmt.Errorf("Vault secret %s: %s is not a secret setting", path, key))
+			continue
+		}
+		value, ok := secret.Data[key].(string)
+		if !ok {
+			errs = append(errs, fmt.Errorf("Vault secret %s: %s must be a string", path, key))
+			continue
+		}
+		set(value)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"go.uber.org/zap"
+)
+
+// selfTestTimeout bounds the startup self-test, so a dependency that hangs fails it rather than the start
+const selfTestTimeout = 30 * time.Second
+
+// selfTestCheck checks at startup that the bot can use one of its external dependencies with the
+// credentials it was given
+type selfTestCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+	// Hint tells the operator how to fix err, empty if there's nothing more to say than err
+	Hint func(err error) string
+}
+
+// runSelfTest runs checks and reports every failure at once with a hint on how to fix it, so a
+// wrong token or API key stops the bot at startup instead of failing users' commands later
+func runSelfTest(logger *zap.SugaredLogger, checks ...selfTestCheck) error {
+	ctx, cancel := context.WithTimeout(context.Background(), selfTestTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, check := range checks {
+		start := time.Now()
+		err := check.Check(ctx)
+		if err == nil {
+			logger.Infow("Self-test passed", "check", check.Name, "duration", time.Since(start))
+			continue
+		}
+		if hint := check.Hint(err); hint != "" {
+			err = fmt.Errorf("%w\n  %s", err, hint)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", check.Name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// telegramSelfTest calls getMe with each token, in the order of TELEGRAM_TOKEN and then
+// EXTRA_TELEGRAM_TOKENS. It calls Telegram directly, so it works in dry runs as well.
+func telegramSelfTest(tokens []string) selfTestCheck {
+	return selfTestCheck{
+		Name: "telegram",
+		Check: func(ctx context.Context) error {
+			client := &gotgbot.BaseBotClient{}
+			for i, token := range tokens {
+				_, err := client.RequestWithContext(ctx, token, "getMe", nil, nil, nil)
+				if err == nil {
+					continue
+				}
+				var tgErr *gotgbot.TelegramError
+				if !errors.As(err, &tgErr) {
+					// Network errors quote the request URL, which holds the token
+					err = errors.New(strings.ReplaceAll(err.Error(), token, "<token>"))
+				}
+				return fmt.Errorf("%s: getMe failed: %w", tokenSetting(i), err)
+			}
+			return nil
+		},
+		Hint: func(err error) string {
+			var tgErr *gotgbot.TelegramError
+			if errors.As(err, &tgErr) && (tgErr.Code == http.StatusUnauthorized || tgErr.Code == http.StatusNotFound) {
+				return "The token is not valid: copy it again from @BotFather, or create a new one with /token there."
+			}
+			return "Telegram can't be reached: check the bot can connect to api.telegram.org over HTTPS."
+		},
+	}
+}
+
+// tokenSetting names the setting the i-th of the bot tokens comes from
+func tokenSetting(i int) string {
+	if i == 0 {
+		return "TELEGRAM_TOKEN"
+	}
+	return fmt.Sprintf("EXTRA_TELEGRAM_TOKENS entry %d", i)
+}
+
+// subgraphSelfTest queries the Uniswap subgraphs with GRAPH_API_KEY
+func subgraphSelfTest(client uniswap.HealthChecker) selfTestCheck {
+	return selfTestCheck{
+		Name:  "subgraphs",
+		Check: client.CheckHealth,
+		Hint: func(err error) string {
+			var statusErr *uniswap.StatusError
+			if errors.As(err, &statusErr) {
+				switch statusErr.StatusCode {
+				case http.StatusUnauthorized, http.StatusForbidden:
+					return "GRAPH_API_KEY was rejected: create a key at https://thegraph.com/studio/apikeys/ and check it isn't restricted to other subgraphs or domains."
+				case http.StatusPaymentRequired, http.StatusTooManyRequests:
+					return "The Graph API key is out of quota: check its billing at https://thegraph.com/studio/billing/."
+				}
+			}
+			// The gateway also answers 200 OK with an error for some keys it doesn't accept
+			if strings.Contains(strings.ToLower(err.Error()), "api key") || strings.Contains(err.Error(), "auth error") {
+				return "GRAPH_API_KEY was rejected: create a key at https://thegraph.com/studio/apikeys/."
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				return "The Graph didn't answer in time: check the bot can connect to gateway.thegraph.com over HTTPS, or skip the self-test with SKIP_SELF_TEST=true during an outage."
+			}
+			return "Check the subgraph IDs in REGISTRY_FILE, if any, and that the bot can connect to gateway.thegraph.com over HTTPS."
+		},
+	}
+}
+
+// rpcSelfTest asks the Ethereum node at ETH_RPC_URL for its chain ID, which must be mainnet's,
+// the chain positions are read from
+func rpcSelfTest(client *uniswap.RPCClient) selfTestCheck {
+	return selfTestCheck{
+		Name: "ethereum node",
+		Check: func(ctx context.Context) error {
+			chainID, err := client.ChainID(ctx)
+			if err != nil {
+				return fmt.Errorf("eth_chainId failed: %w", err)
+			}
+			if chainID != uniswap.ChainIDEthereum {
+				return fmt.Errorf("node is on chain %d, not Ethereum mainnet (%d)", chainID, uniswap.ChainIDEthereum)
+			}
+			return nil
+		},
+		Hint: func(err error) string {
+			var statusErr *uniswap.StatusError
+			switch {
+			case errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden):
+				return "ETH_RPC_URL was rejected: check the API key in it with the node provider."
+			case errors.Is(err, context.DeadlineExceeded):
+				return "The Ethereum node didn't answer in time: check the bot can connect to ETH_RPC_URL, or raise RPC_TIMEOUT."
+			case strings.Contains(err.Error(), "not Ethereum mainnet"):
+				return "Point ETH_RPC_URL at an Ethereum mainnet endpoint of the node provider."
+			}
+			return "Check ETH_RPC_URL is the JSON-RPC endpoint of an Ethereum node and the bot can connect to it."
+		},
+	}
+}
+
+// selfTestChecks returns the checks the configuration calls for. Only the subgraph backend uses
+// The Graph for positions, so a bot serving fixtures starts without it. The Ethereum node is only
+// checked if rpc isn't nil.
+func selfTestChecks(cfg Config, subgraph uniswap.HealthChecker, rpc *uniswap.RPCClient) []selfTestCheck {
+	tokens := append([]string{cfg.TelegramToken}, cfg.ExtraTelegramTokens...)
+	checks := []selfTestCheck{telegramSelfTest(tokens)}
+	for _, name := range cfg.Backends {
+		if name == backendSubgraph {
+			checks = append(checks, subgraphSelfTest(subgraph))
+		}
+	}
+	if rpc != nil {
+		checks = append(checks, rpcSelfTest(rpc))
+	}
+	return checks
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	// globalSendInterval keeps the bot under Telegram's limit of about 30 messages a second
+	globalSendInterval = time.Second / 30
+	// privateChatSendInterval keeps the bot under the limit of about a message a second in a chat
+	privateChatSendInterval = time.Second
+	// groupChatSendInterval keeps the bot under the limit of 20 messages a minute in a group
+	groupChatSendInterval = 3 * time.Second
+	// maxSendRetryWait is the longest retry_after a send is retried after rather than failed
+	maxSendRetryWait = 10 * time.Second
+	// maxSendRetries bounds how often a send Telegram throttles is retried
+	maxSendRetries = 3
+)
+
+// sendLimiter spaces out the Bot API calls sending or editing messages, so the handlers,
+// notifications and anything else sending through the bot together stay within Telegram's
+// rate limits rather than getting the bot throttled. A call waits for its turn, both overall
+// and in its chat. When Telegram still answers 429, the chat is paused for the retry_after it
+// asks for and the call retried if that's short, or failed with Telegram's error otherwise.
+type sendLimiter struct {
+	gotgbot.BotClient
+	logger *zap.SugaredLogger
+
+	mu         sync.Mutex
+	nextGlobal time.Time
+	nextInChat map[string]time.Time
+}
+
+func newSendLimiter(client gotgbot.BotClient, logger *zap.SugaredLogger) *sendLimiter {
+	return &sendLimiter{
+		BotClient:  client,
+		logger:     logger,
+		nextInChat: make(map[string]time.Time),
+	}
+}
+
+func (l *sendLimiter) RequestWithContext(ctx context.Context, token string, method string, params map[string]string, data map[string]gotgbot.NamedReader, opts *gotgbot.RequestOpts) (json.RawMessage, error) {
+	chatID := params["chat_id"]
+	if !isSendMethod(method) || chatID == "" {
+		return l.BotClient.RequestWithContext(ctx, token, method, params, data, opts)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := l.wait(ctx, chatID); err != nil {
+			return nil, err
+		}
+		result, err := l.BotClient.RequestWithContext(ctx, token, method, params, data, opts)
+
+		var tgErr *gotgbot.TelegramError
+		if !errors.As(err, &tgErr) || tgErr.Code != http.StatusTooManyRequests || tgErr.ResponseParams == nil {
+			return result, err
+		}
+		retryAfter := time.Duration(tgErr.ResponseParams.RetryAfter) * time.Second
+		l.pause(chatID, retryAfter)
+		l.logger.Warnw("Telegram throttled sending", "method", method, "chat_id", chatID, "retry_after", retryAfter)
+		// Files were read by the failed attempt, so a call uploading them can't be made again
+		if attempt+1 >= maxSendRetries || retryAfter > maxSendRetryWait || len(data) > 0 {
+			return result, err
+		}
+	}
+}
+
+// wait blocks until the next send to chatID is due and reserves that slot
+func (l *sendLimiter) wait(ctx context.Context, chatID string) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := now
+	if l.nextGlobal.After(at) {
+		at = l.nextGlobal
+	}
+	if next := l.nextInChat[chatID]; next.After(at) {
+		at = next
+	}
+	l.nextGlobal = at.Add(globalSendInterval)
+	l.nextInChat[chatID] = at.Add(chatSendInterval(chatID))
+	l.prune(now)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pause holds back sends to chatID for d, as Telegram asked
+func (l *sendLimiter) pause(chatID string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.nextInChat[chatID]) {
+		l.nextInChat[chatID] = until
+	}
+}
+
+// prune forgets the chats that can be sent to right away, so the map only holds recent chats
+func (l *sendLimiter) prune(now time.Time) {
+	if len(l.nextInChat) < 1000 {
+		return
+	}
+	for chatID, next := range l.nextInChat {
+		if !next.After(now) {
+			delete(l.nextInChat, chatID)
+		}
+	}
+}
+
+// isSendMethod reports whether method posts or changes a message and so counts against the limits
+func isSendMethod(method string) bool {
+	return strings.HasPrefix(method, "send") || strings.HasPrefix(method, "edit") ||
+		strings.HasPrefix(method, "copyMessage") || strings.HasPrefix(method, "forwardMessage")
+}
+
+// chatSendInterval returns the time to leave between two sends to chatID. Group, supergroup
+// and channel IDs are negative, and @usernames only address public groups and channels.
+func chatSendInterval(chatID string) time.Duration {
+	if strings.HasPrefix(chatID, "-") || strings.HasPrefix(chatID, "@") {
+		return groupChatSendInterval
+	}
+	return privateChatSendInterval
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// settingsCallbackPrefix is the callback data prefix of the /settings toggles
+const settingsCallbackPrefix = "settings:"
+
+// Settings toggled from the /settings keyboard
+const (
+	settingsToggleDisplay = "display"
+	settingsToggleAlerts  = "alerts"
+	settingsToggleQuick   = "quick_actions"
+)
+
+func (h *BotHandlers) handleSettings(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received settings command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	settings, err := h.db.GetChatSettings(reqCtx, ctx.EffectiveChat.Id)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get chat settings", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to retrieve settings. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	_, err = ctx.EffectiveMessage.Reply(b, formatSettings(settings), &gotgbot.SendMessageOpts{
+		ReplyMarkup: settingsKeyboard(settings),
+	})
+	return err
+}
+
+func (h *BotHandlers) handleSettingsCallback(b *gotgbot.Bot, ctx *ext.Context) error {
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	cb := ctx.CallbackQuery
+	toggle := strings.TrimPrefix(cb.Data, settingsCallbackPrefix)
+	h.log(ctx).Infow("Received settings callback", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id, "toggle", toggle)
+
+	// Only administrators may change a group chat's settings
+	if allowed, err := h.requireChatAdmin(b, ctx); !allowed {
+		if err != nil {
+			return err
+		}
+		_, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{})
+		return err
+	}
+
+	settings, err := h.db.GetChatSettings(reqCtx, ctx.EffectiveChat.Id)
+	if err == nil {
+		switch toggle {
+		case settingsToggleDisplay:
+			if settings.DisplayMode == DisplayCompact {
+				settings.DisplayMode = DisplayDetailed
+			} else {
+				settings.DisplayMode = DisplayCompact
+			}
+		case settingsToggleAlerts:
+			settings.AlertsEnabled = !settings.AlertsEnabled
+		case settingsToggleQuick:
+			settings.QuickActions = !settings.QuickActions
+		}
+		err = h.db.SaveChatSettings(reqCtx, ctx.EffectiveChat.Id, settings)
+	}
+	if err != nil {
+		h.log(ctx).Errorw("Failed to update chat settings", "error", err)
+		_, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      "Failed to save settings. Please try again later.",
+			ShowAlert: true,
+		})
+		return err
+	}
+
+	if _, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{}); err != nil {
+		return err
+	}
+
+	_, _, err = cb.Message.EditText(b, formatSettings(settings), &gotgbot.EditMessageTextOpts{
+		ReplyMarkup: settingsKeyboard(settings),
+	})
+	if err != nil && !isMessageNotModified(err) {
+		return err
+	}
+
+	if toggle == settingsToggleQuick {
+		return h.sendQuickActionKeyboard(b, ctx.EffectiveChat.Id, settings.QuickActions)
+	}
+	return nil
+}
+
+func formatSettings(settings ChatSettings) string {
+	deployments := "Ethereum V3 and V4"
+	switch {
+	case settings.IncludeV3 && !settings.IncludeV4:
+		deployments = "Ethereum V3"
+	case !settings.IncludeV3 && settings.IncludeV4:
+		deployments = "Ethereum V4"
+	}
+
+	notifications := "off"
+	if settings.AlertsEnabled {
+		notifications = "on"
+	}
+
+	swapAlerts := "off"
+	if settings.SwapAlertUSD > 0 {
+		swapAlerts = "$" + strconv.FormatFloat(settings.SwapAlertUSD, 'f', -1, 64) + " and above"
+	}
+
+	quickActions := "off"
+	if settings.QuickActions {
+		quickActions = "on"
+	}
+
+	return fmt.Sprintf(`Settings
+Deployments: %s (change with /setup)
+Notifications: %s
+Swap alerts: %s (change with /swap_alerts)
+Display: %s
+Quick actions: %s`, deployments, notifications, swapAlerts, settings.DisplayMode, quickActions)
+}
+
+func settingsKeyboard(settings ChatSettings) gotgbot.InlineKeyboardMarkup {
+	display := "Compact display"
+	if settings.DisplayMode == DisplayCompact {
+		display = "Detailed display"
+	}
+
+	alerts := "Turn notifications on"
+	if settings.AlertsEnabled {
+		alerts = "Turn notifications off"
+	}
+
+	quickActions := "Show quick actions"
+	if settings.QuickActions {
+		quickActions = "Hide quick actions"
+	}
+
+	return gotgbot.InlineKeyboardMarkup{
+		InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+			{{Text: display, CallbackData: settingsCallbackPrefix + settingsToggleDisplay}},
+			{{Text: alerts, CallbackData: settingsCallbackPrefix + settingsToggleAlerts}},
+			{{Text: quickActions, CallbackData: settingsCallbackPrefix + settingsToggleQuick}},
+		},
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"go.uber.org/zap"
+)
+
+// sharePathPrefix is the URL path share pages are served under, relative to PUBLIC_URL
+const sharePathPrefix = "/share/"
+
+// sharePageCacheTTL is how long a rendered share page is served before positions are fetched again
+const sharePageCacheTTL = time.Minute
+
+func (h *BotHandlers) handleShare(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received share command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	if h.publicURL == "" {
+		_, err := ctx.EffectiveMessage.Reply(b, "Sharing is not enabled on this bot.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	// Only administrators may publish a group chat's wallets
+	if allowed, err := h.requireChatAdmin(b, ctx); !allowed {
+		return err
+	}
+
+	wallet, ok, err := h.sharedWallet(reqCtx, b, ctx, "share")
+	if !ok {
+		return err
+	}
+
+	token, err := h.db.GetShareLinkForWallet(reqCtx, ctx.EffectiveChat.Id, wallet)
+	if err == nil && token == "" {
+		token, err = newShareToken()
+		if err == nil {
+			err = h.db.CreateShareLink(reqCtx, ShareLink{
+				Token:      token,
+				ChatWallet: ChatWallet{ChatID: ctx.EffectiveChat.Id, WalletAddress: wallet},
+			})
+		}
+	}
+	if err != nil {
+		h.log(ctx).Errorw("Failed to create share link", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to create share link. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	msg := fmt.Sprintf("Anyone with this link can see the positions of %s:\n%s\n\nUse /unshare %s to revoke it.", wallet, h.shareURL(token), wallet)
+	_, err = ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
+	return err
+}
+
+func (h *BotHandlers) handleUnshare(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received unshare command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	// Only administrators may revoke a group chat's links
+	if allowed, err := h.requireChatAdmin(b, ctx); !allowed {
+		return err
+	}
+
+	// Without an address all of the chat's links are revoked
+	var wallet string
+	if args := ctx.Args(); len(args) >= 2 {
+		address, err := parseWalletAddress(args[1])
+		if err != nil {
+			_, err := ctx.EffectiveMessage.Reply(b, err.Error(), &gotgbot.SendMessageOpts{})
+			return err
+		}
+		wallet = address.Hex()
+	}
+
+	revoked, err := h.db.DeleteShareLinks(reqCtx, ctx.EffectiveChat.Id, wallet)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to revoke share links", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to revoke share links. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	msg := fmt.Sprintf("Revoked %d share link(s).", revoked)
+	if revoked == 0 {
+		msg = "There are no share links to revoke."
+	}
+	_, err = ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
+	return err
+}
+
+// sharedWallet picks the tracked wallet a share command refers to: the given address, or the
+// chat's only wallet if none was given. If it returns false, the user was already told why.
+func (h *BotHandlers) sharedWallet(reqCtx context.Context, b *gotgbot.Bot, ctx *ext.Context, command string) (string, bool, error) {
+	wallets, err := h.db.GetChatWallets(reqCtx, ctx.EffectiveChat.Id)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get wallets", "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to retrieve wallets. Please try again later.", &gotgbot.SendMessageOpts{})
+		return "", false, err
+	}
+
+	args := ctx.Args()
+	if len(args) < 2 {
+		if len(wallets) == 1 {
+			return wallets[0].WalletAddress, true, nil
+		}
+		msg := fmt.Sprintf("Please provide one of your tracked wallets: /%s <address>", command)
+		if len(wallets) == 0 {
+			msg = "You don't have any wallets added yet. Use /add_wallet <address> to add one."
+		}
+		_, err := ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
+		return "", false, err
+	}
+
+	address, err := parseWalletAddress(args[1])
+	if err != nil {
+		_, err := ctx.EffectiveMessage.Reply(b, err.Error(), &gotgbot.SendMessageOpts{})
+		return "", false, err
+	}
+	for _, wallet := range wallets {
+		if strings.EqualFold(wallet.WalletAddress, address.Hex()) {
+			return wallet.WalletAddress, true, nil
+		}
+	}
+
+	_, err = ctx.EffectiveMessage.Reply(b, "Only tracked wallets can be shared. Use /add_wallet to track it first.", &gotgbot.SendMessageOpts{})
+	return "", false, err
+}
+
+func (h *BotHandlers) shareURL(token string) string {
+	return strings.TrimSuffix(h.publicURL, "/") + sharePathPrefix + token
+}
+
+// newShareToken returns an unguessable URL-safe token
+func newShareToken() (string, error) {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// ShareServer serves the read-only pages behind share links
+type ShareServer struct {
+	db            Store
+	uniswapClient uniswap.Client
+	logger        *zap.SugaredLogger
+
+	mu    sync.Mutex
+	pages map[string]sharePage
+}
+
+type sharePage struct {
+	at   time.Time
+	body []byte
+}
+
+func NewShareServer(db Store, uniswapClient uniswap.Client, logger *zap.SugaredLogger) *ShareServer {
+	return &ShareServer{
+		db:            db,
+		uniswapClient: uniswapClient,
+		logger:        logger,
+		pages:         make(map[string]sharePage),
+	}
+}
+
+func (s *ShareServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := strings.TrimPrefix(r.URL.Path, sharePathPrefix)
+	link, ok, err := s.db.GetShareLink(r.Context(), token)
+	if err != nil {
+		s.logger.Errorw("Failed to get share link", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := s.render(r.Context(), link)
+	if err != nil {
+		s.logger.Errorw("Failed to render share page", "wallet", link.WalletAddress, "error", err)
+		http.Error(w, "Failed to fetch positions. Please try again later.", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	w.Write(body)
+}
+
+// render returns the page for a link, fetching positions at most once per sharePageCacheTTL
+func (s *ShareServer) render(ctx context.Context, link ShareLink) ([]byte, error) {
+	s.mu.Lock()
+	now := time.Now()
+	for token, page := range s.pages {
+		if now.Sub(page.at) >= sharePageCacheTTL {
+			delete(s.pages, token)
+		}
+	}
+	page, ok := s.pages[link.Token]
+	s.mu.Unlock()
+	if ok {
+		return page.body, nil
+	}
+
+	settings, err := s.db.GetChatSettings(ctx, link.ChatID)
+	if err != nil {
+		s.logger.Errorw("Failed to get chat settings", "error", err)
+		settings = DefaultChatSettings
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	positions, err := s.uniswapClient.GetPositions(fetchCtx, uniswap.PositionRequest{
+		WalletAddress: common.HexToAddress(link.WalletAddress),
+		IncludeV3:     settings.IncludeV3,
+		IncludeV4:     settings.IncludeV4,
+		IncludeClosed: true,
+	})
+	if err != nil && !errors.Is(err, uniswap.ErrWalletHasNoPositions) && !errors.Is(err, uniswap.ErrPartialPositions) {
+		return nil, err
+	}
+
+	prices := priceTokens(fetchCtx, s.uniswapClient, positions, s.logger)
+	data := sharePageData{Wallet: link.WalletAddress, UpdatedAt: now.UTC().Format("2006-01-02 15:04 MST")}
+	for _, pos := range positions {
+		data.Positions = append(data.Positions, uniswap.FormatPositionSummaryUSD(pos, prices))
+	}
+
+	var buf bytes.Buffer
+	if err := sharePageTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.pages[link.Token] = sharePage{at: now, body: buf.Bytes()}
+	s.mu.Unlock()
+	return buf.Bytes(), nil
+}
+
+type sharePageData struct {
+	Wallet    string
+	UpdatedAt string
+	Positions []uniswap.PositionSummary
+}
+
+var sharePageTemplate = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Uniswap positions of {{.Wallet}}</title>
+<style>
+body { font-family: sans-serif; max-width: 48em; margin: 2em auto; padding: 0 1em; color: #222; }
+.position { border: 1px solid #ddd; border-radius: 8px; padding: 0.5em 1em; margin: 1em 0; }
+.muted { color: #777; }
+td { padding: 0.1em 1em 0.1em 0; vertical-align: top; }
+.logo { width: 1.1em; height: 1.1em; border-radius: 50%; vertical-align: -0.2em; margin-right: 2px; }
+</style>
+</head>
+<body>
+<h1>Uniswap positions</h1>
+<p class="muted">{{.Wallet}}<br>Updated {{.UpdatedAt}}</p>
+{{range .Positions}}
+<div class="position">
+<h3>{{with .Token0LogoURI}}<img class="logo" src="{{.}}" alt="">{{end}}{{with .Token1LogoURI}}<img class="logo" src="{{.}}" alt="">{{end}}{{.TokenPair}} {{.Version}} <span class="muted">#{{.ID}}</span></h3>
+<table>
+<tr><td>Created</td><td>{{.CreatedAt}}</td></tr>
+<tr><td>Amounts</td><td>{{.Amounts}}</td></tr>
+<tr><td>Price Range</td><td>{{.PriceRange}}</td></tr>
+<tr><td>In Range</td><td>{{.InRange}}</td></tr>
+<tr><td>Unclaimed Fees</td><td>{{.UnclaimedFees}}</td></tr>
+{{if .ValueUSD}}<tr><td>Value</td><td>{{.ValueUSD}}</td></tr>
+<tr><td>Fees</td><td>{{.FeesUSD}}</td></tr>
+<tr><td>PnL</td><td>{{.PnLUSD}}</td></tr>
+<tr><td>Token Prices</td><td>{{.TokenPrices}}</td></tr>{{end}}
+</table>
+</div>
+{{else}}
+<p>No Uniswap positions found.</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// sourceAlertInterval is how often alerts about failing subgraphs are queued for the administrators
+	sourceAlertInterval = 30 * time.Second
+	// sourceErrorWindow is how many of a subgraph's latest requests its error rate is taken over
+	sourceErrorWindow = 20
+	// sourceErrorRateThreshold is the error rate over sourceErrorWindow requests alerted about
+	sourceErrorRateThreshold = 0.5
+	// sourceErrorSamples is how many of a subgraph's latest errors an alert quotes
+	sourceErrorSamples = 3
+	// maxErrorSampleLength is where a quoted error is cut off
+	maxErrorSampleLength = 200
+)
+
+// alertQueue queues messages for delivery, it is implemented by Notifier
+type alertQueue interface {
+	Enqueue(ctx context.Context, chatID int64, text string) error
+}
+
+// sourceHealth is what the alerter tracks about the latest requests to one subgraph
+type sourceHealth struct {
+	// consecutive is how many requests in a row failed
+	consecutive int
+	// failed holds whether each of the latest requests failed, oldest first
+	failed  []bool
+	samples []string
+	// alerting is set while the administrators were told about failures and not yet about the recovery
+	alerting bool
+}
+
+// errorRate returns the share of the latest requests that failed, and whether there were enough to tell
+func (h *sourceHealth) errorRate() (float64, bool) {
+	if len(h.failed) < sourceErrorWindow {
+		return 0, false
+	}
+	failures := 0
+	for _, failed := range h.failed {
+		if failed {
+			failures++
+		}
+	}
+	return float64(failures) / float64(len(h.failed)), true
+}
+
+// dataSourceAlerter tells the bot's administrators on Telegram when a subgraph of The Graph keeps
+// failing: once repeatedFailureThreshold requests in a row failed, or sourceErrorRateThreshold of
+// the latest requests did. The alert names the subgraph and quotes its latest errors. Once the
+// subgraph recovers, the administrators are told about that too, and alerted again only after that.
+type dataSourceAlerter struct {
+	admins []int64
+	// apiKey is redacted from the errors quoted, since the URLs of requests to The Graph hold it
+	apiKey string
+	logger *zap.SugaredLogger
+
+	mu      sync.Mutex
+	sources map[string]*sourceHealth
+	pending []string
+}
+
+func newDataSourceAlerter(admins []int64, apiKey string, logger *zap.SugaredLogger) *dataSourceAlerter {
+	return &dataSourceAlerter{
+		admins:  admins,
+		apiKey:  apiKey,
+		logger:  logger,
+		sources: make(map[string]*sourceHealth),
+	}
+}
+
+// ObserveResponse records the outcome of a request, it is a uniswap.ResponseObserver. Alerts are
+// queued by the job rather than here, so requests don't wait for the database.
+func (a *dataSourceAlerter) ObserveResponse(subgraph string, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	h, ok := a.sources[subgraph]
+	if !ok {
+		h = &sourceHealth{}
+		a.sources[subgraph] = h
+	}
+	h.failed = append(h.failed, err != nil)
+	if len(h.failed) > sourceErrorWindow {
+		h.failed = h.failed[1:]
+	}
+	if err == nil {
+		h.consecutive = 0
+	} else {
+		h.consecutive++
+		h.samples = append(h.samples, fmt.Sprintf("%s %s", time.Now().UTC().Format(time.TimeOnly), a.errorSample(err)))
+		if len(h.samples) > sourceErrorSamples {
+			h.samples = h.samples[1:]
+		}
+	}
+
+	rate, enough := h.errorRate()
+	switch {
+	case !h.alerting && h.consecutive >= repeatedFailureThreshold:
+		h.alerting = true
+		a.pending = append(a.pending, a.failureAlert(subgraph, h, fmt.Sprintf("%d requests in a row failed", h.consecutive)))
+	case !h.alerting && enough && rate >= sourceErrorRateThreshold:
+		h.alerting = true
+		a.pending = append(a.pending, a.failureAlert(subgraph, h, fmt.Sprintf("%.0f%% of the last %d requests failed", rate*100, len(h.failed))))
+	case h.alerting && err == nil && (!enough || rate < sourceErrorRateThreshold/2):
+		// Recovered once failures became rare, so a flapping subgraph doesn't alert over and over
+		h.alerting = false
+		a.pending = append(a.pending, fmt.Sprintf("The %s subgraph is answering again.", subgraph))
+	}
+}
+
+// errorSample formats err for an alert, without the API key and cut off if long
+func (a *dataSourceAlerter) errorSample(err error) string {
+	sample := err.Error()
+	if a.apiKey != "" {
+		sample = strings.ReplaceAll(sample, a.apiKey, "<api key>")
+	}
+	if len(sample) > maxErrorSampleLength {
+		sample = sample[:maxErrorSampleLength] + "…"
+	}
+	return sample
+}
+
+func (a *dataSourceAlerter) failureAlert(subgraph string, h *sourceHealth, reason string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "The %s subgraph of The Graph is failing: %s.\n\nLatest errors (UTC):", subgraph, reason)
+	for _, sample := range h.samples {
+		sb.WriteString("\n- " + sample)
+	}
+	return sb.String()
+}
+
+// Job returns the scheduler job queueing alerts for the administrators through queue. Every
+// replica runs it, since each watches its own requests.
+func (a *dataSourceAlerter) Job(queue alertQueue) Job {
+	return Job{
+		Name:     "data source alerts",
+		Interval: sourceAlertInterval,
+		Run: func(ctx context.Context) error {
+			return a.queueAlerts(ctx, queue)
+		},
+	}
+}
+
+func (a *dataSourceAlerter) queueAlerts(ctx context.Context, queue alertQueue) error {
+	a.mu.Lock()
+	alerts := a.pending
+	a.pending = nil
+	a.mu.Unlock()
+
+	for i, alert := range alerts {
+		requestLogger(ctx, a.logger).Warnw("Alerting administrators", "alert", alert)
+		for _, admin := range a.admins {
+			if err := queue.Enqueue(ctx, admin, alert); err != nil {
+				// Try again at the next run, which may repeat the alert to administrators it was queued for
+				a.mu.Lock()
+				a.pending = append(alerts[i:], a.pending...)
+				a.mu.Unlock()
+				return fmt.Errorf("failed to queue alert: %w", err)
+			}
+		}
+	}
+	return nil
+}
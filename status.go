@@ -0,0 +1,576 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+)
+
+// statusCallbackPrefix prefixes the callback data of the buttons under /status results
+const statusCallbackPrefix = "status:"
+
+// walletStatusCallbackPrefix prefixes the Retry button of a failed ad-hoc wallet lookup
+const walletStatusCallbackPrefix = "wallet_status:"
+
+// positionCacheTTL is how old cached positions may be to be shown while /status refreshes them
+const positionCacheTTL = 15 * time.Minute
+
+// statusOutcome tells callers of the status builders how the result should be presented
+type statusOutcome int
+
+const (
+	// statusOK means the result contains position data
+	statusOK statusOutcome = iota
+	// statusNothing means there is nothing to show, e.g. no wallets or invalid input
+	statusNothing
+	// statusFailed means fetching failed and the user may want to retry
+	statusFailed
+)
+
+// statusView selects which positions a /status result shows
+type statusView string
+
+const (
+	// statusViewDefault follows the chat's settings
+	statusViewDefault statusView = ""
+	statusViewV3      statusView = "v3"
+	statusViewV4      statusView = "v4"
+	statusViewAll     statusView = "all"
+)
+
+func (h *BotHandlers) handleStatus(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received status command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	// An explicit address or ENS name checks that wallet once without tracking it. The quick-action
+	// button shares this handler, its label is not an argument.
+	var target string
+	if args := ctx.Args(); len(args) >= 2 && isCommand(ctx.EffectiveMessage) {
+		target = args[1]
+	}
+
+	// Serve the cached result if the user refreshed very recently, to protect the Graph API quota.
+	// Only the chat's own status is cached, not that of wallets looked up once.
+	if cached, age, ok := h.statusThrottle.Recent(ctx.EffectiveUser.Id, ctx.EffectiveChat.Id); ok && target == "" {
+		h.log(ctx).Debugw("Serving throttled status from cache", "user_id", ctx.EffectiveUser.Id, "age", age)
+		msg := fmt.Sprintf("Recently refreshed %ds ago, here's the cached result:\n\n%s", int(age.Seconds()), cached)
+		_, err := ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	// Send initial message
+	statusMsg, err := ctx.EffectiveMessage.Reply(b, "Fetching Uniswap positions... This may take a moment.", &gotgbot.SendMessageOpts{})
+	if err != nil {
+		return err
+	}
+
+	if target != "" {
+		return h.sendWalletStatus(b, ctx, statusMsg, target)
+	}
+
+	msg, outcome := h.buildStatus(b, ctx, statusMsg, statusViewDefault, h.userLocation(reqCtx, ctx.EffectiveUser.Id))
+	if outcome == statusOK {
+		h.statusThrottle.Store(ctx.EffectiveUser.Id, ctx.EffectiveChat.Id, msg)
+	}
+
+	// Update final message
+	_, _, err = statusMsg.EditText(b, msg, &gotgbot.EditMessageTextOpts{
+		ReplyMarkup: statusKeyboardFor(outcome, statusViewDefault),
+	})
+	return err
+}
+
+// handleStatusCallback re-runs /status with the view picked from the inline buttons
+func (h *BotHandlers) handleStatusCallback(b *gotgbot.Bot, ctx *ext.Context) error {
+	cb := ctx.CallbackQuery
+	view := statusView(strings.TrimPrefix(cb.Data, statusCallbackPrefix))
+	h.log(ctx).Infow("Received status callback", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id, "view", view)
+
+	if cb.Message == nil {
+		_, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: "This message is too old, please send /status again."})
+		return err
+	}
+
+	if _, age, ok := h.statusThrottle.Recent(ctx.EffectiveUser.Id, ctx.EffectiveChat.Id); ok {
+		wait := statusCooldown - age
+		_, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
+			Text: fmt.Sprintf("Recently refreshed, please try again in %ds.", int(wait.Seconds())+1),
+		})
+		return err
+	}
+
+	if _, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: "Refreshing..."}); err != nil {
+		return err
+	}
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	msg, outcome := h.buildStatus(b, ctx, cb.Message, view, h.userLocation(reqCtx, ctx.EffectiveUser.Id))
+	if outcome == statusOK {
+		h.statusThrottle.Store(ctx.EffectiveUser.Id, ctx.EffectiveChat.Id, msg)
+	}
+
+	_, _, err := cb.Message.EditText(b, msg, &gotgbot.EditMessageTextOpts{
+		ReplyMarkup: statusKeyboardFor(outcome, view),
+	})
+	if isMessageNotModified(err) {
+		return nil
+	}
+	return err
+}
+
+// sendWalletStatus runs an ad-hoc wallet lookup and puts the result into statusMsg. The result
+// isn't cached for the status throttle, which would serve it as the chat's status.
+func (h *BotHandlers) sendWalletStatus(b *gotgbot.Bot, ctx *ext.Context, statusMsg gotgbot.MaybeInaccessibleMessage, target string) error {
+	msg, outcome := h.buildWalletStatus(b, ctx, statusMsg, target)
+
+	opts := &gotgbot.EditMessageTextOpts{}
+	if outcome == statusFailed {
+		opts.ReplyMarkup = retryKeyboard(walletStatusCallbackPrefix + target)
+	}
+	_, _, err := statusMsg.EditText(b, msg, opts)
+	if isMessageNotModified(err) {
+		return nil
+	}
+	return err
+}
+
+// handleWalletStatusCallback retries a failed ad-hoc wallet lookup
+func (h *BotHandlers) handleWalletStatusCallback(b *gotgbot.Bot, ctx *ext.Context) error {
+	cb := ctx.CallbackQuery
+	target := strings.TrimPrefix(cb.Data, walletStatusCallbackPrefix)
+	h.log(ctx).Infow("Received wallet status retry", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id, "target", target)
+
+	if cb.Message == nil {
+		_, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: "This message is too old, please send the command again."})
+		return err
+	}
+
+	if _, err := cb.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: "Retrying..."}); err != nil {
+		return err
+	}
+	return h.sendWalletStatus(b, ctx, cb.Message, target)
+}
+
+// buildStatus fetches the positions of all wallets and tracked positions of a chat and
+// formats them for display. Progress is reported by editing statusMsg.
+func (h *BotHandlers) buildStatus(b *gotgbot.Bot, ctx *ext.Context, statusMsg gotgbot.MaybeInaccessibleMessage, view statusView, loc *time.Location) (string, statusOutcome) {
+	chatID := ctx.EffectiveChat.Id
+	bgCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	// Get wallets from database
+	chatWallets, err := h.db.GetChatWallets(bgCtx, chatID)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get wallets", "error", err)
+		return "Failed to retrieve wallets. Please try again later.", statusFailed
+	}
+	wallets := make([]string, 0, len(chatWallets))
+	names := make(map[string]string, len(chatWallets))
+	for _, w := range chatWallets {
+		wallets = append(wallets, w.WalletAddress)
+		names[w.WalletAddress] = w.DisplayName()
+	}
+
+	// Get individually tracked positions from database
+	tracked, err := h.db.GetTrackedPositions(bgCtx, chatID)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get tracked positions", "error", err)
+		return "Failed to retrieve tracked positions. Please try again later.", statusFailed
+	}
+
+	if len(wallets) == 0 && len(tracked) == 0 {
+		return "You don't have any wallets added yet. Use /add_wallet <address> to add one.", statusNothing
+	}
+
+	settings, err := h.db.GetChatSettings(bgCtx, chatID)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get chat settings", "error", err)
+		settings = DefaultChatSettings
+	}
+
+	includeV3, includeV4 := settings.IncludeV3, settings.IncludeV4
+	switch view {
+	case statusViewV3:
+		includeV3, includeV4 = true, false
+	case statusViewV4:
+		includeV3, includeV4 = false, true
+	case statusViewAll:
+		includeV3, includeV4 = true, true
+	}
+
+	// Show the positions cached by earlier lookups right away while fresh ones are fetched
+	previewed := h.previewCachedStatus(bgCtx, b, statusMsg, chatWallets, names, includeV3, includeV4, settings.DisplayMode, loc)
+
+	// Report progress without editing the message once per lookup, unless the preview is shown
+	unit := "wallets"
+	if len(tracked) > 0 {
+		unit = "lookups"
+	}
+	total := len(wallets) + len(tracked)
+	if previewed {
+		total = 0
+	}
+	progress := newProgressReporter(b, statusMsg, total, unit, h.logger)
+
+	// Fetch positions for each wallet, a few wallets at a time
+	type walletLookup struct {
+		address string
+		req     uniswap.PositionRequest
+	}
+	var walletLookups []walletLookup
+	for _, wallet := range chatWallets {
+		// Don't query versions the wallet was restricted away from
+		req := uniswap.PositionRequest{
+			WalletAddress: common.HexToAddress(wallet.WalletAddress),
+			IncludeV3:     includeV3 && wallet.Version != string(uniswap.VersionV4),
+			IncludeV4:     includeV4 && wallet.Version != string(uniswap.VersionV3),
+			IncludeClosed: true,
+		}
+		if !req.IncludeV3 && !req.IncludeV4 {
+			progress.Done()
+			continue
+		}
+		walletLookups = append(walletLookups, walletLookup{address: wallet.WalletAddress, req: req})
+	}
+
+	var failed atomic.Int32
+	walletPositions := make([][]uniswap.Position, len(walletLookups))
+	forEachConcurrently(bgCtx, h.fetchConcurrency, walletLookups, func(i int, l walletLookup) {
+		lookupCtx, cancel := newLookupContext(bgCtx)
+		positions, err := h.uniswapClient.GetPositions(lookupCtx, l.req)
+		cancel()
+		progress.Done()
+		if err != nil && !errors.Is(err, uniswap.ErrWalletHasNoPositions) && !errors.Is(err, uniswap.ErrPartialPositions) {
+			h.log(ctx).Errorw("Failed to fetch positions", "wallet", l.address, "error", err)
+			failed.Add(1)
+			return
+		}
+		// Only cache all of the wallet's positions, a partial list would hide the rest
+		if l.req.IncludeV3 && l.req.IncludeV4 && err == nil {
+			if err := h.db.SaveCachedPositions(bgCtx, l.address, positions, time.Now()); err != nil {
+				h.log(ctx).Warnw("Failed to cache positions", "wallet", l.address, "error", err)
+			}
+		}
+		walletPositions[i] = positions
+	})
+	var allPositions []uniswap.Position
+	for _, positions := range walletPositions {
+		allPositions = append(allPositions, positions...)
+	}
+
+	// Fetch individually tracked positions
+	type positionLookup struct {
+		tp TrackedPosition
+		id *big.Int
+	}
+	var positionLookups []positionLookup
+	for _, tp := range tracked {
+		version := uniswap.PositionVersion(tp.Version)
+		if (version == uniswap.VersionV3 && !includeV3) || (version == uniswap.VersionV4 && !includeV4) {
+			continue
+		}
+
+		id, ok := new(big.Int).SetString(tp.PositionID, 10)
+		if !ok {
+			h.log(ctx).Warnw("Invalid tracked position ID", "position_id", tp.PositionID)
+			continue
+		}
+		positionLookups = append(positionLookups, positionLookup{tp: tp, id: id})
+	}
+
+	fetchedTracked := make([]*uniswap.Position, len(positionLookups))
+	forEachConcurrently(bgCtx, h.fetchConcurrency, positionLookups, func(i int, l positionLookup) {
+		lookupCtx, cancel := newLookupContext(bgCtx)
+		pos, err := h.uniswapClient.GetPosition(lookupCtx, uniswap.PositionVersion(l.tp.Version), l.id)
+		cancel()
+		progress.Done()
+		if err != nil {
+			h.log(ctx).Errorw("Failed to fetch tracked position", "position_id", l.tp.PositionID, "version", l.tp.Version, "error", err)
+			failed.Add(1)
+			return
+		}
+		fetchedTracked[i] = pos
+	})
+	var trackedPositions []uniswap.Position
+	for _, pos := range fetchedTracked {
+		if pos != nil {
+			trackedPositions = append(trackedPositions, *pos)
+		}
+	}
+
+	attempted := len(walletLookups) + len(positionLookups)
+	failedLookups := int(failed.Load())
+
+	// Nothing could be fetched at all, offer a retry instead of claiming there are no positions
+	if attempted > 0 && failedLookups == attempted {
+		return "Failed to fetch positions. Please try again later.", statusFailed
+	}
+
+	// Show the most valuable positions first rather than in the order the subgraphs return them
+	prices := priceTokens(bgCtx, h.uniswapClient, slices.Concat(allPositions, trackedPositions), h.log(ctx))
+	uniswap.SortPositions(allPositions, uniswap.ByValueDesc(prices))
+	uniswap.SortPositions(trackedPositions, uniswap.ByValueDesc(prices))
+	h.screenTokens(bgCtx, allPositions)
+	h.screenTokens(bgCtx, trackedPositions)
+
+	msg := formatStatus(wallets, names, allPositions, trackedPositions, settings.DisplayMode, loc)
+
+	if failedLookups > 0 {
+		msg += fmt.Sprintf("\nWarning: %d of %d lookups failed, use Refresh to try again.", failedLookups, attempted)
+	}
+
+	return msg, statusOK
+}
+
+// formatStatus lists the positions of each wallet and the individually tracked positions.
+// names maps wallet addresses to the names to show for them.
+func formatStatus(wallets []string, names map[string]string, allPositions, trackedPositions []uniswap.Position, mode DisplayMode, loc *time.Location) string {
+	var msg string
+	if len(allPositions) == 0 && len(trackedPositions) == 0 {
+		msg = "No Uniswap positions found for your wallets."
+	} else {
+		msg = fmt.Sprintf("Found %d Uniswap positions:\n\n", len(allPositions)+len(trackedPositions))
+
+		// Create a map to store positions by wallet
+		positionsByWallet := make(map[string][]uniswap.Position)
+
+		// Initialize the map with all wallets, even those with no positions
+		for _, wallet := range wallets {
+			positionsByWallet[wallet] = []uniswap.Position{}
+		}
+
+		// Group positions by wallet
+		for _, pos := range allPositions {
+			// Find which wallet this position belongs to
+			for _, wallet := range wallets {
+				if strings.EqualFold(pos.Owner.Hex(), wallet) {
+					positionsByWallet[wallet] = append(positionsByWallet[wallet], pos)
+					break
+				}
+			}
+		}
+
+		// Format each wallet's positions
+		for wallet, positions := range positionsByWallet {
+			msg += fmt.Sprintf("Wallet: %s\n", names[wallet])
+			msg += "--------------------\n"
+
+			for i, pos := range positions {
+				pos.CreatedAt = pos.CreatedAt.In(loc)
+				msg += formatPosition(i+1, pos, mode)
+			}
+			if mode == DisplayCompact {
+				msg += "\n"
+			}
+		}
+
+		// Format individually tracked positions
+		if len(trackedPositions) > 0 {
+			msg += "Tracked positions\n"
+			msg += "--------------------\n"
+
+			for i, pos := range trackedPositions {
+				pos.CreatedAt = pos.CreatedAt.In(loc)
+				msg += formatPosition(i+1, pos, mode)
+			}
+			if mode == DisplayCompact {
+				msg += "\n"
+			}
+		}
+	}
+	return msg
+}
+
+// previewCachedStatus shows the chat's wallets as cached by earlier lookups in statusMsg, if all of them
+// were cached within positionCacheTTL. It reports whether it did.
+func (h *BotHandlers) previewCachedStatus(ctx context.Context, b *gotgbot.Bot, statusMsg gotgbot.MaybeInaccessibleMessage, chatWallets []ChatWallet, names map[string]string, includeV3, includeV4 bool, mode DisplayMode, loc *time.Location) bool {
+	if len(chatWallets) == 0 {
+		return false
+	}
+
+	var wallets []string
+	var positions []uniswap.Position
+	var oldest time.Time
+	for _, wallet := range chatWallets {
+		cached, fetchedAt, ok, err := h.db.GetCachedPositions(ctx, wallet.WalletAddress)
+		if err != nil {
+			requestLogger(ctx, h.logger).Warnw("Failed to get cached positions", "wallet", wallet.WalletAddress, "error", err)
+		}
+		if err != nil || !ok || time.Since(fetchedAt) > positionCacheTTL {
+			return false
+		}
+		if oldest.IsZero() || fetchedAt.Before(oldest) {
+			oldest = fetchedAt
+		}
+
+		// Apply the same version restrictions as a fresh lookup
+		for _, pos := range cached {
+			switch {
+			case pos.Version == uniswap.VersionV3 && includeV3 && wallet.Version != string(uniswap.VersionV4),
+				pos.Version == uniswap.VersionV4 && includeV4 && wallet.Version != string(uniswap.VersionV3):
+				positions = append(positions, pos)
+			}
+		}
+		wallets = append(wallets, wallet.WalletAddress)
+	}
+
+	msg := fmt.Sprintf("Data from %s ago, refreshing…\n\n%s", formatAge(time.Since(oldest)), formatStatus(wallets, names, positions, nil, mode, loc))
+	if _, _, err := statusMsg.EditText(b, msg, &gotgbot.EditMessageTextOpts{}); err != nil && !isMessageNotModified(err) {
+		requestLogger(ctx, h.logger).Warnw("Failed to show cached status", "error", err)
+		return false
+	}
+	return true
+}
+
+// formatAge formats a duration for "data from ... ago" notes, e.g. "3 min"
+func formatAge(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	return fmt.Sprintf("%d min", int(d.Minutes()))
+}
+
+// buildWalletStatus looks up the positions of a single wallet given as an address or ENS
+// name, without requiring it to be tracked.
+func (h *BotHandlers) buildWalletStatus(b *gotgbot.Bot, ctx *ext.Context, statusMsg gotgbot.MaybeInaccessibleMessage, target string) (string, statusOutcome) {
+	chatID := ctx.EffectiveChat.Id
+	bgCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	var wallet common.Address
+	if uniswap.IsENSName(target) {
+		resolver, ok := h.uniswapClient.(uniswap.ENSResolver)
+		if !ok {
+			return "ENS names are not supported by the configured data source. Please use a 0x address.", statusNothing
+		}
+
+		_, _, err := statusMsg.EditText(b, fmt.Sprintf("Resolving %s...", target), &gotgbot.EditMessageTextOpts{})
+		if err != nil {
+			h.log(ctx).Warnw("Failed to update status message", "error", err)
+		}
+
+		wallet, err = resolver.ResolveENS(bgCtx, target)
+		if errors.Is(err, uniswap.ErrNameNotFound) {
+			return fmt.Sprintf("%s does not resolve to an address.", target), statusNothing
+		}
+		if err != nil {
+			h.log(ctx).Errorw("Failed to resolve ENS name", "name", target, "error", err)
+			return "Failed to resolve ENS name. Please try again later.", statusFailed
+		}
+	} else {
+		address, err := parseWalletAddress(target)
+		if err != nil {
+			return err.Error(), statusNothing
+		}
+		wallet = address
+	}
+
+	settings, err := h.db.GetChatSettings(bgCtx, chatID)
+	if err != nil {
+		h.log(ctx).Errorw("Failed to get chat settings", "error", err)
+		settings = DefaultChatSettings
+	}
+
+	positions, err := h.uniswapClient.GetPositions(bgCtx, uniswap.PositionRequest{
+		WalletAddress: wallet,
+		IncludeV3:     settings.IncludeV3,
+		IncludeV4:     settings.IncludeV4,
+		IncludeClosed: true,
+	})
+	if err != nil && !errors.Is(err, uniswap.ErrWalletHasNoPositions) && !errors.Is(err, uniswap.ErrPartialPositions) {
+		h.log(ctx).Errorw("Failed to fetch positions", "wallet", wallet.Hex(), "error", err)
+		return fetchErrorMessage(err), statusFailed
+	}
+
+	header := fmt.Sprintf("Wallet: %s", wallet.Hex())
+	if uniswap.IsENSName(target) {
+		header = fmt.Sprintf("Wallet: %s (%s)", target, wallet.Hex())
+	}
+
+	if len(positions) == 0 {
+		return fmt.Sprintf("%s\n\nNo Uniswap positions found.", header), statusOK
+	}
+
+	uniswap.SortPositions(positions, uniswap.ByValueDesc(priceTokens(bgCtx, h.uniswapClient, positions, h.log(ctx))))
+	h.screenTokens(bgCtx, positions)
+
+	msg := fmt.Sprintf("%s\nFound %d Uniswap positions:\n\n", header, len(positions))
+	for i, pos := range positions {
+		msg += formatPosition(i+1, pos, settings.DisplayMode)
+	}
+	if settings.DisplayMode == DisplayCompact {
+		msg += "\n"
+	}
+	msg += "This wallet is not tracked. Use /add_wallet to track it."
+	return msg, statusOK
+}
+
+// statusKeyboard builds the view toggles shown under /status results. Refresh keeps the current view.
+func statusKeyboard(current statusView) gotgbot.InlineKeyboardMarkup {
+	return gotgbot.InlineKeyboardMarkup{
+		InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+			{
+				{Text: "V3 only", CallbackData: statusCallbackPrefix + string(statusViewV3)},
+				{Text: "V4 only", CallbackData: statusCallbackPrefix + string(statusViewV4)},
+				{Text: "All chains", CallbackData: statusCallbackPrefix + string(statusViewAll)},
+			},
+			{
+				{Text: "Refresh", CallbackData: statusCallbackPrefix + string(current)},
+			},
+		},
+	}
+}
+
+// statusKeyboardFor picks the buttons for a /status result: view toggles for position data,
+// a Retry button after a failure and nothing otherwise.
+func statusKeyboardFor(outcome statusOutcome, view statusView) gotgbot.InlineKeyboardMarkup {
+	switch outcome {
+	case statusOK:
+		return statusKeyboard(view)
+	case statusFailed:
+		return retryKeyboard(statusCallbackPrefix + string(view))
+	default:
+		return gotgbot.InlineKeyboardMarkup{}
+	}
+}
+
+// retryKeyboard builds a single Retry button that sends the given callback data.
+// Telegram limits callback data to 64 bytes, longer data gets no button.
+func retryKeyboard(callbackData string) gotgbot.InlineKeyboardMarkup {
+	if len(callbackData) > 64 {
+		return gotgbot.InlineKeyboardMarkup{}
+	}
+	return gotgbot.InlineKeyboardMarkup{
+		InlineKeyboard: [][]gotgbot.InlineKeyboardButton{{
+			{Text: "Retry", CallbackData: callbackData},
+		}},
+	}
+}
+
+// isMessageNotModified reports whether Telegram rejected an edit because the content didn't change
+func isMessageNotModified(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "message is not modified")
+}
+
+// userLocation returns the time zone the user chose in /preferences
+func (h *BotHandlers) userLocation(ctx context.Context, userID int64) *time.Location {
+	settings, err := h.db.GetUserSettings(ctx, userID)
+	if err != nil {
+		requestLogger(ctx, h.logger).Errorw("Failed to get user settings", "user_id", userID, "error", err)
+	}
+	return settings.Location()
+}
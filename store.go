@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/korjavin/uniswapfetcher/uniswap"
+)
+
+// WalletStore persists the wallets and single positions each chat tracks, and which user added them
+type WalletStore interface {
+	AddWallet(ctx context.Context, chatID, userID int64, walletAddress, version string) (bool, error)
+	RemoveWallet(ctx context.Context, chatID int64, walletAddress string) error
+	GetChatWallets(ctx context.Context, chatID int64) ([]ChatWallet, error)
+	GetAllTrackedWallets(ctx context.Context) ([]TrackedWallet, error)
+	SetWalletLabel(ctx context.Context, chatID int64, walletAddress, label string) (bool, error)
+	GetWalletLabel(ctx context.Context, chatID int64, walletAddress string) (string, error)
+
+	TrackPosition(ctx context.Context, chatID, userID int64, positionID, version string) error
+	UntrackPosition(ctx context.Context, chatID int64, positionID, version string) (bool, error)
+	GetTrackedPositions(ctx context.Context, chatID int64) ([]TrackedPosition, error)
+	ListAllTrackedPositions(ctx context.Context) ([]ChatTrackedPosition, error)
+}
+
+// SettingsStore persists each chat's preferences
+type SettingsStore interface {
+	GetChatSettings(ctx context.Context, chatID int64) (ChatSettings, error)
+	SaveChatSettings(ctx context.Context, chatID int64, settings ChatSettings) error
+}
+
+// UserSettingsStore persists each user's personal preferences and deletes all of a user's data on request
+type UserSettingsStore interface {
+	GetUserSettings(ctx context.Context, userID int64) (UserSettings, error)
+	SaveUserSettings(ctx context.Context, userID int64, settings UserSettings) error
+	DeleteUser(ctx context.Context, userID int64) error
+}
+
+// TierStore persists each user's subscription tier and tells what it entitles them to
+type TierStore interface {
+	GetUserTier(ctx context.Context, userID int64) (Tier, error)
+	SetUserTier(ctx context.Context, userID int64, tier Tier) error
+	GetEntitlements(ctx context.Context, userID int64) (Entitlements, error)
+}
+
+// AccessStore persists the users admitted with the invite code
+type AccessStore interface {
+	AllowUser(ctx context.Context, userID int64) error
+	IsUserAllowed(ctx context.Context, userID int64) (bool, error)
+}
+
+// ShareStore persists the read-only share links
+type ShareStore interface {
+	CreateShareLink(ctx context.Context, link ShareLink) error
+	GetShareLinkForWallet(ctx context.Context, chatID int64, walletAddress string) (string, error)
+	GetShareLink(ctx context.Context, token string) (ShareLink, bool, error)
+	DeleteShareLinks(ctx context.Context, chatID int64, walletAddress string) (int64, error)
+}
+
+// SnapshotStore persists the position snapshots taken at each monitor refresh
+type SnapshotStore interface {
+	SaveSnapshots(ctx context.Context, snapshots []PositionSnapshot) error
+	GetSnapshots(ctx context.Context, positionID, version string, since time.Time) ([]PositionSnapshot, error)
+	GetLatestSnapshots(ctx context.Context) ([]PositionSnapshot, error)
+}
+
+// FeeLedgerStore persists the fees each position accrued between refreshes
+type FeeLedgerStore interface {
+	SaveFeeLedger(ctx context.Context, entries []FeeLedgerEntry) error
+	GetFeeLedger(ctx context.Context, positionID, version string, since time.Time) ([]FeeLedgerEntry, error)
+}
+
+// AlertStore persists the chats' alert rules and the ledger of alerts sent for them
+type AlertStore interface {
+	CreateAlertRule(ctx context.Context, rule AlertRule) (int64, error)
+	GetAlertRule(ctx context.Context, chatID, id int64) (AlertRule, bool, error)
+	GetAlertRules(ctx context.Context, chatID int64) ([]AlertRule, error)
+	ListEnabledAlertRules(ctx context.Context) ([]AlertRule, error)
+	UpdateAlertRule(ctx context.Context, rule AlertRule) (bool, error)
+	DeleteAlertRule(ctx context.Context, chatID, id int64) (bool, error)
+	MarkAlertRuleFired(ctx context.Context, id int64, at time.Time) error
+	RecordAlertDelivery(ctx context.Context, delivery AlertDelivery) error
+	GetLatestAlertDeliveries(ctx context.Context) ([]AlertDelivery, error)
+}
+
+// NotificationStore persists the queue of notifications waiting to be delivered to chats
+type NotificationStore interface {
+	EnqueueNotification(ctx context.Context, chatID int64, text string) error
+	ClaimDueNotifications(ctx context.Context, now, until time.Time, limit int) ([]Notification, error)
+	ReleaseNotifications(ctx context.Context, ids []int64, next time.Time) error
+	DeleteNotification(ctx context.Context, id int64) error
+	RetryNotification(ctx context.Context, id int64, next time.Time, lastError string) error
+	DeadLetterNotification(ctx context.Context, id int64, at time.Time, lastError string) error
+}
+
+// ChatBotStore persists which of the bots served by the process each chat talks to
+type ChatBotStore interface {
+	SetChatBot(ctx context.Context, chatID, botID int64) error
+	GetChatBot(ctx context.Context, chatID int64) (int64, bool, error)
+}
+
+// TokenStore persists token metadata so tokens resolved once are remembered across restarts
+type TokenStore interface {
+	uniswap.TokenCache
+}
+
+// PositionCacheStore persists the positions last fetched for each wallet, so they can be shown right away
+type PositionCacheStore interface {
+	SaveCachedPositions(ctx context.Context, walletAddress string, positions []uniswap.Position, fetchedAt time.Time) error
+	GetCachedPositions(ctx context.Context, walletAddress string) ([]uniswap.Position, time.Time, bool, error)
+}
+
+// MonitorStateStore persists the positions the monitor last saw for each wallet and tracked position, and how far it
+// followed events such as swaps, so changes that happen while the bot is down are still reported
+// after a restart
+type MonitorStateStore interface {
+	SaveLastSeenPositions(ctx context.Context, walletAddress string, positions []uniswap.Position, seenAt time.Time) error
+	GetLastSeenPositions(ctx context.Context, walletAddress string) ([]uniswap.Position, time.Time, bool, error)
+	SaveLastSeenTrackedPosition(ctx context.Context, pos uniswap.Position, seenAt time.Time) error
+	GetLastSeenTrackedPosition(ctx context.Context, positionID, version string) (*uniswap.Position, bool, error)
+	DeleteLastSeenTrackedPosition(ctx context.Context, positionID, version string) error
+	SaveMonitorCursor(ctx context.Context, name string, at time.Time) error
+	GetMonitorCursor(ctx context.Context, name string) (time.Time, bool, error)
+}
+
+// UsageStore persists how much the bot is used, to plan API quota
+type UsageStore interface {
+	RecordUsage(ctx context.Context, activeUsers map[string][]int64, counters []UsageCounter) error
+	GetUsage(ctx context.Context, since string) ([]DailyUsage, error)
+}
+
+// HealthStore reports whether the store can be queried
+type HealthStore interface {
+	Ping(ctx context.Context) error
+}
+
+// BackupStore copies the whole store for safekeeping
+type BackupStore interface {
+	Backup(ctx context.Context, path string) error
+}
+
+// Store is everything the bot persists. Database implements it on top of SQLite, other backends
+// only need to implement these interfaces.
+type Store interface {
+	WalletStore
+	SettingsStore
+	UserSettingsStore
+	TierStore
+	AccessStore
+	ShareStore
+	AlertStore
+	NotificationStore
+	ChatBotStore
+	SnapshotStore
+	FeeLedgerStore
+	TokenStore
+	PositionCacheStore
+	MonitorStateStore
+	UsageStore
+	BackupStore
+	HealthStore
+
+	// WithTx runs fn with a Store whose methods all run in one transaction, committed only if fn returns nil
+	WithTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+var _ Store = (*Database)(nil)
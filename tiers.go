@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// Tier is a user's subscription tier
+type Tier string
+
+const (
+	// TierFree is every user's tier unless an administrator changed it
+	TierFree Tier = "free"
+	// TierPremium lifts the free tier's limits
+	TierPremium Tier = "premium"
+)
+
+// Entitlements are what a tier allows its users
+type Entitlements struct {
+	// MaxWallets caps the wallets a user may add, counted across all chats, 0 means no limit
+	MaxWallets int
+	// MinAlertCooldown is the shortest cooldown the user may give an alert rule
+	MinAlertCooldown time.Duration
+	// Chains are the chains the user may follow
+	Chains []string
+}
+
+// tierEntitlements are the entitlements of each tier. The free tier's MaxWallets is
+// MAX_WALLETS_PER_USER, see Database.GetEntitlements.
+var tierEntitlements = map[Tier]Entitlements{
+	TierFree:    {Chains: supportedChains},
+	TierPremium: {Chains: supportedChains},
+}
+
+// AllowsChain reports whether the entitlements include the chain
+func (e Entitlements) AllowsChain(chain string) bool {
+	return slices.Contains(e.Chains, chain)
+}
+
+// parseTier matches a tier name case-insensitively
+func parseTier(s string) (Tier, bool) {
+	tier := Tier(strings.ToLower(s))
+	_, ok := tierEntitlements[tier]
+	return tier, ok
+}
+
+// handleSetTier lets bot administrators change a user's tier with "/set_tier <user id> <tier>"
+func (h *BotHandlers) handleSetTier(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received set_tier command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	if !h.admins[ctx.EffectiveUser.Id] {
+		_, err := ctx.EffectiveMessage.Reply(b, "Only the bot's administrators can change tiers.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	args := ctx.Args()
+	if len(args) < 3 {
+		_, err := ctx.EffectiveMessage.Reply(b, "Usage: /set_tier <user id> <free|premium>", &gotgbot.SendMessageOpts{})
+		return err
+	}
+	userID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		_, err := ctx.EffectiveMessage.Reply(b, "Please provide a numeric Telegram user ID.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+	tier, ok := parseTier(args[2])
+	if !ok {
+		_, err := ctx.EffectiveMessage.Reply(b, "Unknown tier. Usage: /set_tier <user id> <free|premium>", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	reqCtx, cancel := newRequestContext(ctx)
+	defer cancel()
+
+	if err := h.db.SetUserTier(reqCtx, userID, tier); err != nil {
+		h.log(ctx).Errorw("Failed to set user tier", "target_user_id", userID, "error", err)
+		_, err := ctx.EffectiveMessage.Reply(b, "Failed to change the tier. Please try again later.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+	h.log(ctx).Infow("Changed user tier", "target_user_id", userID, "tier", tier)
+
+	_, err = ctx.EffectiveMessage.Reply(b, fmt.Sprintf("User %d is now on the %s tier.", userID, tier), &gotgbot.SendMessageOpts{})
+	return err
+}
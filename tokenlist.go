@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+)
+
+// ipfsGateway serves the ipfs:// logos of token lists over HTTPS, which browsers can load
+const ipfsGateway = "https://ipfs.io/ipfs/"
+
+// tokenList is a token list in the Uniswap Token Lists format, see https://tokenlists.org
+type tokenList struct {
+	Name   string `json:"name"`
+	Tokens []struct {
+		ChainID  uint64 `json:"chainId"`
+		Address  string `json:"address"`
+		Symbol   string `json:"symbol"`
+		Decimals int    `json:"decimals"`
+		LogoURI  string `json:"logoURI"`
+	} `json:"tokens"`
+}
+
+// parseTokenList parses a tokenlist.json file into the metadata of its tokens on the chain
+// positions are fetched on. Tokens on other chains are left out, as are tokens without decimals,
+// which the subgraphs also report for tokens they couldn't resolve. Invalid tokens are left out
+// too, and returned as invalid, so one mistake doesn't cost the thousands of other tokens.
+func parseTokenList(data []byte) (tokens []uniswap.TokenMetadata, invalid []error, err error) {
+	var list tokenList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, nil, err
+	}
+	if list.Tokens == nil {
+		return nil, nil, errors.New("no tokens")
+	}
+
+	tokens = make([]uniswap.TokenMetadata, 0, len(list.Tokens))
+	for i, entry := range list.Tokens {
+		if uniswap.ChainID(entry.ChainID) != uniswap.ChainIDEthereum {
+			continue
+		}
+		switch {
+		case !common.IsHexAddress(entry.Address):
+			invalid = append(invalid, fmt.Errorf("token %d: invalid address %q", i+1, entry.Address))
+			continue
+		case entry.Symbol == "":
+			invalid = append(invalid, fmt.Errorf("token %d: symbol is required", i+1))
+			continue
+		case entry.Decimals < 0 || entry.Decimals > 255:
+			invalid = append(invalid, fmt.Errorf("token %d: invalid decimals %d", i+1, entry.Decimals))
+			continue
+		case entry.Decimals == 0:
+			continue
+		}
+		tokens = append(tokens, uniswap.TokenMetadata{
+			Address:  common.HexToAddress(entry.Address),
+			Chain:    uniswap.ChainEthereum,
+			Symbol:   entry.Symbol,
+			Decimals: uint8(entry.Decimals),
+			LogoURI:  tokenListLogoURI(entry.LogoURI),
+		})
+	}
+	return tokens, invalid, nil
+}
+
+// tokenListLogoURI returns a logo URI browsers can load, rewriting ipfs:// URIs to ipfsGateway
+func tokenListLogoURI(uri string) string {
+	if cid, ok := strings.CutPrefix(uri, "ipfs://"); ok {
+		return ipfsGateway + cid
+	}
+	return uri
+}
+
+// mergeKnownTokens combines the tokens of the registry file and of the token lists. The registry
+// file's tokens take precedence, and of tokens on several lists, the first list's.
+func mergeKnownTokens(registryTokens, listedTokens []uniswap.TokenMetadata) []uniswap.TokenMetadata {
+	seen := make(map[common.Address]bool, len(registryTokens)+len(listedTokens))
+	known := make([]uniswap.TokenMetadata, 0, len(registryTokens)+len(listedTokens))
+	for _, token := range append(append([]uniswap.TokenMetadata{}, registryTokens...), listedTokens...) {
+		if !seen[token.Address] {
+			seen[token.Address] = true
+			known = append(known, token)
+		}
+	}
+	return known
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"go.uber.org/zap"
+)
+
+// tokenBlocklistRefreshInterval is how often the token blocklists are read again, to pick up
+// tokens newly found to be honeypots
+const tokenBlocklistRefreshInterval = time.Hour
+
+// maxRemoteFileSize bounds how much of a blocklist or token list at a URL is read
+const maxRemoteFileSize = 16 << 20
+
+// remoteFileTimeout bounds how long reading a blocklist or token list at a URL may take, so a
+// host that stops answering doesn't hold up startup or every later reload
+const remoteFileTimeout = 30 * time.Second
+
+// remoteFileClient reads blocklists and token lists at URLs
+var remoteFileClient = &http.Client{Timeout: remoteFileTimeout}
+
+// TokenBlocklistLoader keeps the screener's blocklist up to date with the files and URLs of
+// TOKEN_BLOCKLISTS, each a list of token addresses, one per line
+type TokenBlocklistLoader struct {
+	sources  []string
+	screener *uniswap.TokenScreener
+	logger   *zap.SugaredLogger
+}
+
+func NewTokenBlocklistLoader(sources []string, screener *uniswap.TokenScreener, logger *zap.SugaredLogger) *TokenBlocklistLoader {
+	return &TokenBlocklistLoader{sources: sources, screener: screener, logger: logger}
+}
+
+// Load reads every blocklist and applies them together, returning how many tokens they list.
+// If any can't be read, the blocklist is left as it was.
+func (l *TokenBlocklistLoader) Load(ctx context.Context) (int, error) {
+	seen := make(map[common.Address]bool)
+	var tokens []common.Address
+	var errs []error
+	for _, source := range l.sources {
+		data, err := readFileOrURL(ctx, source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read token blocklist %s: %w", source, err))
+			continue
+		}
+		addresses, err := parseTokenBlocklist(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid token blocklist %s: %w", source, err))
+			continue
+		}
+		for _, address := range addresses {
+			if !seen[address] {
+				seen[address] = true
+				tokens = append(tokens, address)
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return 0, err
+	}
+	l.screener.SetBlocklist(tokens)
+	return len(tokens), nil
+}
+
+// Job reads the blocklists again. Every replica runs it, since each has its own screener.
+func (l *TokenBlocklistLoader) Job() Job {
+	return Job{Name: "token blocklist refresh", Interval: tokenBlocklistRefreshInterval, Run: func(ctx context.Context) error {
+		tokens, err := l.Load(ctx)
+		if err != nil {
+			return err
+		}
+		requestLogger(ctx, l.logger).Infow("Reloaded token blocklists", "tokens", tokens)
+		return nil
+	}}
+}
+
+// readFileOrURL reads a blocklist or token list from a file, or from an http:// or https:// URL
+func readFileOrURL(ctx context.Context, source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := remoteFileClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxRemoteFileSize))
+}
+
+// parseTokenBlocklist parses a blocklist of one token address per line. Blank lines and
+// comments starting with # are skipped, as is anything after the address on a line.
+func parseTokenBlocklist(data []byte) ([]common.Address, error) {
+	var addresses []common.Address
+	var errs []error
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if !common.IsHexAddress(fields[0]) {
+			errs = append(errs, fmt.Errorf("line %d: invalid address %q", n, fields[0]))
+			continue
+		}
+		addresses = append(addresses, common.HexToAddress(fields[0]))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return addresses, errors.Join(errs...)
+}
+
+// screenTokens flags the positions' suspicious tokens, if token screening is enabled
+func (h *BotHandlers) screenTokens(ctx context.Context, positions []uniswap.Position) {
+	if h.screener != nil {
+		h.screener.Screen(ctx, positions)
+	}
+}
+
+// formatTokenWarning warns about the position's suspicious tokens, e.g. "SCAM is on a honeypot
+// list", or returns "" if it has none
+func formatTokenWarning(pos uniswap.Position) string {
+	var warnings []string
+	for _, token := range pos.SuspiciousTokens() {
+		warnings = append(warnings, token.String()+" "+token.Risk.Describe())
+	}
+	return strings.Join(warnings, "; ")
+}
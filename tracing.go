@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// serviceName identifies the bot in traces unless OTEL_SERVICE_NAME says otherwise
+const serviceName = "uniswapfetcher"
+
+// tracingShutdownTimeout bounds exporting the spans still buffered when the bot stops
+const tracingShutdownTimeout = 5 * time.Second
+
+// traceSpanKey is where a command's span is kept in the update's ext.Context data
+const traceSpanKey = "trace_span"
+
+// tracer creates the bot's own spans. It does nothing until initTracing installs an exporter.
+var tracer = otel.Tracer("github.com/korjavin/uniswapfetcher")
+
+// initTracing exports spans over OTLP/HTTP to endpoint, e.g. http://localhost:4318. The other
+// standard OTEL_EXPORTER_OTLP_* and OTEL_RESOURCE_ATTRIBUTES variables are honoured too. The
+// returned function exports the spans still buffered.
+func initTracing(ctx context.Context, endpoint string) (shutdown func(), err error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the service: %w", err)
+	}
+	// Let OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	if fromEnv, err := resource.New(ctx, resource.WithFromEnv()); err == nil {
+		if merged, err := resource.Merge(res, fromEnv); err == nil {
+			res = merged
+		}
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		provider.Shutdown(ctx)
+	}, nil
+}
+
+// traceCommand wraps the handler of command in a span lasting as long as the handler runs.
+// newRequestContext makes the work the handler does part of it.
+func traceCommand(command string, handler handlers.Response) handlers.Response {
+	return func(b *gotgbot.Bot, ctx *ext.Context) error {
+		_, span := tracer.Start(context.Background(), "command /"+command, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+		if ctx.EffectiveUser != nil {
+			span.SetAttributes(attribute.Int64("telegram.user_id", ctx.EffectiveUser.Id))
+		}
+		if ctx.EffectiveChat != nil {
+			span.SetAttributes(attribute.Int64("telegram.chat_id", ctx.EffectiveChat.Id))
+		}
+
+		if ctx.Data == nil {
+			ctx.Data = make(map[string]any)
+		}
+		ctx.Data[traceSpanKey] = span
+
+		err := handler(b, ctx)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+}
+
+// traceJob runs a scheduled job's run in a span of its own
+func traceJob(ctx context.Context, name string, run func(context.Context) error) error {
+	ctx, span := tracer.Start(ctx, "job "+name)
+	defer span.End()
+
+	err := run(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// updateTraceContext returns a context carrying the span of the command update is handled for, if any
+func updateTraceContext(update *ext.Context) context.Context {
+	if update != nil {
+		if span, ok := update.Data[traceSpanKey].(trace.Span); ok {
+			return trace.ContextWithSpan(context.Background(), span)
+		}
+	}
+	return context.Background()
+}
@@ -4,27 +4,35 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
-// UniswapSubgraphURL is the endpoint for Uniswap's V3 subgraph on The Graph protocol.
-// The subgraph indexes Uniswap V3 data from the blockchain and provides a GraphQL API
-// to efficiently query positions, pools, and other Uniswap-related data.
+// tracer creates a span for each query to The Graph
+var tracer = otel.Tracer("github.com/korjavin/uniswapfetcher/uniswap")
+
+// subgraphURLFormat is the endpoint of a subgraph on The Graph protocol, given the API key and
+// the subgraph's ID. The Uniswap subgraphs index Uniswap data from the blockchain and provide a
+// GraphQL API to efficiently query positions, pools, and other Uniswap-related data.
 // Documentation: https://docs.uniswap.org/protocol/reference/api/subgraph
+const subgraphURLFormat = "https://gateway.thegraph.com/api/%s/subgraphs/id/%s"
 
-const (
-	UniswapSubgraphURLV3 = "https://gateway.thegraph.com/api/%s/subgraphs/id/5zvR82QoaXYFyDEKLZ9t6v9adgnptxYpKpSbxtgVENFV"
-	UniswapSubgraphURLV4 = "https://gateway.thegraph.com/api/%s/subgraphs/id/DiYPVdygkfjDWhbxGSqAQxwBKmfKnkWQojqeM2rkLb3G"
-)
+// DefaultQueryTimeout bounds a single query to The Graph unless SetQueryTimeout says otherwise
+const DefaultQueryTimeout = 30 * time.Second
 
 // GraphQLError represents a GraphQL error response
 type GraphQLError struct {
@@ -52,9 +60,10 @@ type PositionData struct {
 		TickLower           string `json:"tickLower"`
 		TickUpper           string `json:"tickUpper"`
 		Pool                struct {
-			FeeTier     string `json:"feeTier"`
-			Token0Price string `json:"token0Price"`
-			Token1Price string `json:"token1Price"`
+			ID        string `json:"id"`
+			FeeTier   string `json:"feeTier"`
+			Tick      string `json:"tick"`
+			SqrtPrice string `json:"sqrtPrice"`
 		} `json:"pool"`
 		Token0 struct {
 			ID       string `json:"id"`
@@ -76,6 +85,7 @@ type V4PositionData struct {
 		Owner              string `json:"owner"`
 		CreatedAtTimestamp string `json:"createdAtTimestamp"`
 		Pool               struct {
+			ID     string `json:"id"`
 			Token0 struct {
 				ID       string `json:"id"`
 				Symbol   string `json:"symbol"`
@@ -111,52 +121,164 @@ type APIClient struct {
 	httpClient *http.Client
 	logger     *zap.SugaredLogger
 	apiKey     string
+	tokens     *tokenRegistry
+	chain      atomic.Pointer[Chain]
+	observer   RequestObserver
+	responses  ResponseObserver
+}
+
+// RequestObserver is told about every request made to The Graph, e.g. to count them against the
+// API quota. subgraph is "uniswap-v3", "uniswap-v4" or "ens".
+type RequestObserver func(subgraph string)
+
+// SetRequestObserver makes the client report each request it makes to The Graph to observer
+func (c *APIClient) SetRequestObserver(observer RequestObserver) {
+	c.observer = observer
+}
+
+// ResponseObserver is told whether each request made to The Graph succeeded, e.g. to report
+// repeated failures. Requests whose context ended are not reported.
+type ResponseObserver func(subgraph string, err error)
+
+// SetResponseObserver makes the client report the outcome of each request to The Graph to observer
+func (c *APIClient) SetResponseObserver(observer ResponseObserver) {
+	c.responses = observer
+}
+
+// SetQueryTimeout sets how long a single query to The Graph may take. Call it before using the client.
+func (c *APIClient) SetQueryTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// subgraphName names the subgraph a query URL points at
+func (c *APIClient) subgraphName(url string) string {
+	switch url {
+	case c.subgraphURL(VersionV3):
+		return "uniswap-v3"
+	case c.subgraphURL(VersionV4):
+		return "uniswap-v4"
+	case fmt.Sprintf(ENSSubgraphURL, c.apiKey):
+		return "ens"
+	default:
+		return "other"
+	}
 }
 
 // NewAPIClient creates a new Uniswap API client
 func NewAPIClient(logger *zap.SugaredLogger, apiKey string) (*APIClient, error) {
 	client := &APIClient{
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: DefaultQueryTimeout,
+			// Trace each request as part of the query it is made for
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
 		},
 		logger: logger,
 		apiKey: apiKey,
+		tokens: newTokenRegistry(logger),
 	}
+	client.chain.Store(&DefaultChain)
 	var _ Client = client
+	var _ ENSResolver = client
+	var _ PriceProvider = client
+	var _ SwapSource = client
+	var _ FeeHistorySource = client
+	var _ PositionEventSource = client
+	var _ HealthChecker = client
+	var _ PoolSource = client
 	return client, nil
 }
 
 // GetPositions fetches all Uniswap positions for a given wallet address using the subgraph API.
+// If one version's subgraph fails, it returns the other version's positions with an error that
+// is ErrPartialPositions. If ctx has a deadline and both versions are asked for, the V3 query gets at most half of the
+// time left, so a slow V3 subgraph doesn't keep the V4 positions from being fetched.
 func (c *APIClient) GetPositions(ctx context.Context, req PositionRequest) ([]Position, error) {
+	if !req.OnChain(c.chainID()) {
+		return nil, ErrWalletHasNoPositions
+	}
+
 	var allPositions []Position
+	var errs []error
+	fetched := 0
 
 	if req.IncludeV3 {
-		positions, err := c.getVersionPositions(ctx, req.WalletAddress, fmt.Sprintf(UniswapSubgraphURLV3, c.apiKey), VersionV3)
+		v3Ctx, cancel := ctx, context.CancelFunc(func() {})
+		if deadline, ok := ctx.Deadline(); ok && req.IncludeV4 {
+			v3Ctx, cancel = context.WithTimeout(ctx, time.Until(deadline)/2)
+		}
+		positions, err := c.getVersionPositions(v3Ctx, req.WalletAddress, c.subgraphURL(VersionV3), VersionV3)
+		cancel()
 		if err != nil {
-			c.logger.Warnw("Failed to fetch V3 positions", "error", err)
+			LoggerWithCorrelationID(ctx, c.logger).Warnw("Failed to fetch V3 positions", "error", err)
+			errs = append(errs, fmt.Errorf("V3: %w", err))
 		} else {
 			allPositions = append(allPositions, positions...)
+			fetched++
 		}
 	}
 
 	if req.IncludeV4 {
-		positions, err := c.getVersionPositions(ctx, req.WalletAddress, fmt.Sprintf(UniswapSubgraphURLV4, c.apiKey), VersionV4)
+		positions, err := c.getVersionPositions(ctx, req.WalletAddress, c.subgraphURL(VersionV4), VersionV4)
 		if err != nil {
-			c.logger.Warnw("Failed to fetch V4 positions", "error", err)
+			LoggerWithCorrelationID(ctx, c.logger).Warnw("Failed to fetch V4 positions", "error", err)
+			errs = append(errs, fmt.Errorf("V4: %w", err))
 		} else {
 			allPositions = append(allPositions, positions...)
+			fetched++
 		}
 	}
 
+	// The positions of one version are still worth showing if the other failed, but callers
+	// must be able to tell them from all of the wallet's
+	switch {
+	case fetched == 0 && len(errs) > 0:
+		return nil, errors.Join(errs...)
+	case len(errs) > 0:
+		return req.Apply(allPositions), fmt.Errorf("%w: %w", ErrPartialPositions, errors.Join(errs...))
+	case len(allPositions) == 0:
+		return nil, ErrWalletHasNoPositions
+	}
+	if allPositions = req.Apply(allPositions); len(allPositions) == 0 {
+		return nil, ErrWalletHasNoPositions
+	}
 	return allPositions, nil
 }
 
 func (c *APIClient) getVersionPositions(ctx context.Context, wallet common.Address, url string, version PositionVersion) ([]Position, error) {
+	where := fmt.Sprintf(`owner: "%s"`, strings.ToLower(wallet.Hex()))
+	return c.queryPositions(ctx, url, version, where)
+}
+
+// GetPosition fetches a single position by its NFT token ID from the subgraph of the given version.
+func (c *APIClient) GetPosition(ctx context.Context, version PositionVersion, id *big.Int) (*Position, error) {
+	var url string
+	switch version {
+	case VersionV3:
+		url = c.subgraphURL(VersionV3)
+	case VersionV4:
+		url = c.subgraphURL(VersionV4)
+	default:
+		return nil, fmt.Errorf("unsupported version: %s", version)
+	}
+
+	where := fmt.Sprintf(`id: "%s"`, id.String())
+	positions, err := c.queryPositions(ctx, url, version, where)
+	if err != nil {
+		return nil, err
+	}
+	if len(positions) == 0 {
+		return nil, ErrPositionNotFound
+	}
+	return &positions[0], nil
+}
+
+// queryPositions runs a positions query against the subgraph at url, filtered by the given where clause.
+func (c *APIClient) queryPositions(ctx context.Context, url string, version PositionVersion, where string) ([]Position, error) {
 	var query string
 
 	if version == VersionV3 {
 		query = fmt.Sprintf(`{
-			positions(where: { owner: "%s" }) {
+			positions(where: { %s }) {
 				id
 				owner
 				depositedToken0
@@ -169,9 +291,10 @@ func (c *APIClient) getVersionPositions(ctx context.Context, wallet common.Addre
 				tickLower
 				tickUpper
 				pool {
+					id
 					feeTier
-					token0Price
-					token1Price
+					tick
+					sqrtPrice
 				}
 				token0 {
 					id
@@ -184,15 +307,16 @@ func (c *APIClient) getVersionPositions(ctx context.Context, wallet common.Addre
 					decimals
 				}
 			}
-		}`, strings.ToLower(wallet.Hex()))
+		}`, where)
 	} else if version == VersionV4 {
 		// V4 has a different schema, use appropriate fields
 		query = fmt.Sprintf(`{
-			positions(where: { owner: "%s" }) {
+			positions(where: { %s }) {
 				id
 				owner
 				createdAtTimestamp
 				pool {
+					id
 					token0 {
 						id
 						symbol
@@ -209,7 +333,7 @@ func (c *APIClient) getVersionPositions(ctx context.Context, wallet common.Addre
 					feeTier
 				}
 			}
-		}`, strings.ToLower(wallet.Hex()))
+		}`, where)
 	}
 	resp, err := c.executeGraphQLQuery(ctx, url, query)
 	if err != nil {
@@ -224,6 +348,7 @@ func (c *APIClient) getVersionPositions(ctx context.Context, wallet common.Addre
 			return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 		}
 		positions := c.parsePositionData(&graphResp.Data, version)
+		c.tokens.resolvePositions(ctx, positions)
 		return positions, nil
 	} else {
 		var graphResp struct {
@@ -233,11 +358,28 @@ func (c *APIClient) getVersionPositions(ctx context.Context, wallet common.Addre
 			return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 		}
 		positions := c.parseV4PositionData(&graphResp.Data)
+		c.tokens.resolvePositions(ctx, positions)
 		return positions, nil
 	}
 }
 
 func (c *APIClient) executeGraphQLQuery(ctx context.Context, url, query string) ([]byte, error) {
+	subgraph := c.subgraphName(url)
+	ctx, span := tracer.Start(ctx, "graphql "+subgraph, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	resp, err := c.doGraphQLQuery(ctx, url, query)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if c.responses != nil && ctx.Err() == nil {
+		c.responses(subgraph, err)
+	}
+	return resp, err
+}
+
+func (c *APIClient) doGraphQLQuery(ctx context.Context, url, query string) ([]byte, error) {
 	body, err := json.Marshal(map[string]string{
 		"query": query,
 	})
@@ -252,17 +394,24 @@ func (c *APIClient) executeGraphQLQuery(ctx context.Context, url, query string)
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if id := CorrelationID(ctx); id != "" {
+		req.Header.Set(CorrelationIDHeader, id)
+	}
 
 	obfuscatedKey := c.apiKey
 	if len(obfuscatedKey) > 4 {
 		obfuscatedKey = strings.Repeat("*", len(obfuscatedKey)-4) + obfuscatedKey[len(obfuscatedKey)-4:]
 	}
 
-	c.logger.Debugw("Making GraphQL request",
+	LoggerWithCorrelationID(ctx, c.logger).Debugw("Making GraphQL request",
 		"url", url,
 		"apiKey", obfuscatedKey,
 		"bodyLength", len(body))
 
+	if c.observer != nil {
+		c.observer(c.subgraphName(url))
+	}
+
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, err
@@ -274,12 +423,12 @@ func (c *APIClient) executeGraphQLQuery(ctx context.Context, url, query string)
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	c.logger.Debugw("Got GraphQL response",
+	LoggerWithCorrelationID(ctx, c.logger).Debugw("Got GraphQL response",
 		"statusCode", resp.StatusCode,
 		"contentLength", len(respBody))
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(respBody)}
 	}
 
 	// Check for GraphQL errors
@@ -289,10 +438,10 @@ func (c *APIClient) executeGraphQLQuery(ctx context.Context, url, query string)
 	}
 
 	if len(graphQLResp.Errors) > 0 {
-		c.logger.Errorw("GraphQL query returned errors",
+		LoggerWithCorrelationID(ctx, c.logger).Errorw("GraphQL query returned errors",
 			"errors", graphQLResp.Errors,
 			"query", query)
-		return nil, fmt.Errorf("GraphQL errors: %v", graphQLResp.Errors)
+		return nil, &QueryError{Errors: graphQLResp.Errors}
 	}
 
 	return respBody, nil
@@ -300,6 +449,7 @@ func (c *APIClient) executeGraphQLQuery(ctx context.Context, url, query string)
 
 func (c *APIClient) parsePositionData(data *PositionData, version PositionVersion) []Position {
 	var positions []Position
+	chainID := c.chainID()
 	for _, p := range data.Positions {
 		token0Decimals, _ := strconv.ParseUint(p.Token0.Decimals, 10, 8)
 		token1Decimals, _ := strconv.ParseUint(p.Token1.Decimals, 10, 8)
@@ -307,6 +457,12 @@ func (c *APIClient) parsePositionData(data *PositionData, version PositionVersio
 
 		tickLower, _ := strconv.ParseInt(p.TickLower, 10, 64)
 		tickUpper, _ := strconv.ParseInt(p.TickUpper, 10, 64)
+		// A range the pool couldn't hold means the subgraph's data is off, e.g. mid-resync
+		if spacing, ok := TickSpacing(uint32(feeTier)); ok {
+			if err := ValidateTickRange(int(tickLower), int(tickUpper), spacing); err != nil {
+				c.logger.Warnw("Subgraph returned an invalid position range", "position_id", p.ID, "error", err)
+			}
+		}
 
 		// Calculate amounts
 		depositedToken0 := stringToBigInt(p.DepositedToken0)
@@ -318,32 +474,39 @@ func (c *APIClient) parsePositionData(data *PositionData, version PositionVersio
 
 		pos := Position{
 			ID:      stringToBigInt(p.ID),
+			ChainID: chainID,
 			Version: version,
 			Owner:   common.HexToAddress(p.Owner),
 			Token0: Token{
 				Address:  common.HexToAddress(p.Token0.ID),
 				Symbol:   p.Token0.Symbol,
 				Decimals: uint8(token0Decimals),
+				ChainID:  chainID,
 			},
 			Token1: Token{
 				Address:  common.HexToAddress(p.Token1.ID),
 				Symbol:   p.Token1.Symbol,
 				Decimals: uint8(token1Decimals),
+				ChainID:  chainID,
 			},
 			Amount0:         amount0,
 			Amount1:         amount1,
 			DepositedToken0: stringToBigInt(p.DepositedToken0),
 			DepositedToken1: stringToBigInt(p.DepositedToken1),
+			WithdrawnToken0: withdrawnToken0,
+			WithdrawnToken1: withdrawnToken1,
 			UnclaimedFees0:  stringToBigInt(p.CollectedFeesToken0),
 			UnclaimedFees1:  stringToBigInt(p.CollectedFeesToken1),
 			FeeTier:         uint32(feeTier),
+			PoolAddress:     common.HexToAddress(p.Pool.ID),
 			CreatedAt:       time.Now(),
 			TickLower:       int(tickLower),
 			TickUpper:       int(tickUpper),
 			Liquidity:       stringToBigInt(p.Liquidity),
-			CurrentPrice:    stringToBigFloat(p.Pool.Token0Price),
-			PriceLower:      tickToPrice(tickLower),
-			PriceUpper:      tickToPrice(tickUpper),
+			CurrentTick:     parseTick(p.Pool.Tick),
+			CurrentPrice:    sqrtPriceX96Price(p.Pool.SqrtPrice, uint8(token0Decimals), uint8(token1Decimals)),
+			PriceLower:      tickPrice(int(tickLower), uint8(token0Decimals), uint8(token1Decimals)),
+			PriceUpper:      tickPrice(int(tickUpper), uint8(token0Decimals), uint8(token1Decimals)),
 		}
 		positions = append(positions, pos)
 	}
@@ -353,6 +516,7 @@ func (c *APIClient) parsePositionData(data *PositionData, version PositionVersio
 // parseV4PositionData parses V4 position data from the API response
 func (c *APIClient) parseV4PositionData(data *V4PositionData) []Position {
 	var positions []Position
+	chainID := c.chainID()
 	for _, p := range data.Positions {
 		// Parse token decimals
 		token0Decimals, _ := strconv.ParseUint(p.Pool.Token0.Decimals, 10, 8)
@@ -369,8 +533,12 @@ func (c *APIClient) parseV4PositionData(data *V4PositionData) []Position {
 		tickLower, _ := strconv.ParseInt(p.TickLower, 10, 64)
 		tickUpper, _ := strconv.ParseInt(p.TickUpper, 10, 64)
 
-		// Parse liquidity, deposited, withdrawn, and collected tokens
-		liquidity := stringToBigInt(p.Liquidity)
+		// Parse liquidity, deposited, withdrawn, and collected tokens. The liquidity stays
+		// unknown rather than zero if the subgraph doesn't tell, so the position isn't closed.
+		var liquidity *big.Int
+		if p.Liquidity != "" {
+			liquidity = stringToBigInt(p.Liquidity)
+		}
 		depositedToken0 := stringToBigInt(p.DepositedToken0)
 		depositedToken1 := stringToBigInt(p.DepositedToken1)
 		withdrawnToken0 := stringToBigInt(p.WithdrawnToken0)
@@ -383,28 +551,33 @@ func (c *APIClient) parseV4PositionData(data *V4PositionData) []Position {
 
 		pos := Position{
 			ID:      stringToBigInt(p.ID),
+			ChainID: chainID,
 			Version: VersionV4,
 			Owner:   common.HexToAddress(p.Owner),
 			Token0: Token{
 				Address:  common.HexToAddress(p.Pool.Token0.ID),
 				Symbol:   p.Pool.Token0.Symbol,
 				Decimals: uint8(token0Decimals),
+				ChainID:  chainID,
 			},
 			Token1: Token{
 				Address:  common.HexToAddress(p.Pool.Token1.ID),
 				Symbol:   p.Pool.Token1.Symbol,
 				Decimals: uint8(token1Decimals),
+				ChainID:  chainID,
 			},
 			Amount0:         amount0,
 			Amount1:         amount1,
 			UnclaimedFees0:  collectedToken0,
 			UnclaimedFees1:  collectedToken1,
 			FeeTier:         uint32(feeTier),
+			PoolID:          common.HexToHash(p.Pool.ID),
 			CreatedAt:       time.Unix(timestamp, 0),
 			TickLower:       int(tickLower),
 			TickUpper:       int(tickUpper),
 			Liquidity:       liquidity,
-			CurrentPrice:    calculateCurrentPrice(p.Pool.SqrtPrice),
+			CurrentTick:     parseTick(p.Pool.Tick),
+			CurrentPrice:    sqrtPriceX96Price(p.Pool.SqrtPrice, uint8(token0Decimals), uint8(token1Decimals)),
 			DepositedToken0: depositedToken0,
 			DepositedToken1: depositedToken1,
 			WithdrawnToken0: withdrawnToken0,
@@ -433,14 +606,11 @@ func stringToBigFloat(s string) *big.Float {
 	return f
 }
 
-func tickToPrice(tick int64) *big.Float {
-	price := big.NewFloat(1.0001)
-	return price.SetMantExp(price, int(tick))
-}
-
-func calculateCurrentPrice(priceStr string) *big.Float {
-	price := new(big.Float)
-	price.SetPrec(256)
-	price.SetString(priceStr)
-	return price
+// parseTick parses a tick from the subgraph, nil if it has none, as pools without liquidity yet
+func parseTick(s string) *int {
+	tick, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return &tick
 }
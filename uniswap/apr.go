@@ -0,0 +1,47 @@
+package uniswap
+
+import (
+	"math"
+)
+
+// daysPerYear annualizes daily returns
+const daysPerYear = 365
+
+// PoolAPR is the yearly return a pool's liquidity earns in fees at the rate of the last 24 hours
+type PoolAPR struct {
+	// FeesUSD24h is what swaps in the pool paid in fees over the last 24 hours
+	FeesUSD24h float64 `json:"feesUSD24h"`
+	TVLUSD     float64 `json:"tvlUSD"`
+	// APR is FeesUSD24h over TVLUSD, annualized without compounding, as a fraction: 0.12 is 12%.
+	// It is 0 for pools without TVL.
+	APR float64 `json:"apr"`
+}
+
+// NewPoolAPR computes the APR of a pool that earned feesUSD24h in fees over the last 24 hours with
+// tvlUSD locked in it
+func NewPoolAPR(feesUSD24h, tvlUSD float64) PoolAPR {
+	apr := PoolAPR{FeesUSD24h: feesUSD24h, TVLUSD: tvlUSD}
+	if tvlUSD > 0 {
+		apr.APR = feesUSD24h / tvlUSD * daysPerYear
+	}
+	return apr
+}
+
+// InRangeAPR estimates the APR of a position from tickLower to tickUpper while the pool's price is
+// within its range. Fees are shared by liquidity rather than by value, and a dollar in a narrow
+// range is more liquidity than a dollar spread over all prices: 1 / (1 - 1.0001^((tickLower -
+// tickUpper) / 4)) times as much, for a range centered on the price. The estimate takes the APR as
+// that of liquidity spread over all prices, so it is high for pools whose liquidity is concentrated
+// already, and the position earns nothing while out of range.
+func (a PoolAPR) InRangeAPR(tickLower, tickUpper int) float64 {
+	return a.APR * ConcentrationFactor(tickLower, tickUpper)
+}
+
+// ConcentrationFactor returns how many times the liquidity of a dollar spread over all prices a
+// dollar in the range from tickLower to tickUpper is, 1 for a full range position
+func ConcentrationFactor(tickLower, tickUpper int) float64 {
+	if tickUpper <= tickLower {
+		return 1
+	}
+	return max(1, 1/(1-math.Pow(1.0001, float64(tickLower-tickUpper)/4)))
+}
@@ -0,0 +1,101 @@
+package uniswap
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ChainID identifies an EVM chain by its EIP-155 chain ID, e.g. 1 for Ethereum. The zero
+// ChainID is an unknown chain.
+type ChainID uint64
+
+// ChainIDEthereum is the chain ID of Ethereum mainnet
+const ChainIDEthereum ChainID = 1
+
+// chainInfo is what is known about a chain beyond its ID
+type chainInfo struct {
+	name string
+	// explorer is the URL of the chain's block explorer
+	explorer string
+}
+
+// knownChains are the chains ChainID can name and link to a block explorer
+var knownChains = map[ChainID]chainInfo{
+	ChainIDEthereum: {name: ChainEthereum, explorer: "https://etherscan.io"},
+}
+
+// ChainIDByName returns the ID of the chain named name, e.g. ChainEthereum
+func ChainIDByName(name string) (ChainID, bool) {
+	for id, info := range knownChains {
+		if info.name == name {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// String returns the chain's name, e.g. "ethereum", or its ID if the chain isn't known
+func (id ChainID) String() string {
+	if info, ok := knownChains[id]; ok {
+		return info.name
+	}
+	return fmt.Sprintf("chain %d", uint64(id))
+}
+
+// TxURL returns the page of a transaction on the chain's block explorer, empty if the chain
+// isn't known
+func (id ChainID) TxURL(hash common.Hash) string {
+	if info, ok := knownChains[id]; ok {
+		return info.explorer + "/tx/" + hash.Hex()
+	}
+	return ""
+}
+
+// Chain is the configuration of the chain positions are fetched on
+type Chain struct {
+	// ID is set from Name by SetChain
+	ID   ChainID
+	Name string
+	// SubgraphV3 and SubgraphV4 are the IDs of the Uniswap V3 and V4 subgraphs indexing the chain
+	SubgraphV3 string
+	SubgraphV4 string
+}
+
+// DefaultChain is the chain the client queries until told otherwise with SetChain
+var DefaultChain = Chain{
+	ID:         ChainIDEthereum,
+	Name:       ChainEthereum,
+	SubgraphV3: "5zvR82QoaXYFyDEKLZ9t6v9adgnptxYpKpSbxtgVENFV",
+	SubgraphV4: "DiYPVdygkfjDWhbxGSqAQxwBKmfKnkWQojqeM2rkLb3G",
+}
+
+// SetChain makes the client query the subgraphs of chain from now on, e.g. to move to a new
+// deployment of a subgraph. Positions are only fetched on Ethereum so far.
+func (c *APIClient) SetChain(chain Chain) error {
+	if chain.Name != ChainEthereum {
+		return fmt.Errorf("%w %q, positions are only fetched on %s", ErrUnsupportedChain, chain.Name, ChainEthereum)
+	}
+	if chain.SubgraphV3 == "" || chain.SubgraphV4 == "" {
+		return errors.New("both the V3 and the V4 subgraph are needed")
+	}
+	chain.ID, _ = ChainIDByName(chain.Name)
+	c.chain.Store(&chain)
+	return nil
+}
+
+// chainID returns the ID of the chain the client queries
+func (c *APIClient) chainID() ChainID {
+	return c.chain.Load().ID
+}
+
+// subgraphURL returns the query URL of the subgraph of version, which is V3 or V4
+func (c *APIClient) subgraphURL(version PositionVersion) string {
+	chain := c.chain.Load()
+	id := chain.SubgraphV3
+	if version == VersionV4 {
+		id = chain.SubgraphV4
+	}
+	return fmt.Sprintf(subgraphURLFormat, c.apiKey, id)
+}
@@ -2,26 +2,29 @@ package uniswap
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 )
 
+// ErrPositionNotFound is returned when a position with the requested ID does not exist
+var ErrPositionNotFound = errors.New("position not found")
+
 // Client is the interface for interacting with Uniswap
 type Client interface {
-	// GetPositions fetches all positions for a given wallet address
+	// GetPositions fetches the positions of a wallet that req asks for, see PositionRequest.Apply.
+	// It returns ErrWalletHasNoPositions if the wallet has none of them.
 	GetPositions(ctx context.Context, req PositionRequest) ([]Position, error)
 
+	// GetPosition fetches a single position by its NFT token ID
+	GetPosition(ctx context.Context, version PositionVersion, id *big.Int) (*Position, error)
+
 	// Close closes the client and releases any resources
 	Close()
 }
 
 // FormatPositionSummary formats a position into a human-readable summary
 func FormatPositionSummary(position Position) PositionSummary {
-	inRange := false
-	if position.CurrentPrice != nil && position.PriceLower != nil && position.PriceUpper != nil {
-		inRange = position.CurrentPrice.Cmp(position.PriceLower) >= 0 && position.CurrentPrice.Cmp(position.PriceUpper) <= 0
-	}
-
 	return PositionSummary{
 		ID:            position.ID.String(),
 		Version:       string(position.Version),
@@ -30,10 +33,17 @@ func FormatPositionSummary(position Position) PositionSummary {
 		PriceRange:    fmt.Sprintf("%s - %s", formatBigFloat(position.PriceLower), formatBigFloat(position.PriceUpper)),
 		UnclaimedFees: fmt.Sprintf("%s %s, %s %s", formatBigInt(position.UnclaimedFees0, int(position.Token0.Decimals)), position.Token0.Symbol, formatBigInt(position.UnclaimedFees1, int(position.Token1.Decimals)), position.Token1.Symbol),
 		CreatedAt:     position.CreatedAt.Format("2006-01-02 15:04:05"),
-		InRange:       inRange,
+		InRange:       position.InRange(),
+		Token0LogoURI: position.Token0.LogoURI,
+		Token1LogoURI: position.Token1.LogoURI,
 	}
 }
 
+// FormatTokenAmount formats a raw token amount adjusted by the token's decimals, e.g. "1.5 WETH"
+func FormatTokenAmount(amount *big.Int, token Token) string {
+	return fmt.Sprintf("%s %s", formatBigInt(amount, int(token.Decimals)), token.Symbol)
+}
+
 // Helper functions for formatting big numbers
 func formatBigInt(n *big.Int, decimals int) string {
 	if n == nil {
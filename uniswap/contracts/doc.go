@@ -0,0 +1,11 @@
+// Package contracts holds Go bindings of the Uniswap V3 contracts and the token standards the bot
+// reads on-chain, generated by abigen from the ABIs in abi/. The ABIs only have the views and
+// events the bot may read, not the methods that send transactions. Regenerate the bindings with
+// go generate after changing them.
+package contracts
+
+//go:generate go run github.com/ethereum/go-ethereum/cmd/abigen@v1.13.14 --abi abi/NonfungiblePositionManager.json --pkg contracts --type NonfungiblePositionManager --out nonfungible_position_manager.go
+//go:generate go run github.com/ethereum/go-ethereum/cmd/abigen@v1.13.14 --abi abi/UniswapV3Factory.json --pkg contracts --type UniswapV3Factory --out uniswap_v3_factory.go
+//go:generate go run github.com/ethereum/go-ethereum/cmd/abigen@v1.13.14 --abi abi/UniswapV3Pool.json --pkg contracts --type UniswapV3Pool --out uniswap_v3_pool.go
+//go:generate go run github.com/ethereum/go-ethereum/cmd/abigen@v1.13.14 --abi abi/ERC20.json --pkg contracts --type ERC20 --out erc20.go
+//go:generate go run github.com/ethereum/go-ethereum/cmd/abigen@v1.13.14 --abi abi/ERC721.json --pkg contracts --type ERC721 --out erc721.go
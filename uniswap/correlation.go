@@ -0,0 +1,41 @@
+package uniswap
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"go.uber.org/zap"
+)
+
+// CorrelationIDHeader carries the correlation ID of the work a request to The Graph is made for
+const CorrelationIDHeader = "X-Correlation-ID"
+
+type correlationIDKey struct{}
+
+// NewCorrelationID returns a random ID to correlate the log lines and requests of one piece of
+// work, such as handling an update
+func NewCorrelationID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// WithCorrelationID returns a context carrying the correlation ID id
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the correlation ID ctx carries, or "" if it carries none
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// LoggerWithCorrelationID returns logger annotated with the correlation ID ctx carries, if any
+func LoggerWithCorrelationID(ctx context.Context, logger *zap.SugaredLogger) *zap.SugaredLogger {
+	if id := CorrelationID(ctx); id != "" {
+		return logger.With("correlation_id", id)
+	}
+	return logger
+}
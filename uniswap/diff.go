@@ -0,0 +1,94 @@
+package uniswap
+
+import (
+	"math/big"
+	"strconv"
+)
+
+// PositionDiff describes how a set of positions changed between two snapshots
+type PositionDiff struct {
+	// Opened are positions present in the current snapshot but not in the previous one
+	Opened []Position
+	// Closed are positions whose liquidity dropped to zero since the previous snapshot
+	Closed []Position
+	// Removed are positions that disappeared from the snapshot because the NFT was burned or
+	// transferred away. They carry their last known state.
+	Removed []Position
+	// Collected are fee collections observed since the previous snapshot
+	Collected []FeeCollection
+}
+
+// FeeCollection is an increase of a position's collected fees between two snapshots
+type FeeCollection struct {
+	Position Position
+	Amount0  *big.Int
+	Amount1  *big.Int
+}
+
+// DiffPositions compares two snapshots of the same wallet's positions
+func DiffPositions(previous, current []Position) PositionDiff {
+	prev := make(map[PositionIdentity]Position, len(previous))
+	for _, p := range previous {
+		prev[p.Identity()] = p
+	}
+
+	var diff PositionDiff
+	for _, p := range current {
+		key := p.Identity()
+		old, seen := prev[key]
+		delete(prev, key)
+
+		// Most positions don't change between two checks
+		if seen && old.ContentHash() == p.ContentHash() {
+			continue
+		}
+
+		switch {
+		case !seen:
+			diff.Opened = append(diff.Opened, p)
+		case HasLiquidity(old) && !HasLiquidity(p):
+			diff.Closed = append(diff.Closed, p)
+		}
+
+		if seen {
+			amount0 := positiveDelta(old.UnclaimedFees0, p.UnclaimedFees0)
+			amount1 := positiveDelta(old.UnclaimedFees1, p.UnclaimedFees1)
+			if amount0.Sign() > 0 || amount1.Sign() > 0 {
+				diff.Collected = append(diff.Collected, FeeCollection{Position: p, Amount0: amount0, Amount1: amount1})
+			}
+		}
+	}
+
+	// Whatever is left wasn't in the current snapshot anymore
+	for _, p := range previous {
+		if _, gone := prev[p.Identity()]; gone {
+			diff.Removed = append(diff.Removed, p)
+		}
+	}
+	return diff
+}
+
+// positiveDelta returns current - previous, or zero if it didn't grow
+func positiveDelta(previous, current *big.Int) *big.Int {
+	if previous == nil || current == nil || current.Cmp(previous) <= 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Sub(current, previous)
+}
+
+// HasLiquidity reports whether a position still holds any liquidity
+func HasLiquidity(p Position) bool {
+	return p.Liquidity != nil && p.Liquidity.Sign() > 0
+}
+
+// IsClosed reports whether a position is known to hold no liquidity anymore. Positions whose
+// liquidity the data source doesn't report aren't closed, though they don't have liquidity
+// either as far as HasLiquidity can tell.
+func IsClosed(p Position) bool {
+	return p.Liquidity != nil && p.Liquidity.Sign() == 0
+}
+
+// FormatFeeTier formats a fee tier in hundredths of a basis point as a percentage, e.g. 500 as 0.05%
+func FormatFeeTier(feeTier uint32) string {
+	return strconv.FormatFloat(float64(feeTier)/10000, 'f', -1, 64) + "%"
+}
@@ -0,0 +1,64 @@
+package uniswap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ENSSubgraphURL is the endpoint of the ENS subgraph on The Graph, queried with the same API key as Uniswap
+const ENSSubgraphURL = "https://gateway.thegraph.com/api/%s/subgraphs/id/5XqPmWe6gjyrJtFn9cLy237i4cWw2j9HcUJEXsP5qGtH"
+
+// ErrNameNotFound is returned when an ENS name doesn't exist or doesn't resolve to an address
+var ErrNameNotFound = errors.New("ENS name not found")
+
+// ENSResolver is implemented by clients that can resolve ENS names to addresses
+type ENSResolver interface {
+	// ResolveENS returns the address an ENS name (e.g. vitalik.eth) resolves to
+	ResolveENS(ctx context.Context, name string) (common.Address, error)
+}
+
+// IsENSName reports whether s looks like an ENS name rather than a hex address
+func IsENSName(s string) bool {
+	return strings.HasSuffix(strings.ToLower(s), ".eth") && len(s) > len(".eth")
+}
+
+// ResolveENS resolves an ENS name using the ENS subgraph
+func (c *APIClient) ResolveENS(ctx context.Context, name string) (common.Address, error) {
+	query := fmt.Sprintf(`{
+		domains(where: { name: %q }) {
+			resolvedAddress {
+				id
+			}
+		}
+	}`, strings.ToLower(name))
+
+	resp, err := c.executeGraphQLQuery(ctx, fmt.Sprintf(ENSSubgraphURL, c.apiKey), query)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to execute GraphQL query: %w", err)
+	}
+
+	var graphResp struct {
+		Data struct {
+			Domains []struct {
+				ResolvedAddress *struct {
+					ID string `json:"id"`
+				} `json:"resolvedAddress"`
+			} `json:"domains"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &graphResp); err != nil {
+		return common.Address{}, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	for _, domain := range graphResp.Data.Domains {
+		if domain.ResolvedAddress != nil && common.IsHexAddress(domain.ResolvedAddress.ID) {
+			return common.HexToAddress(domain.ResolvedAddress.ID), nil
+		}
+	}
+	return common.Address{}, ErrNameNotFound
+}
@@ -0,0 +1,78 @@
+package uniswap
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Errors the clients return, possibly wrapped, for callers to tell apart with errors.Is
+var (
+	// ErrRateLimited is returned when the data source refuses queries for a while because the
+	// bot made too many
+	ErrRateLimited = errors.New("rate limited by the data source")
+	// ErrSubgraphStale is returned when a subgraph can't answer because it is too far behind the
+	// chain, e.g. while it is being resynced
+	ErrSubgraphStale = errors.New("subgraph is behind the chain")
+	// ErrWalletHasNoPositions is returned by GetPositions when the wallet has no positions of
+	// the versions asked for, or none the request's options keep
+	ErrWalletHasNoPositions = errors.New("wallet has no positions")
+	// ErrPartialPositions is returned by GetPositions along with the positions of the versions it
+	// fetched when the other version's could not be, so callers that compare snapshots of a
+	// wallet don't take the missing positions for closed ones
+	ErrPartialPositions = errors.New("positions of some versions could not be fetched")
+	// ErrUnsupportedChain is returned when asked for a chain positions aren't fetched on
+	ErrUnsupportedChain = errors.New("unsupported chain")
+	// ErrInvalidTickRange is returned by ValidateTickRange for a range no pool could hold
+	ErrInvalidTickRange = errors.New("invalid tick range")
+)
+
+// StatusError is returned when The Graph or an Ethereum node answers a request with an HTTP
+// status other than 200 OK, e.g. 401 for an API key it doesn't accept. It is ErrRateLimited for
+// 429 Too Many Requests.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d, body: %s", e.StatusCode, e.Body)
+}
+
+func (e *StatusError) Is(target error) bool {
+	return target == ErrRateLimited && e.StatusCode == http.StatusTooManyRequests
+}
+
+// QueryError is returned when The Graph answers a query with GraphQL errors. It is
+// ErrSubgraphStale or ErrRateLimited if the errors say so.
+type QueryError struct {
+	Errors []GraphQLError
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("GraphQL errors: %v", e.Errors)
+}
+
+// staleMessages are parts of the errors the gateway and indexers answer with when no indexer is
+// close enough to the chain head
+var staleMessages = []string{"too far behind", "has only indexed up to", "not yet indexed", "indexing_error"}
+
+func (e *QueryError) Is(target error) bool {
+	for _, graphErr := range e.Errors {
+		message := strings.ToLower(graphErr.Message)
+		switch target {
+		case ErrRateLimited:
+			if strings.Contains(message, "rate limit") || strings.Contains(message, "too many requests") {
+				return true
+			}
+		case ErrSubgraphStale:
+			for _, stale := range staleMessages {
+				if strings.Contains(message, stale) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
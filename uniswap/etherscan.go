@@ -0,0 +1,101 @@
+package uniswap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// EtherscanAPIURL is the endpoint of Etherscan's API, which serves every chain it explores
+const EtherscanAPIURL = "https://api.etherscan.io/v2/api"
+
+// EtherscanVerifier asks Etherscan whether contracts are verified
+type EtherscanVerifier struct {
+	httpClient *http.Client
+	apiKey     string
+	chainID    ChainID
+}
+
+// NewEtherscanVerifier creates a verifier of contracts on the chain, authenticated with apiKey
+func NewEtherscanVerifier(apiKey string, chainID ChainID) *EtherscanVerifier {
+	verifier := &EtherscanVerifier{
+		httpClient: &http.Client{
+			Timeout:   DefaultQueryTimeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		apiKey:  apiKey,
+		chainID: chainID,
+	}
+	var _ ContractVerifier = verifier
+	return verifier
+}
+
+// IsVerified reports whether the contract's source code is verified on Etherscan. Addresses
+// without a contract aren't.
+func (v *EtherscanVerifier) IsVerified(ctx context.Context, address common.Address) (bool, error) {
+	query := url.Values{
+		"chainid": {strconv.FormatUint(uint64(v.chainID), 10)},
+		"module":  {"contract"},
+		"action":  {"getsourcecode"},
+		"address": {address.Hex()},
+		"apikey":  {v.apiKey},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", EtherscanAPIURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		// The error names the URL, which holds the API key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var etherscanResp struct {
+		Status  string          `json:"status"`
+		Message string          `json:"message"`
+		Result  json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &etherscanResp); err != nil {
+		return false, fmt.Errorf("failed to decode Etherscan response: %w", err)
+	}
+	if etherscanResp.Status != "1" {
+		// The result is an error message then, e.g. "Max rate limit reached"
+		var message string
+		_ = json.Unmarshal(etherscanResp.Result, &message)
+		if strings.Contains(strings.ToLower(message), "rate limit") {
+			return false, fmt.Errorf("%w: %s", ErrRateLimited, message)
+		}
+		return false, fmt.Errorf("etherscan: %s: %s", etherscanResp.Message, message)
+	}
+
+	var contracts []struct {
+		SourceCode string `json:"SourceCode"`
+	}
+	if err := json.Unmarshal(etherscanResp.Result, &contracts); err != nil {
+		return false, fmt.Errorf("failed to decode Etherscan result: %w", err)
+	}
+	return len(contracts) > 0 && contracts[0].SourceCode != "", nil
+}
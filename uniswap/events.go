@@ -0,0 +1,235 @@
+package uniswap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PositionEventType is what happened to a position
+type PositionEventType string
+
+const (
+	// EventMint is the position's creation, with its first deposit
+	EventMint PositionEventType = "mint"
+	// EventIncreaseLiquidity is a deposit into the position
+	EventIncreaseLiquidity PositionEventType = "increase_liquidity"
+	// EventDecreaseLiquidity is a withdrawal from the position
+	EventDecreaseLiquidity PositionEventType = "decrease_liquidity"
+	// EventCollect is a collection of the position's fees
+	EventCollect PositionEventType = "collect"
+	// EventTransfer is a change of the position NFT's owner
+	EventTransfer PositionEventType = "transfer"
+)
+
+// PositionEvent is something that happened to a position, as in the position manager's events
+type PositionEvent struct {
+	Type      PositionEventType
+	Timestamp time.Time
+	// TxHash is the transaction the event happened in, zero if the subgraph doesn't tell
+	TxHash common.Hash
+	// Amount0 and Amount1 are the tokens deposited, withdrawn or collected as fees, in token
+	// units already adjusted by decimals. They are nil for transfers, and for V4 mints whose
+	// deposit isn't known.
+	Amount0 *big.Float
+	Amount1 *big.Float
+	// From and To are the previous and the new owner of a transfer, To also the owner of a mint
+	From common.Address
+	To   common.Address
+}
+
+// PositionEventSource is implemented by clients that can list what happened to a position
+type PositionEventSource interface {
+	// GetPositionEvents returns the position's events, oldest first
+	GetPositionEvents(ctx context.Context, version PositionVersion, id *big.Int) ([]PositionEvent, error)
+}
+
+// GetPositionEvents derives a V3 position's events from the snapshots the subgraph records
+// whenever the position changes, and lists a V4 position's transfers. The V4 subgraph doesn't
+// link liquidity changes to positions, so V4 positions only have mint and transfer events.
+func (c *APIClient) GetPositionEvents(ctx context.Context, version PositionVersion, id *big.Int) ([]PositionEvent, error) {
+	switch version {
+	case VersionV3:
+		return c.getV3PositionEvents(ctx, id)
+	case VersionV4:
+		return c.getV4PositionEvents(ctx, id)
+	}
+	return nil, fmt.Errorf("unsupported version: %s", version)
+}
+
+func (c *APIClient) getV3PositionEvents(ctx context.Context, id *big.Int) ([]PositionEvent, error) {
+	query := fmt.Sprintf(`{
+		positionSnapshots(
+			first: 1000
+			orderBy: timestamp
+			orderDirection: asc
+			where: { position: "%s" }
+		) {
+			timestamp
+			owner
+			depositedToken0
+			depositedToken1
+			withdrawnToken0
+			withdrawnToken1
+			collectedFeesToken0
+			collectedFeesToken1
+			transaction {
+				id
+			}
+		}
+	}`, id.String())
+
+	resp, err := c.executeGraphQLQuery(ctx, c.subgraphURL(VersionV3), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute GraphQL query: %w", err)
+	}
+
+	var graphResp struct {
+		Data struct {
+			PositionSnapshots []struct {
+				Timestamp           string `json:"timestamp"`
+				Owner               string `json:"owner"`
+				DepositedToken0     string `json:"depositedToken0"`
+				DepositedToken1     string `json:"depositedToken1"`
+				WithdrawnToken0     string `json:"withdrawnToken0"`
+				WithdrawnToken1     string `json:"withdrawnToken1"`
+				CollectedFeesToken0 string `json:"collectedFeesToken0"`
+				CollectedFeesToken1 string `json:"collectedFeesToken1"`
+				Transaction         struct {
+					ID string `json:"id"`
+				} `json:"transaction"`
+			} `json:"positionSnapshots"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &graphResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	snapshots := make([]positionEventSnapshot, 0, len(graphResp.Data.PositionSnapshots))
+	for _, s := range graphResp.Data.PositionSnapshots {
+		timestamp, _ := strconv.ParseInt(s.Timestamp, 10, 64)
+		snapshots = append(snapshots, positionEventSnapshot{
+			timestamp: time.Unix(timestamp, 0),
+			txHash:    common.HexToHash(s.Transaction.ID),
+			owner:     common.HexToAddress(s.Owner),
+			deposited: [2]*big.Float{stringToBigFloat(s.DepositedToken0), stringToBigFloat(s.DepositedToken1)},
+			withdrawn: [2]*big.Float{stringToBigFloat(s.WithdrawnToken0), stringToBigFloat(s.WithdrawnToken1)},
+			collected: [2]*big.Float{stringToBigFloat(s.CollectedFeesToken0), stringToBigFloat(s.CollectedFeesToken1)},
+		})
+	}
+	return snapshotEvents(snapshots), nil
+}
+
+// positionEventSnapshot is the state of a position after a change, with cumulative amounts
+type positionEventSnapshot struct {
+	timestamp time.Time
+	txHash    common.Hash
+	owner     common.Address
+	deposited [2]*big.Float
+	withdrawn [2]*big.Float
+	collected [2]*big.Float
+}
+
+// snapshotEvents turns consecutive snapshots of a position into the events between them. The
+// subgraph records one snapshot per block, so a transaction that e.g. withdraws and collects at
+// once leaves one snapshot, which yields both events.
+func snapshotEvents(snapshots []positionEventSnapshot) []PositionEvent {
+	var events []PositionEvent
+	for i, s := range snapshots {
+		event := func(eventType PositionEventType, amounts [2]*big.Float) PositionEvent {
+			return PositionEvent{Type: eventType, Timestamp: s.timestamp, TxHash: s.txHash, Amount0: amounts[0], Amount1: amounts[1]}
+		}
+
+		if i == 0 {
+			mint := event(EventMint, s.deposited)
+			mint.To = s.owner
+			events = append(events, mint)
+			continue
+		}
+
+		prev := snapshots[i-1]
+		if deposited, ok := snapshotDelta(prev.deposited, s.deposited); ok {
+			events = append(events, event(EventIncreaseLiquidity, deposited))
+		}
+		if withdrawn, ok := snapshotDelta(prev.withdrawn, s.withdrawn); ok {
+			events = append(events, event(EventDecreaseLiquidity, withdrawn))
+		}
+		if collected, ok := snapshotDelta(prev.collected, s.collected); ok {
+			events = append(events, event(EventCollect, collected))
+		}
+		if s.owner != prev.owner {
+			events = append(events, PositionEvent{Type: EventTransfer, Timestamp: s.timestamp, TxHash: s.txHash, From: prev.owner, To: s.owner})
+		}
+	}
+	return events
+}
+
+// snapshotDelta returns how much cumulative token amounts grew, and false if neither did
+func snapshotDelta(previous, current [2]*big.Float) ([2]*big.Float, bool) {
+	var delta [2]*big.Float
+	grew := false
+	for i := range delta {
+		delta[i] = new(big.Float).Sub(current[i], previous[i])
+		if delta[i].Sign() > 0 {
+			grew = true
+		} else {
+			delta[i].SetInt64(0)
+		}
+	}
+	return delta, grew
+}
+
+func (c *APIClient) getV4PositionEvents(ctx context.Context, id *big.Int) ([]PositionEvent, error) {
+	query := fmt.Sprintf(`{
+		transfers(
+			first: 1000
+			orderBy: timestamp
+			orderDirection: asc
+			where: { tokenId: "%s" }
+		) {
+			timestamp
+			from
+			to
+		}
+	}`, id.String())
+
+	resp, err := c.executeGraphQLQuery(ctx, c.subgraphURL(VersionV4), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute GraphQL query: %w", err)
+	}
+
+	var graphResp struct {
+		Data struct {
+			Transfers []struct {
+				Timestamp string `json:"timestamp"`
+				From      string `json:"from"`
+				To        string `json:"to"`
+			} `json:"transfers"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &graphResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	events := make([]PositionEvent, 0, len(graphResp.Data.Transfers))
+	for _, t := range graphResp.Data.Transfers {
+		timestamp, _ := strconv.ParseInt(t.Timestamp, 10, 64)
+		event := PositionEvent{
+			Type:      EventTransfer,
+			Timestamp: time.Unix(timestamp, 0),
+			From:      common.HexToAddress(t.From),
+			To:        common.HexToAddress(t.To),
+		}
+		// The NFT is minted to its first owner from the zero address
+		if event.From == (common.Address{}) {
+			event.Type = EventMint
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
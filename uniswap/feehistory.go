@@ -0,0 +1,86 @@
+package uniswap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// FeeSnapshot is the cumulative amount of fees a position had collected at a point in time
+type FeeSnapshot struct {
+	Timestamp time.Time
+	// CollectedFees0 and CollectedFees1 are in token units, already adjusted by decimals
+	CollectedFees0 *big.Float
+	CollectedFees1 *big.Float
+}
+
+// FeeHistorySource is implemented by clients that can list how a position's collected fees evolved
+type FeeHistorySource interface {
+	// GetFeeHistory returns the position's fee snapshots taken after since, oldest first. The
+	// last snapshot before since is included as the starting point if there is one.
+	GetFeeHistory(ctx context.Context, version PositionVersion, id *big.Int, since time.Time) ([]FeeSnapshot, error)
+}
+
+// GetFeeHistory queries the position snapshots the V3 subgraph records on every liquidity change and collect
+func (c *APIClient) GetFeeHistory(ctx context.Context, version PositionVersion, id *big.Int, since time.Time) ([]FeeSnapshot, error) {
+	if version != VersionV3 {
+		return nil, fmt.Errorf("fee history is not available for %s positions", version)
+	}
+
+	query := fmt.Sprintf(`{
+		before: positionSnapshots(
+			first: 1
+			orderBy: timestamp
+			orderDirection: desc
+			where: { position: "%s", timestamp_lte: "%d" }
+		) {
+			timestamp
+			collectedFeesToken0
+			collectedFeesToken1
+		}
+		after: positionSnapshots(
+			first: 1000
+			orderBy: timestamp
+			orderDirection: asc
+			where: { position: "%s", timestamp_gt: "%d" }
+		) {
+			timestamp
+			collectedFeesToken0
+			collectedFeesToken1
+		}
+	}`, id.String(), since.Unix(), id.String(), since.Unix())
+
+	resp, err := c.executeGraphQLQuery(ctx, c.subgraphURL(VersionV3), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute GraphQL query: %w", err)
+	}
+
+	type snapshot struct {
+		Timestamp           string `json:"timestamp"`
+		CollectedFeesToken0 string `json:"collectedFeesToken0"`
+		CollectedFeesToken1 string `json:"collectedFeesToken1"`
+	}
+	var graphResp struct {
+		Data struct {
+			Before []snapshot `json:"before"`
+			After  []snapshot `json:"after"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &graphResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	snapshots := make([]FeeSnapshot, 0, len(graphResp.Data.Before)+len(graphResp.Data.After))
+	for _, s := range append(graphResp.Data.Before, graphResp.Data.After...) {
+		timestamp, _ := strconv.ParseInt(s.Timestamp, 10, 64)
+		snapshots = append(snapshots, FeeSnapshot{
+			Timestamp:      time.Unix(timestamp, 0),
+			CollectedFees0: stringToBigFloat(s.CollectedFeesToken0),
+			CollectedFees1: stringToBigFloat(s.CollectedFeesToken1),
+		})
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,104 @@
+// Package filter selects Uniswap positions with predicates that can be combined, e.g.
+//
+//	filter.Apply(positions, filter.InRangeOnly(), filter.Any(filter.Token("WETH"), filter.Token("WBTC")))
+package filter
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+)
+
+// Filter reports whether a position is selected
+type Filter func(pos uniswap.Position) bool
+
+// Apply returns the positions all filters select, in their order. positions is left as is.
+func Apply(positions []uniswap.Position, filters ...Filter) []uniswap.Position {
+	match := All(filters...)
+	selected := make([]uniswap.Position, 0, len(positions))
+	for _, pos := range positions {
+		if match(pos) {
+			selected = append(selected, pos)
+		}
+	}
+	return selected
+}
+
+// All selects the positions every filter selects, all positions if there are no filters
+func All(filters ...Filter) Filter {
+	return func(pos uniswap.Position) bool {
+		for _, f := range filters {
+			if !f(pos) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any selects the positions at least one of filters selects, none if there are no filters
+func Any(filters ...Filter) Filter {
+	return func(pos uniswap.Position) bool {
+		for _, f := range filters {
+			if f(pos) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not selects the positions f doesn't
+func Not(f Filter) Filter {
+	return func(pos uniswap.Position) bool {
+		return !f(pos)
+	}
+}
+
+// InRangeOnly selects the positions whose range holds the pool's current price, the ones
+// earning fees
+func InRangeOnly() Filter {
+	return func(pos uniswap.Position) bool {
+		return pos.InRange()
+	}
+}
+
+// MinValueUSD selects the positions worth at least usd at the USD token prices given.
+// Positions whose tokens aren't both priced aren't selected.
+func MinValueUSD(usd float64, pricesUSD map[common.Address]float64) Filter {
+	return func(pos uniswap.Position) bool {
+		values, ok := uniswap.PositionValuesUSD(pos, pricesUSD)
+		return ok && values.Value >= usd
+	}
+}
+
+// Token selects the positions with a token given by its symbol, matched case-insensitively,
+// or by its address
+func Token(symbolOrAddress string) Filter {
+	if common.IsHexAddress(symbolOrAddress) {
+		address := common.HexToAddress(symbolOrAddress)
+		return func(pos uniswap.Position) bool {
+			return pos.Token0.Address == address || pos.Token1.Address == address
+		}
+	}
+	return func(pos uniswap.Position) bool {
+		return strings.EqualFold(pos.Token0.Symbol, symbolOrAddress) || strings.EqualFold(pos.Token1.Symbol, symbolOrAddress)
+	}
+}
+
+// Version selects the positions of a Uniswap version
+func Version(version uniswap.PositionVersion) Filter {
+	return func(pos uniswap.Position) bool {
+		return pos.Version == version
+	}
+}
+
+// Chain selects the positions on the chain named name, e.g. uniswap.ChainEthereum. It selects
+// none if the chain isn't known.
+func Chain(name string) Filter {
+	id, ok := uniswap.ChainIDByName(strings.ToLower(name))
+	return func(pos uniswap.Position) bool {
+		return ok && pos.Chain() == id
+	}
+}
@@ -0,0 +1,35 @@
+package uniswap
+
+import "fmt"
+
+// String formats the token as its symbol, or as its address if the symbol isn't known
+func (t Token) String() string {
+	if t.Symbol == "" {
+		return t.Address.Hex()
+	}
+	return t.Symbol
+}
+
+// Pair formats the position's tokens and fee tier, e.g. "WETH/USDC 0.05%"
+func (p Position) Pair() string {
+	return fmt.Sprintf("%s/%s %s", p.Token0, p.Token1, FormatFeeTier(p.FeeTier))
+}
+
+// String identifies the position for logs and messages, e.g. "WETH/USDC 0.05% V3 #123"
+func (p Position) String() string {
+	id := "?"
+	if p.ID != nil {
+		id = p.ID.String()
+	}
+	return fmt.Sprintf("%s %s #%s", p.Pair(), p.Version, id)
+}
+
+// FormatPositionCompact formats the position on one line, as the bot's compact display mode
+// shows it, e.g. "WETH/USDC 0.05% V3 #123, in range, fees 0.1 WETH, 250 USDC"
+func FormatPositionCompact(p Position) string {
+	status := "out of range"
+	if p.InRange() {
+		status = "in range"
+	}
+	return fmt.Sprintf("%s, %s, fees %s, %s", p, status, FormatTokenAmount(p.UnclaimedFees0, p.Token0), FormatTokenAmount(p.UnclaimedFees1, p.Token1))
+}
@@ -0,0 +1,46 @@
+package uniswap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// HealthChecker is implemented by clients that can check whether their data sources respond
+type HealthChecker interface {
+	// CheckHealth returns an error if a data source can't be queried
+	CheckHealth(ctx context.Context) error
+}
+
+// CheckHealth queries the latest indexed block of the Uniswap V3 and V4 subgraphs
+func (c *APIClient) CheckHealth(ctx context.Context) error {
+	for _, subgraph := range []struct {
+		name    string
+		version PositionVersion
+	}{
+		{"uniswap-v3", VersionV3},
+		{"uniswap-v4", VersionV4},
+	} {
+		resp, err := c.executeGraphQLQuery(ctx, c.subgraphURL(subgraph.version), `{ _meta { block { number } } }`)
+		if err != nil {
+			return fmt.Errorf("%s subgraph: %w", subgraph.name, err)
+		}
+
+		var graphResp struct {
+			Data struct {
+				Meta struct {
+					Block struct {
+						Number int64 `json:"number"`
+					} `json:"block"`
+				} `json:"_meta"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(resp, &graphResp); err != nil {
+			return fmt.Errorf("%s subgraph: failed to unmarshal response: %w", subgraph.name, err)
+		}
+		if graphResp.Data.Meta.Block.Number == 0 {
+			return fmt.Errorf("%s subgraph has not indexed any block", subgraph.name)
+		}
+	}
+	return nil
+}
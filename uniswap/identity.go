@@ -0,0 +1,71 @@
+package uniswap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// PositionIdentity identifies a position: its NFT ID is only unique among the positions of one
+// Uniswap version on one chain. It is comparable, so it can key maps.
+type PositionIdentity struct {
+	Chain   ChainID
+	Version PositionVersion
+	ID      string
+}
+
+// Identity returns the position's identity
+func (p Position) Identity() PositionIdentity {
+	id := ""
+	if p.ID != nil {
+		id = p.ID.String()
+	}
+	return PositionIdentity{Chain: p.Chain(), Version: p.Version, ID: id}
+}
+
+// String formats the identity as "<version>:<id>" for positions on Ethereum, e.g. "V3:12345",
+// and as "<chain ID>:<version>:<id>" on other chains, e.g. "8453:V3:12345". Positions on Ethereum
+// keep the keys they had before positions recorded their chain, so keys stored with alert rules
+// still match.
+func (i PositionIdentity) String() string {
+	if i.Chain == ChainIDEthereum {
+		return fmt.Sprintf("%s:%s", i.Version, i.ID)
+	}
+	return fmt.Sprintf("%d:%s:%s", i.Chain, i.Version, i.ID)
+}
+
+// PositionKey identifies a position uniquely across chains and Uniswap versions, see
+// PositionIdentity.String
+func PositionKey(p Position) string {
+	return p.Identity().String()
+}
+
+// ContentHash returns a hash of the position's liquidity, unclaimed fees and range, the state
+// DiffPositions reports changes of. It changes when any of them does, and only then: amounts and
+// prices move with the pool's price, so they aren't part of it.
+func (p Position) ContentHash() string {
+	h := sha256.New()
+	// Unknown amounts are written as <nil>, which no amount is
+	fmt.Fprintf(h, "%v|%v|%v|%d|%d", p.Liquidity, p.UnclaimedFees0, p.UnclaimedFees1, p.TickLower, p.TickUpper)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// PositionsChanged reports whether DiffPositions would find any changes between two snapshots of
+// the same wallet's positions: whether a position was opened or removed, or the content hash of
+// one changed
+func PositionsChanged(previous, current []Position) bool {
+	if len(previous) != len(current) {
+		return true
+	}
+	hashes := make(map[PositionIdentity]string, len(previous))
+	for _, p := range previous {
+		hashes[p.Identity()] = p.ContentHash()
+	}
+	for _, p := range current {
+		hash, ok := hashes[p.Identity()]
+		if !ok || hash != p.ContentHash() {
+			return true
+		}
+	}
+	return false
+}
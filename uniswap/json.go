@@ -0,0 +1,184 @@
+package uniswap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// positionAlias has the fields of Position without its JSON methods, so they can encode the
+// fields they don't change
+type positionAlias Position
+
+// positionJSON is the JSON encoding of a Position. It replaces the fields that encoding/json
+// would write as raw integers and floating point numbers with decimal strings.
+type positionJSON struct {
+	*positionAlias
+
+	ID        *string `json:"id"`
+	Amount0   *string `json:"amount0"`
+	Amount1   *string `json:"amount1"`
+	Liquidity *string `json:"liquidity,omitempty"`
+
+	UnclaimedFees0 *string `json:"unclaimedFees0"`
+	UnclaimedFees1 *string `json:"unclaimedFees1"`
+
+	PriceLower   *string `json:"priceLower"`
+	PriceUpper   *string `json:"priceUpper"`
+	CurrentPrice *string `json:"currentPrice"`
+
+	DepositedToken0 *string `json:"depositedToken0"`
+	DepositedToken1 *string `json:"depositedToken1"`
+	WithdrawnToken0 *string `json:"withdrawnToken0"`
+	WithdrawnToken1 *string `json:"withdrawnToken1"`
+}
+
+// MarshalJSON encodes the position with its token amounts as decimal strings adjusted by the
+// token's decimals, e.g. "1.5" for 1.5 WETH, its ID and liquidity as integer strings and its
+// prices as decimal strings, so consumers don't need to know about token decimals or read
+// integers too large for a float64. Missing values are null.
+func (p Position) MarshalJSON() ([]byte, error) {
+	token0 := func(n *big.Int) *string { return unitsString(n, p.Token0.Decimals) }
+	token1 := func(n *big.Int) *string { return unitsString(n, p.Token1.Decimals) }
+	return json.Marshal(positionJSON{
+		positionAlias: (*positionAlias)(&p),
+
+		ID:        unitsString(p.ID, 0),
+		Amount0:   token0(p.Amount0),
+		Amount1:   token1(p.Amount1),
+		Liquidity: unitsString(p.Liquidity, 0),
+
+		UnclaimedFees0: token0(p.UnclaimedFees0),
+		UnclaimedFees1: token1(p.UnclaimedFees1),
+
+		PriceLower:   priceString(p.PriceLower),
+		PriceUpper:   priceString(p.PriceUpper),
+		CurrentPrice: priceString(p.CurrentPrice),
+
+		DepositedToken0: token0(p.DepositedToken0),
+		DepositedToken1: token1(p.DepositedToken1),
+		WithdrawnToken0: token0(p.WithdrawnToken0),
+		WithdrawnToken1: token1(p.WithdrawnToken1),
+	})
+}
+
+// positionJSONInput is positionJSON as read. Integers may also be JSON numbers of raw token
+// amounts, as positions were encoded before MarshalJSON, so stored snapshots and fixtures written
+// then still read the same.
+type positionJSONInput struct {
+	*positionAlias
+
+	ID        json.RawMessage `json:"id"`
+	Amount0   json.RawMessage `json:"amount0"`
+	Amount1   json.RawMessage `json:"amount1"`
+	Liquidity json.RawMessage `json:"liquidity"`
+
+	UnclaimedFees0 json.RawMessage `json:"unclaimedFees0"`
+	UnclaimedFees1 json.RawMessage `json:"unclaimedFees1"`
+
+	DepositedToken0 json.RawMessage `json:"depositedToken0"`
+	DepositedToken1 json.RawMessage `json:"depositedToken1"`
+	WithdrawnToken0 json.RawMessage `json:"withdrawnToken0"`
+	WithdrawnToken1 json.RawMessage `json:"withdrawnToken1"`
+}
+
+// UnmarshalJSON decodes a position encoded by MarshalJSON, exactly: amounts are converted back
+// to raw amounts with the decimals of the tokens in the same JSON object
+func (p *Position) UnmarshalJSON(data []byte) error {
+	var decoded Position
+	in := positionJSONInput{positionAlias: (*positionAlias)(&decoded)}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	for _, field := range []struct {
+		name     string
+		raw      json.RawMessage
+		decimals uint8
+		dst      **big.Int
+	}{
+		{"id", in.ID, 0, &decoded.ID},
+		{"amount0", in.Amount0, decoded.Token0.Decimals, &decoded.Amount0},
+		{"amount1", in.Amount1, decoded.Token1.Decimals, &decoded.Amount1},
+		{"liquidity", in.Liquidity, 0, &decoded.Liquidity},
+		{"unclaimedFees0", in.UnclaimedFees0, decoded.Token0.Decimals, &decoded.UnclaimedFees0},
+		{"unclaimedFees1", in.UnclaimedFees1, decoded.Token1.Decimals, &decoded.UnclaimedFees1},
+		{"depositedToken0", in.DepositedToken0, decoded.Token0.Decimals, &decoded.DepositedToken0},
+		{"depositedToken1", in.DepositedToken1, decoded.Token1.Decimals, &decoded.DepositedToken1},
+		{"withdrawnToken0", in.WithdrawnToken0, decoded.Token0.Decimals, &decoded.WithdrawnToken0},
+		{"withdrawnToken1", in.WithdrawnToken1, decoded.Token1.Decimals, &decoded.WithdrawnToken1},
+	} {
+		n, err := parseUnitsJSON(field.raw, field.decimals)
+		if err != nil {
+			return fmt.Errorf("position %s: %w", field.name, err)
+		}
+		*field.dst = n
+	}
+	*p = decoded
+	return nil
+}
+
+// unitsString formats a raw amount as a decimal string adjusted by decimals, nil if n is
+func unitsString(n *big.Int, decimals uint8) *string {
+	if n == nil {
+		return nil
+	}
+	s := formatBigInt(n, int(decimals))
+	if n.Sign() < 0 {
+		s = "-" + formatBigInt(new(big.Int).Neg(n), int(decimals))
+	}
+	return &s
+}
+
+// priceString formats a price as a decimal string, nil if f is
+func priceString(f *big.Float) *string {
+	if f == nil {
+		return nil
+	}
+	s := f.Text('f', -1)
+	return &s
+}
+
+// parseUnitsJSON reads an amount written by unitsString, or a JSON number of a raw amount.
+// A missing amount or null is nil.
+func parseUnitsJSON(raw json.RawMessage, decimals uint8) (*big.Int, error) {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+	if raw[0] != '"' {
+		n, ok := new(big.Int).SetString(string(raw), 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid raw amount %s", raw)
+		}
+		return n, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	return parseUnits(s, decimals)
+}
+
+// parseUnits converts a decimal amount of a token with decimals to the raw amount, e.g. "1.5"
+// with 6 decimals to 1500000. It fails rather than round if s has more fractional digits.
+func parseUnits(s string, decimals uint8) (*big.Int, error) {
+	digits, negative := strings.CutPrefix(s, "-")
+	intPart, fracPart, _ := strings.Cut(digits, ".")
+	if intPart == "" {
+		intPart = "0"
+	}
+	if len(fracPart) > int(decimals) {
+		return nil, fmt.Errorf("amount %q has more than %d decimals", s, decimals)
+	}
+	fracPart += strings.Repeat("0", int(decimals)-len(fracPart))
+	n, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok || strings.ContainsAny(intPart+fracPart, "+-_") {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	if negative {
+		n.Neg(n)
+	}
+	return n, nil
+}
@@ -0,0 +1,33 @@
+package uniswap
+
+import "fmt"
+
+// uniswapAppURL is the Uniswap web app positions and pools are linked to
+const uniswapAppURL = "https://app.uniswap.org"
+
+// PositionURL returns the position's page in the Uniswap web app, where its owner can manage
+// it, e.g. "https://app.uniswap.org/positions/v3/ethereum/123"
+func PositionURL(p Position) string {
+	if p.ID == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/positions/%s/%s/%s", uniswapAppURL, versionPath(p.Version), p.Chain(), p.ID)
+}
+
+// PoolURL returns the page of the position's pool in the Uniswap web app, with its volume,
+// liquidity and latest swaps, or "" if the pool isn't known
+func PoolURL(p Position) string {
+	key := p.PoolKey()
+	if key == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/explore/pools/%s/%s", uniswapAppURL, p.Chain(), key)
+}
+
+// versionPath is the version as the web app's URLs have it, e.g. "v3"
+func versionPath(version PositionVersion) string {
+	if version == VersionV4 {
+		return "v4"
+	}
+	return "v3"
+}
@@ -0,0 +1,234 @@
+package uniswap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrPoolNotFound is returned when a pool with the requested ID does not exist
+var ErrPoolNotFound = errors.New("pool not found")
+
+// Pool is the current state of a Uniswap pool
+type Pool struct {
+	// ID is the pool's contract address for V3 and its pool ID, a 32 byte hash, for V4
+	ID      string          `json:"id"`
+	Version PositionVersion `json:"version"`
+	Token0  Token           `json:"token0"`
+	Token1  Token           `json:"token1"`
+	FeeTier uint32          `json:"feeTier"`
+
+	Liquidity *big.Int `json:"liquidity"`
+	SqrtPrice *big.Int `json:"sqrtPrice"`
+	Tick      int      `json:"tick"`
+	// Token0Price and Token1Price are the decimal adjusted prices as reported by the subgraph:
+	// Token0Price is token0 per token1, Token1Price token1 per token0
+	Token0Price *big.Float `json:"token0Price"`
+	Token1Price *big.Float `json:"token1Price"`
+
+	TVLUSD    float64 `json:"tvlUSD"`
+	VolumeUSD float64 `json:"volumeUSD"`
+	FeesUSD   float64 `json:"feesUSD"`
+}
+
+// PoolKey returns the ID of the position's pool as GetPool takes it: the pool's address for V3
+// and its pool ID for V4. It is empty if the data source didn't tell.
+func (p Position) PoolKey() string {
+	switch {
+	case p.Version == VersionV4 && p.PoolID != (common.Hash{}):
+		return p.PoolID.Hex()
+	case p.Version == VersionV3 && p.PoolAddress != (common.Address{}):
+		return strings.ToLower(p.PoolAddress.Hex())
+	}
+	return ""
+}
+
+// PoolStats is a pool's current state with its activity over the last 24 hours
+type PoolStats struct {
+	Pool
+	// VolumeUSD24h is the value swapped in the pool over the last 24 hours
+	VolumeUSD24h float64 `json:"volumeUSD24h"`
+	APR          PoolAPR `json:"apr"`
+}
+
+// PoolSource is implemented by clients that can fetch pools
+type PoolSource interface {
+	// GetPool fetches a pool by its ID
+	GetPool(ctx context.Context, version PositionVersion, id string) (*Pool, error)
+	// GetPoolStats fetches a pool by its ID with its volume, fees and APR over the last 24 hours
+	GetPoolStats(ctx context.Context, version PositionVersion, id string) (*PoolStats, error)
+}
+
+// GetPool fetches a pool from the subgraph of its version
+func (c *APIClient) GetPool(ctx context.Context, version PositionVersion, id string) (*Pool, error) {
+	var url string
+	switch version {
+	case VersionV3:
+		url = c.subgraphURL(VersionV3)
+	case VersionV4:
+		url = c.subgraphURL(VersionV4)
+	default:
+		return nil, fmt.Errorf("unsupported version: %s", version)
+	}
+
+	query := fmt.Sprintf(`{
+		pool(id: %q) {
+			id
+			feeTier
+			liquidity
+			sqrtPrice
+			tick
+			token0Price
+			token1Price
+			totalValueLockedUSD
+			volumeUSD
+			feesUSD
+			token0 {
+				id
+				symbol
+				decimals
+			}
+			token1 {
+				id
+				symbol
+				decimals
+			}
+		}
+	}`, strings.ToLower(id))
+
+	resp, err := c.executeGraphQLQuery(ctx, url, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute GraphQL query: %w", err)
+	}
+
+	var graphResp struct {
+		Data struct {
+			Pool *struct {
+				ID                  string `json:"id"`
+				FeeTier             string `json:"feeTier"`
+				Liquidity           string `json:"liquidity"`
+				SqrtPrice           string `json:"sqrtPrice"`
+				Tick                string `json:"tick"`
+				Token0Price         string `json:"token0Price"`
+				Token1Price         string `json:"token1Price"`
+				TotalValueLockedUSD string `json:"totalValueLockedUSD"`
+				VolumeUSD           string `json:"volumeUSD"`
+				FeesUSD             string `json:"feesUSD"`
+				Token0              struct {
+					ID       string `json:"id"`
+					Symbol   string `json:"symbol"`
+					Decimals string `json:"decimals"`
+				} `json:"token0"`
+				Token1 struct {
+					ID       string `json:"id"`
+					Symbol   string `json:"symbol"`
+					Decimals string `json:"decimals"`
+				} `json:"token1"`
+			} `json:"pool"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &graphResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	p := graphResp.Data.Pool
+	if p == nil {
+		return nil, ErrPoolNotFound
+	}
+
+	feeTier, _ := strconv.ParseUint(p.FeeTier, 10, 32)
+	tick, _ := strconv.ParseInt(p.Tick, 10, 64)
+	token0Decimals, _ := strconv.ParseUint(p.Token0.Decimals, 10, 8)
+	token1Decimals, _ := strconv.ParseUint(p.Token1.Decimals, 10, 8)
+	tvlUSD, _ := strconv.ParseFloat(p.TotalValueLockedUSD, 64)
+	volumeUSD, _ := strconv.ParseFloat(p.VolumeUSD, 64)
+	feesUSD, _ := strconv.ParseFloat(p.FeesUSD, 64)
+
+	chainID := c.chainID()
+	pool := &Pool{
+		ID:      p.ID,
+		Version: version,
+		Token0: Token{
+			Address:  common.HexToAddress(p.Token0.ID),
+			Symbol:   p.Token0.Symbol,
+			Decimals: uint8(token0Decimals),
+			ChainID:  chainID,
+		},
+		Token1: Token{
+			Address:  common.HexToAddress(p.Token1.ID),
+			Symbol:   p.Token1.Symbol,
+			Decimals: uint8(token1Decimals),
+			ChainID:  chainID,
+		},
+		FeeTier:     uint32(feeTier),
+		Liquidity:   stringToBigInt(p.Liquidity),
+		SqrtPrice:   stringToBigInt(p.SqrtPrice),
+		Tick:        int(tick),
+		Token0Price: stringToBigFloat(p.Token0Price),
+		Token1Price: stringToBigFloat(p.Token1Price),
+		TVLUSD:      tvlUSD,
+		VolumeUSD:   volumeUSD,
+		FeesUSD:     feesUSD,
+	}
+	c.tokens.resolve(ctx, &pool.Token0, &pool.Token1)
+	return pool, nil
+}
+
+// GetPoolStats fetches a pool along with the hourly volume and fees the subgraph records for it.
+// The last 24 hours are the current hour and the 23 before it, as hours without swaps have no
+// record.
+func (c *APIClient) GetPoolStats(ctx context.Context, version PositionVersion, id string) (*PoolStats, error) {
+	pool, err := c.GetPool(ctx, version, id)
+	if err != nil {
+		return nil, err
+	}
+
+	since := time.Now().Truncate(time.Hour).Add(-23 * time.Hour)
+	query := fmt.Sprintf(`{
+		poolHourDatas(
+			first: 24
+			orderBy: periodStartUnix
+			orderDirection: desc
+			where: { pool: %q, periodStartUnix_gte: %d }
+		) {
+			volumeUSD
+			feesUSD
+		}
+	}`, pool.ID, since.Unix())
+
+	resp, err := c.executeGraphQLQuery(ctx, c.subgraphURL(version), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute GraphQL query: %w", err)
+	}
+
+	var graphResp struct {
+		Data struct {
+			PoolHourDatas []struct {
+				VolumeUSD string `json:"volumeUSD"`
+				FeesUSD   string `json:"feesUSD"`
+			} `json:"poolHourDatas"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &graphResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	var volumeUSD, feesUSD float64
+	for _, hour := range graphResp.Data.PoolHourDatas {
+		volume, _ := strconv.ParseFloat(hour.VolumeUSD, 64)
+		fees, _ := strconv.ParseFloat(hour.FeesUSD, 64)
+		volumeUSD += volume
+		feesUSD += fees
+	}
+	return &PoolStats{
+		Pool:         *pool,
+		VolumeUSD24h: volumeUSD,
+		APR:          NewPoolAPR(feesUSD, pool.TVLUSD),
+	}, nil
+}
@@ -0,0 +1,160 @@
+package uniswap
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Pools keep their price as sqrtPriceX96, the square root of the raw price of token0 in token1
+// as a Q64.96 fixed point number, i.e. multiplied by 2^96. The functions here convert it exactly,
+// with integer and rational arithmetic, as the pool contracts do.
+
+// q96 is 2^96, the scale of a Q64.96 fixed point number
+var q96 = new(big.Int).Lsh(big.NewInt(1), 96)
+
+// MinSqrtPriceX96 and MaxSqrtPriceX96 are the sqrtPriceX96 at MinTick and at MaxTick, the bounds
+// of every pool's price
+var (
+	MinSqrtPriceX96    = big.NewInt(4295128739)
+	MaxSqrtPriceX96, _ = new(big.Int).SetString("1461446703485210103287273052203988822378723970342", 10)
+)
+
+// tickRatios are the Q128.128 values of 1/sqrt(1.0001)^(2^i) the pool contracts' TickMath
+// multiplies together for the bits i of a tick
+var tickRatios = func() []*big.Int {
+	hexRatios := []string{
+		"fffcb933bd6fad37aa2d162d1a594001",
+		"fff97272373d413259a46990580e213a",
+		"fff2e50f5f656932ef12357cf3c7fdcc",
+		"ffe5caca7e10e4e61c3624eaa0941cd0",
+		"ffcb9843d60f6159c9db58835c926644",
+		"ff973b41fa98c081472e6896dfb254c0",
+		"ff2ea16466c96a3843ec78b326b52861",
+		"fe5dee046a99a2a811c461f1969c3053",
+		"fcbe86c7900a88aedcffc83b479aa3a4",
+		"f987a7253ac413176f2b074cf7815e54",
+		"f3392b0822b70005940c7a398e4b70f3",
+		"e7159475a2c29b7443b29c7fa6e889d9",
+		"d097f3bdfd2022b8845ad8f792aa5825",
+		"a9f746462d870fdf8a65dc1f90e061e5",
+		"70d869a156d2a1b890bb3df62baf32f7",
+		"31be135f97d08fd981231505542fcfa6",
+		"9aa508b5b7a84e1c677de54f3e99bc9",
+		"5d6af8dedb81196699c329225ee604",
+		"2216e584f5fa1ea926041bedfe98",
+		"48a170391f7dc42444e8fa2",
+	}
+	ratios := make([]*big.Int, len(hexRatios))
+	for i, s := range hexRatios {
+		ratios[i], _ = new(big.Int).SetString(s, 16)
+	}
+	return ratios
+}()
+
+// maxUint256 is 2^256 - 1
+var maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// TickToSqrtPriceX96 returns the sqrtPriceX96 at a tick, sqrt(1.0001^tick) * 2^96, rounded as
+// the pool contracts' TickMath.getSqrtRatioAtTick rounds it, so it is the exact value pools use
+func TickToSqrtPriceX96(tick int) (*big.Int, error) {
+	if tick < MinTick || tick > MaxTick {
+		return nil, fmt.Errorf("tick %d is outside %d to %d", tick, MinTick, MaxTick)
+	}
+	absTick := tick
+	if absTick < 0 {
+		absTick = -absTick
+	}
+
+	// ratio is 1/sqrt(1.0001)^absTick as a Q128.128 number
+	ratio := new(big.Int).Lsh(big.NewInt(1), 128)
+	for i, tickRatio := range tickRatios {
+		if absTick&(1<<i) != 0 {
+			ratio.Mul(ratio, tickRatio).Rsh(ratio, 128)
+		}
+	}
+	if tick > 0 {
+		ratio.Quo(maxUint256, ratio)
+	}
+
+	// From Q128.128 to Q64.96, rounding up
+	sqrtPriceX96, remainder := new(big.Int).QuoRem(ratio, new(big.Int).Lsh(big.NewInt(1), 32), new(big.Int))
+	if remainder.Sign() != 0 {
+		sqrtPriceX96.Add(sqrtPriceX96, big.NewInt(1))
+	}
+	return sqrtPriceX96, nil
+}
+
+// SqrtPriceX96ToTick returns the tick of a pool at sqrtPriceX96, the greatest tick whose
+// TickToSqrtPriceX96 isn't above it, as TickMath.getTickAtSqrtRatio does
+func SqrtPriceX96ToTick(sqrtPriceX96 *big.Int) (int, error) {
+	if sqrtPriceX96.Cmp(MinSqrtPriceX96) < 0 || sqrtPriceX96.Cmp(MaxSqrtPriceX96) >= 0 {
+		return 0, fmt.Errorf("sqrtPriceX96 %s is outside %s to %s", sqrtPriceX96, MinSqrtPriceX96, MaxSqrtPriceX96)
+	}
+	low, high := MinTick, MaxTick
+	for low < high {
+		mid := low + (high-low+1)/2
+		atMid, _ := TickToSqrtPriceX96(mid)
+		if atMid.Cmp(sqrtPriceX96) <= 0 {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return low, nil
+}
+
+// SqrtPriceX96ToPrice returns the price of token0 in token1 at sqrtPriceX96, adjusted by the
+// tokens' decimals: how many whole token1 one whole token0 is worth
+func SqrtPriceX96ToPrice(sqrtPriceX96 *big.Int, decimals0, decimals1 uint8) *big.Rat {
+	num := new(big.Int).Mul(sqrtPriceX96, sqrtPriceX96)
+	num.Mul(num, pow10(decimals0))
+	den := new(big.Int).Mul(q96, q96)
+	den.Mul(den, pow10(decimals1))
+	return new(big.Rat).SetFrac(num, den)
+}
+
+// TickToPrice returns the price of token0 in token1 at a tick, adjusted by the tokens' decimals,
+// at the tick's sqrtPriceX96 as pools round it
+func TickToPrice(tick int, decimals0, decimals1 uint8) (*big.Rat, error) {
+	sqrtPriceX96, err := TickToSqrtPriceX96(tick)
+	if err != nil {
+		return nil, err
+	}
+	return SqrtPriceX96ToPrice(sqrtPriceX96, decimals0, decimals1), nil
+}
+
+// pricePrecision is the precision in bits of the prices of Position, enough for the 38
+// significant digits of any price
+const pricePrecision = 128
+
+// priceFloat converts an exact price to the *big.Float Position holds, nil if r is
+func priceFloat(r *big.Rat) *big.Float {
+	if r == nil {
+		return nil
+	}
+	return new(big.Float).SetPrec(pricePrecision).SetRat(r)
+}
+
+// sqrtPriceX96Price parses a pool's sqrtPriceX96 from the subgraph into the price of token0 in
+// token1, nil if it has none
+func sqrtPriceX96Price(s string, decimals0, decimals1 uint8) *big.Float {
+	sqrtPriceX96, ok := new(big.Int).SetString(s, 10)
+	if !ok || sqrtPriceX96.Sign() <= 0 {
+		return nil
+	}
+	return priceFloat(SqrtPriceX96ToPrice(sqrtPriceX96, decimals0, decimals1))
+}
+
+// tickPrice returns the price of token0 in token1 at a tick, nil if the tick is out of bounds
+func tickPrice(tick int, decimals0, decimals1 uint8) *big.Float {
+	price, err := TickToPrice(tick, decimals0, decimals1)
+	if err != nil {
+		return nil
+	}
+	return priceFloat(price)
+}
+
+// pow10 returns 10^n
+func pow10(n uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
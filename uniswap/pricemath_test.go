@@ -0,0 +1,116 @@
+package uniswap
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// The sqrtPriceX96 values are those of the pool contracts' TickMath.getSqrtRatioAtTick
+func TestTickToSqrtPriceX96(t *testing.T) {
+	tests := []struct {
+		tick int
+		want string
+	}{
+		{tick: 0, want: "79228162514264337593543950336"},
+		{tick: 1, want: "79232123823359799118286999568"},
+		{tick: -1, want: "79224201403219477170569942574"},
+		{tick: MinTick, want: "4295128739"},
+		{tick: MaxTick, want: "1461446703485210103287273052203988822378723970342"},
+	}
+	for _, tt := range tests {
+		got, err := TickToSqrtPriceX96(tt.tick)
+		if err != nil {
+			t.Errorf("TickToSqrtPriceX96(%d) returned error: %v", tt.tick, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("TickToSqrtPriceX96(%d) = %s, want %s", tt.tick, got, tt.want)
+		}
+	}
+
+	for _, tick := range []int{MinTick - 1, MaxTick + 1} {
+		if _, err := TickToSqrtPriceX96(tick); err == nil {
+			t.Errorf("TickToSqrtPriceX96(%d) returned no error", tick)
+		}
+	}
+}
+
+func TestSqrtPriceX96ToTick(t *testing.T) {
+	for _, tick := range []int{MinTick, -887271, -200000, -1, 0, 1, 60, 198079, 887271} {
+		sqrtPriceX96, err := TickToSqrtPriceX96(tick)
+		if err != nil {
+			t.Fatalf("TickToSqrtPriceX96(%d) returned error: %v", tick, err)
+		}
+
+		// At the tick's sqrtPriceX96 and between it and the next tick's, the pool is at the tick
+		next, err := TickToSqrtPriceX96(tick + 1)
+		if err != nil {
+			t.Fatalf("TickToSqrtPriceX96(%d) returned error: %v", tick+1, err)
+		}
+		between := new(big.Int).Add(sqrtPriceX96, next)
+		between.Rsh(between, 1)
+		for _, s := range []*big.Int{sqrtPriceX96, between, new(big.Int).Sub(next, big.NewInt(1))} {
+			got, err := SqrtPriceX96ToTick(s)
+			if err != nil {
+				t.Errorf("SqrtPriceX96ToTick(%s) returned error: %v", s, err)
+			} else if got != tick {
+				t.Errorf("SqrtPriceX96ToTick(%s) = %d, want %d", s, got, tick)
+			}
+		}
+	}
+
+	for _, s := range []*big.Int{new(big.Int).Sub(MinSqrtPriceX96, big.NewInt(1)), MaxSqrtPriceX96} {
+		if _, err := SqrtPriceX96ToTick(s); err == nil {
+			t.Errorf("SqrtPriceX96ToTick(%s) returned no error", s)
+		}
+	}
+}
+
+// The prices are of the USDC/WETH pool, USDC (6 decimals) being token0 and WETH (18 decimals)
+// token1, so they are in WETH per USDC
+func TestSqrtPriceX96ToPrice(t *testing.T) {
+	// 20000 * 2^96 is a raw price of 4e8 wei per USDC unit, ETH at 2500 USDC
+	sqrtPriceX96 := new(big.Int).Mul(big.NewInt(20000), q96)
+	want := big.NewRat(1, 2500)
+	if got := SqrtPriceX96ToPrice(sqrtPriceX96, 6, 18); got.Cmp(want) != 0 {
+		t.Errorf("SqrtPriceX96ToPrice(%s, 6, 18) = %s, want %s", sqrtPriceX96, got.RatString(), want.RatString())
+	}
+
+	// The reverse pool, WETH being token0, is at the inverse price
+	inverse := new(big.Int).Quo(new(big.Int).Mul(q96, q96), sqrtPriceX96)
+	if got, _ := SqrtPriceX96ToPrice(inverse, 18, 6).Float64(); math.Abs(got-2500) > 1e-9 {
+		t.Errorf("SqrtPriceX96ToPrice(%s, 18, 6) = %v, want 2500", inverse, got)
+	}
+}
+
+func TestTickToPrice(t *testing.T) {
+	tests := []struct {
+		tick int
+		want float64
+	}{
+		{tick: 0, want: 1e-12},
+		{tick: 198079, want: math.Pow(1.0001, 198079) * 1e-12},
+		{tick: 200000, want: math.Pow(1.0001, 200000) * 1e-12},
+	}
+	for _, tt := range tests {
+		price, err := TickToPrice(tt.tick, 6, 18)
+		if err != nil {
+			t.Errorf("TickToPrice(%d, 6, 18) returned error: %v", tt.tick, err)
+			continue
+		}
+		if got, _ := price.Float64(); math.Abs(got-tt.want)/tt.want > 1e-9 {
+			t.Errorf("TickToPrice(%d, 6, 18) = %v, want %v", tt.tick, got, tt.want)
+		}
+	}
+
+	// At tick 0 the raw price is exactly 1
+	price, _ := TickToPrice(0, 6, 18)
+	if want := big.NewRat(1, 1_000_000_000_000); price.Cmp(want) != 0 {
+		t.Errorf("TickToPrice(0, 6, 18) = %s, want %s", price.RatString(), want.RatString())
+	}
+
+	if _, err := TickToPrice(MaxTick+1, 6, 18); err == nil {
+		t.Errorf("TickToPrice(%d, 6, 18) returned no error", MaxTick+1)
+	}
+}
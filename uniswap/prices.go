@@ -0,0 +1,182 @@
+package uniswap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PriceProvider is implemented by clients that can price tokens in USD
+type PriceProvider interface {
+	// GetTokenPricesUSD returns the USD price of each token it could price. Tokens without
+	// a known price are missing from the result.
+	GetTokenPricesUSD(ctx context.Context, version PositionVersion, tokens []common.Address) (map[common.Address]float64, error)
+}
+
+// GetTokenPricesUSD prices tokens via the subgraph's ETH-denominated token prices and the ETH/USD bundle price
+func (c *APIClient) GetTokenPricesUSD(ctx context.Context, version PositionVersion, tokens []common.Address) (map[common.Address]float64, error) {
+	var url string
+	switch version {
+	case VersionV3:
+		url = c.subgraphURL(VersionV3)
+	case VersionV4:
+		url = c.subgraphURL(VersionV4)
+	default:
+		return nil, fmt.Errorf("unsupported version: %s", version)
+	}
+
+	ids := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		ids = append(ids, fmt.Sprintf("%q", strings.ToLower(token.Hex())))
+	}
+
+	query := fmt.Sprintf(`{
+		bundle(id: "1") {
+			ethPriceUSD
+		}
+		tokens(where: { id_in: [%s] }) {
+			id
+			derivedETH
+		}
+	}`, strings.Join(ids, ", "))
+
+	resp, err := c.executeGraphQLQuery(ctx, url, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute GraphQL query: %w", err)
+	}
+
+	var graphResp struct {
+		Data struct {
+			Bundle struct {
+				EthPriceUSD string `json:"ethPriceUSD"`
+			} `json:"bundle"`
+			Tokens []struct {
+				ID         string `json:"id"`
+				DerivedETH string `json:"derivedETH"`
+			} `json:"tokens"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &graphResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	ethPriceUSD, err := strconv.ParseFloat(graphResp.Data.Bundle.EthPriceUSD, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ETH price %q: %w", graphResp.Data.Bundle.EthPriceUSD, err)
+	}
+
+	prices := make(map[common.Address]float64, len(graphResp.Data.Tokens))
+	for _, t := range graphResp.Data.Tokens {
+		derivedETH, err := strconv.ParseFloat(t.DerivedETH, 64)
+		if err != nil || derivedETH == 0 {
+			continue
+		}
+		prices[common.HexToAddress(t.ID)] = derivedETH * ethPriceUSD
+	}
+	return prices, nil
+}
+
+// PositionUSD is a position's dollar figures at current token prices
+type PositionUSD struct {
+	// Value is what the position holds
+	Value float64
+	// Fees is the fees collected by the position
+	Fees float64
+	// PnL is Value plus what was withdrawn and the fees, less what was deposited. All of it is
+	// valued at current prices, so PnL doesn't include price changes of the tokens themselves.
+	PnL float64
+}
+
+// ApplyTokenPrices sets the PriceUSD of the positions' tokens to their price in pricesUSD, e.g.
+// from a PriceProvider, leaving tokens without one as they are
+func ApplyTokenPrices(positions []Position, pricesUSD map[common.Address]float64) {
+	for i := range positions {
+		for _, token := range []*Token{&positions[i].Token0, &positions[i].Token1} {
+			if price, ok := pricesUSD[token.Address]; ok {
+				token.PriceUSD = price
+			}
+		}
+	}
+}
+
+// tokenPriceUSD returns the token's price in pricesUSD, or the PriceUSD set on it
+func tokenPriceUSD(token Token, pricesUSD map[common.Address]float64) (float64, bool) {
+	if price, ok := pricesUSD[token.Address]; ok {
+		return price, true
+	}
+	return token.PriceUSD, token.PriceUSD > 0
+}
+
+// PositionValuesUSD returns the position's dollar figures at the USD token prices given, e.g.
+// by a PriceProvider, or at those set by ApplyTokenPrices. It is false unless both of the
+// position's tokens are priced.
+func PositionValuesUSD(position Position, pricesUSD map[common.Address]float64) (PositionUSD, bool) {
+	price0, ok0 := tokenPriceUSD(position.Token0, pricesUSD)
+	price1, ok1 := tokenPriceUSD(position.Token1, pricesUSD)
+	if !ok0 || !ok1 {
+		return PositionUSD{}, false
+	}
+	usd := func(amount0, amount1 *big.Int) float64 {
+		return TokenAmountUSD(amount0, position.Token0, price0) + TokenAmountUSD(amount1, position.Token1, price1)
+	}
+	values := PositionUSD{
+		Value: usd(position.Amount0, position.Amount1),
+		Fees:  usd(position.UnclaimedFees0, position.UnclaimedFees1),
+	}
+	values.PnL = values.Value + usd(position.WithdrawnToken0, position.WithdrawnToken1) + values.Fees -
+		usd(position.DepositedToken0, position.DepositedToken1)
+	return values, true
+}
+
+// FormatPositionSummaryUSD formats a position like FormatPositionSummary, with its dollar
+// figures at the USD token prices given, e.g. "$1234.56" and "+$12.34" for PnL, and the token
+// prices, e.g. "WETH $3000.00, USDC $1.00"
+func FormatPositionSummaryUSD(position Position, pricesUSD map[common.Address]float64) PositionSummary {
+	summary := FormatPositionSummary(position)
+	if values, ok := PositionValuesUSD(position, pricesUSD); ok {
+		summary.ValueUSD = fmt.Sprintf("$%.2f", values.Value)
+		summary.FeesUSD = fmt.Sprintf("$%.2f", values.Fees)
+		summary.PnLUSD = formatSignedUSD(values.PnL)
+		price0, _ := tokenPriceUSD(position.Token0, pricesUSD)
+		price1, _ := tokenPriceUSD(position.Token1, pricesUSD)
+		summary.TokenPrices = fmt.Sprintf("%s %s, %s %s", position.Token0, formatPriceUSD(price0), position.Token1, formatPriceUSD(price1))
+	}
+	return summary
+}
+
+// formatPriceUSD formats a token price with the significant digits of cheap tokens, e.g.
+// "$3000.00" or "$0.00001234"
+func formatPriceUSD(usd float64) string {
+	if usd >= 1 {
+		return fmt.Sprintf("$%.2f", usd)
+	}
+	// Round to 4 significant digits without switching to an exponent
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(usd, 'g', 4, 64), 64)
+	return "$" + strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+// formatSignedUSD formats a gain or loss, e.g. "+$12.34" or "-$5.00"
+func formatSignedUSD(usd float64) string {
+	if usd < 0 {
+		return fmt.Sprintf("-$%.2f", -usd)
+	}
+	return fmt.Sprintf("+$%.2f", usd)
+}
+
+// TokenAmountUSD converts a raw token amount into USD at the given token price
+func TokenAmountUSD(amount *big.Int, token Token, priceUSD float64) float64 {
+	if amount == nil {
+		return 0
+	}
+	value := new(big.Float).SetInt(amount)
+	divisor := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(token.Decimals)), nil))
+	value.Quo(value, divisor)
+	value.Mul(value, big.NewFloat(priceUSD))
+	usd, _ := value.Float64()
+	return usd
+}
@@ -0,0 +1,32 @@
+package uniswap
+
+// InRange reports whether the position's range holds the pool's current price, i.e. whether it
+// earns fees. As in the pool contracts, that is when tickLower <= tick < tickUpper. Positions
+// without the current tick, e.g. read from fixtures or snapshots stored before it was recorded,
+// compare the current price with the price range instead.
+func (p Position) InRange() bool {
+	if p.CurrentTick != nil {
+		return p.TickLower <= *p.CurrentTick && *p.CurrentTick < p.TickUpper
+	}
+	if p.CurrentPrice == nil || p.PriceLower == nil || p.PriceUpper == nil {
+		return false
+	}
+	return p.CurrentPrice.Cmp(p.PriceLower) >= 0 && p.CurrentPrice.Cmp(p.PriceUpper) <= 0
+}
+
+// Width returns the width of the position's range in ticks. Each tick is a 0.01% price change,
+// so the upper bound of the range is 1.0001^Width times its lower bound.
+func (p Position) Width() int {
+	return p.TickUpper - p.TickLower
+}
+
+// RangeUtilization returns where the pool's current tick is in the position's range: 0 at its
+// lower bound, 0.5 in the middle and 1 at its upper bound. It is below 0 or from 1 up while the
+// position is out of range, telling how far out it is in widths of the range. It is false if
+// the current tick isn't known or the range is empty.
+func (p Position) RangeUtilization() (float64, bool) {
+	if p.CurrentTick == nil || p.Width() <= 0 {
+		return 0, false
+	}
+	return float64(*p.CurrentTick-p.TickLower) / float64(p.Width()), true
+}
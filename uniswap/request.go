@@ -0,0 +1,46 @@
+package uniswap
+
+import "slices"
+
+// Apply applies the request's options other than the wallet and versions to the positions
+// fetched for it: it leaves out closed positions unless IncludeClosed, positions with less than
+// MinLiquidity and positions on other Chains, orders the rest by SortBy and pages them by Offset
+// and Limit. positions is left as is. Clients fetch positions as before and call Apply, so a new
+// option doesn't have to change them all.
+func (r PositionRequest) Apply(positions []Position) []Position {
+	selected := make([]Position, 0, len(positions))
+	for _, pos := range positions {
+		if r.selects(pos) {
+			selected = append(selected, pos)
+		}
+	}
+	if r.SortBy != nil {
+		SortPositions(selected, r.SortBy)
+	}
+
+	if r.Offset >= len(selected) {
+		return nil
+	}
+	selected = selected[max(r.Offset, 0):]
+	if r.Limit > 0 && r.Limit < len(selected) {
+		selected = selected[:r.Limit]
+	}
+	return selected
+}
+
+// selects reports whether the request's filtering options keep pos
+func (r PositionRequest) selects(pos Position) bool {
+	if !r.IncludeClosed && IsClosed(pos) {
+		return false
+	}
+	if r.MinLiquidity != nil && pos.Liquidity != nil && pos.Liquidity.Cmp(r.MinLiquidity) < 0 {
+		return false
+	}
+	return r.OnChain(pos.Chain())
+}
+
+// OnChain reports whether the request asks for positions on the chain, so clients can skip
+// querying chains no position is asked for on
+func (r PositionRequest) OnChain(chain ChainID) bool {
+	return len(r.Chains) == 0 || slices.Contains(r.Chains, chain)
+}
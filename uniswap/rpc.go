@@ -0,0 +1,181 @@
+package uniswap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// DefaultRPCTimeout bounds a single call to an Ethereum node unless the client is given another
+const DefaultRPCTimeout = 10 * time.Second
+
+// ErrExecutionReverted is returned, wrapped in an RPCError, when a contract call reverts
+var ErrExecutionReverted = errors.New("execution reverted")
+
+// RPCError is returned when an Ethereum node answers a call with a JSON-RPC error. It is
+// ErrExecutionReverted if the contract reverted.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+func (e *RPCError) Is(target error) bool {
+	return target == ErrExecutionReverted && strings.Contains(strings.ToLower(e.Message), "execution reverted")
+}
+
+// RPCClient reads contracts through an Ethereum node's JSON-RPC API, for what the subgraphs don't
+// index, such as the pictures of position NFTs
+type RPCClient struct {
+	httpClient *http.Client
+	logger     *zap.SugaredLogger
+	url        string
+	nextID     atomic.Uint64
+}
+
+// NewRPCClient creates a client of the Ethereum mainnet node at url whose calls each take at most
+// timeout
+func NewRPCClient(logger *zap.SugaredLogger, url string, timeout time.Duration) *RPCClient {
+	client := &RPCClient{
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		logger: logger,
+		url:    url,
+	}
+	var _ PositionMetadataSource = client
+	var _ bind.ContractCaller = client
+	return client
+}
+
+// CallContract runs a read-only call of a contract and returns what it returned, at the latest
+// block if blockNumber is nil. It makes the client a bind.ContractCaller for the bindings of the
+// contracts package.
+func (c *RPCClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	args := map[string]string{"data": hexutil.Encode(call.Data)}
+	if call.To != nil {
+		args["to"] = call.To.Hex()
+	}
+	if call.From != (common.Address{}) {
+		args["from"] = call.From.Hex()
+	}
+	var result hexutil.Bytes
+	err := c.traced(ctx, "eth_call", &result, args, blockParam(blockNumber))
+	return result, err
+}
+
+// CodeAt returns the code of a contract, empty if there is no contract at the address, at the
+// latest block if blockNumber is nil
+func (c *RPCClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	var result hexutil.Bytes
+	err := c.traced(ctx, "eth_getCode", &result, contract.Hex(), blockParam(blockNumber))
+	return result, err
+}
+
+// ChainID returns the ID of the chain the node is on, to check it is the one positions are on
+func (c *RPCClient) ChainID(ctx context.Context) (ChainID, error) {
+	var result hexutil.Uint64
+	if err := c.traced(ctx, "eth_chainId", &result); err != nil {
+		return 0, err
+	}
+	return ChainID(result), nil
+}
+
+// blockParam names a block in JSON-RPC parameters, "latest" for nil
+func blockParam(blockNumber *big.Int) string {
+	if blockNumber == nil {
+		return "latest"
+	}
+	return hexutil.EncodeBig(blockNumber)
+}
+
+// traced sends a JSON-RPC request in a span of its own
+func (c *RPCClient) traced(ctx context.Context, method string, result any, params ...any) error {
+	ctx, span := tracer.Start(ctx, method, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	err := c.do(ctx, method, result, params...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// do sends a JSON-RPC request and decodes its result into result
+func (c *RPCClient) do(ctx context.Context, method string, result any, params ...any) error {
+	body, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      c.nextID.Add(1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// The URL often holds the provider's API key, so it isn't logged
+	LoggerWithCorrelationID(ctx, c.logger).Debugw("Making JSON-RPC request", "method", method, "bodyLength", len(body))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// The error names the URL, which may hold the API key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	var rpcResp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &rpcResp); err != nil {
+		return fmt.Errorf("failed to decode JSON-RPC response: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("failed to decode %s result: %w", method, err)
+	}
+	return nil
+}
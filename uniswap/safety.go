@@ -0,0 +1,155 @@
+package uniswap
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// TokenRisk is why a token may be a scam, e.g. one airdropped into wallets as a fake position
+// that lures its owner into approving a drainer
+type TokenRisk string
+
+const (
+	// RiskHoneypot is a token on a honeypot or scam token blocklist
+	RiskHoneypot TokenRisk = "honeypot"
+	// RiskUnverified is a token on no token list whose contract source isn't verified on the
+	// block explorer, which legitimate tokens rarely are
+	RiskUnverified TokenRisk = "unverified"
+)
+
+// Describe explains the risk to users, e.g. "is on a honeypot list"
+func (r TokenRisk) Describe() string {
+	switch r {
+	case RiskHoneypot:
+		return "is on a honeypot list"
+	case RiskUnverified:
+		return "is on no token list and its contract isn't verified"
+	}
+	return string(r)
+}
+
+// ContractVerifier is implemented by block explorers that tell whether a contract's source code
+// was published and verified
+type ContractVerifier interface {
+	IsVerified(ctx context.Context, address common.Address) (bool, error)
+}
+
+// maxVerificationsPerScreen bounds how many tokens Screen asks the verifier about, so a wallet
+// full of airdropped tokens doesn't hold up a command; the rest are asked about next time
+const maxVerificationsPerScreen = 10
+
+// unverifiedRecheckInterval is how long a verifier's answer that a contract isn't verified is
+// trusted, since its source may be verified later. Verified contracts stay verified.
+const unverifiedRecheckInterval = 24 * time.Hour
+
+// TokenScreener flags suspicious tokens: those on a blocklist, and, if it has a verifier, those
+// on no token list whose contracts aren't verified. Tokens on a token list are trusted.
+type TokenScreener struct {
+	logger *zap.SugaredLogger
+	// listed reports whether a token is on a token list
+	listed func(address common.Address) bool
+
+	mu        sync.Mutex
+	verifier  ContractVerifier
+	blocklist map[common.Address]bool
+	// verified caches the verifier's answers, checkedAt when it gave them
+	verified  map[common.Address]bool
+	checkedAt map[common.Address]time.Time
+}
+
+// NewTokenScreener creates a screener trusting the tokens listed reports as on a token list
+func NewTokenScreener(logger *zap.SugaredLogger, listed func(address common.Address) bool) *TokenScreener {
+	return &TokenScreener{
+		logger:    logger,
+		listed:    listed,
+		blocklist: make(map[common.Address]bool),
+		verified:  make(map[common.Address]bool),
+		checkedAt: make(map[common.Address]time.Time),
+	}
+}
+
+// SetVerifier makes the screener flag unlisted tokens whose contracts verifier says aren't verified
+func (s *TokenScreener) SetVerifier(verifier ContractVerifier) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.verifier = verifier
+}
+
+// SetBlocklist replaces the honeypot and scam tokens with tokens
+func (s *TokenScreener) SetBlocklist(tokens []common.Address) {
+	blocklist := make(map[common.Address]bool, len(tokens))
+	for _, token := range tokens {
+		blocklist[token] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocklist = blocklist
+}
+
+// Screen sets the Risk of the positions' suspicious tokens. Tokens the verifier can't be asked
+// about right now are left unflagged.
+func (s *TokenScreener) Screen(ctx context.Context, positions []Position) {
+	verifications := 0
+	for i := range positions {
+		for _, token := range []*Token{&positions[i].Token0, &positions[i].Token1} {
+			token.Risk = s.risk(ctx, token.Address, &verifications)
+		}
+	}
+}
+
+// risk returns the token's risk, asking the verifier about it unless it is cached or
+// verifications reached maxVerificationsPerScreen
+func (s *TokenScreener) risk(ctx context.Context, address common.Address, verifications *int) TokenRisk {
+	s.mu.Lock()
+	verifier := s.verifier
+	blocklisted := s.blocklist[address]
+	verified, cached := s.verified[address]
+	if cached && !verified && time.Since(s.checkedAt[address]) > unverifiedRecheckInterval {
+		cached = false
+	}
+	s.mu.Unlock()
+
+	switch {
+	case blocklisted:
+		return RiskHoneypot
+	case verifier == nil || s.listed(address) || address == (common.Address{}):
+		// The zero address is the native currency of V4 pools, not a contract
+		return ""
+	case !cached:
+		if *verifications >= maxVerificationsPerScreen {
+			return ""
+		}
+		*verifications++
+
+		var err error
+		verified, err = verifier.IsVerified(ctx, address)
+		if err != nil {
+			LoggerWithCorrelationID(ctx, s.logger).Warnw("Failed to check contract verification", "token", address.Hex(), "error", err)
+			return ""
+		}
+		s.mu.Lock()
+		s.verified[address] = verified
+		s.checkedAt[address] = time.Now()
+		s.mu.Unlock()
+	}
+	if !verified {
+		return RiskUnverified
+	}
+	return ""
+}
+
+// SuspiciousTokens returns the position's tokens with a Risk
+func (p Position) SuspiciousTokens() []Token {
+	var tokens []Token
+	for _, token := range []Token{p.Token0, p.Token1} {
+		if token.Risk != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,56 @@
+package uniswap
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PositionOrder compares two positions for SortPositions, returning a negative number if a
+// comes first, a positive number if b does and 0 if either may
+type PositionOrder func(a, b Position) int
+
+// SortPositions sorts positions by order. Positions order doesn't tell apart keep their order,
+// e.g. the order the data source returned them in.
+func SortPositions(positions []Position, order PositionOrder) {
+	slices.SortStableFunc(positions, order)
+}
+
+// ByValueDesc orders the most valuable positions first, at the USD token prices given. Positions
+// whose tokens aren't both priced come last.
+func ByValueDesc(pricesUSD map[common.Address]float64) PositionOrder {
+	return byUSDDesc(pricesUSD, func(values PositionUSD) float64 { return values.Value })
+}
+
+// ByFeesDesc orders the positions that collected the most fees first, at the USD token prices
+// given. Positions whose tokens aren't both priced come last.
+func ByFeesDesc(pricesUSD map[common.Address]float64) PositionOrder {
+	return byUSDDesc(pricesUSD, func(values PositionUSD) float64 { return values.Fees })
+}
+
+func byUSDDesc(pricesUSD map[common.Address]float64, figure func(PositionUSD) float64) PositionOrder {
+	return func(a, b Position) int {
+		valuesA, okA := PositionValuesUSD(a, pricesUSD)
+		valuesB, okB := PositionValuesUSD(b, pricesUSD)
+		switch {
+		case okA && okB:
+			return cmp.Compare(figure(valuesB), figure(valuesA))
+		case okA:
+			return -1
+		case okB:
+			return 1
+		}
+		return 0
+	}
+}
+
+// ByAgeAsc orders the youngest positions first
+func ByAgeAsc(a, b Position) int {
+	return b.CreatedAt.Compare(a.CreatedAt)
+}
+
+// ByAgeDesc orders the oldest positions first
+func ByAgeDesc(a, b Position) int {
+	return a.CreatedAt.Compare(b.CreatedAt)
+}
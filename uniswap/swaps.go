@@ -0,0 +1,135 @@
+package uniswap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SwapSource is implemented by clients that can list recent swaps in pools
+type SwapSource interface {
+	// GetLargeSwaps returns swaps worth at least minUSD executed in the given V3 pools after since, oldest first
+	GetLargeSwaps(ctx context.Context, pools []common.Address, minUSD float64, since time.Time) ([]Swap, error)
+}
+
+// GetLargeSwaps queries the V3 subgraph for large swaps in the given pools
+func (c *APIClient) GetLargeSwaps(ctx context.Context, pools []common.Address, minUSD float64, since time.Time) ([]Swap, error) {
+	if len(pools) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(pools))
+	for _, pool := range pools {
+		ids = append(ids, fmt.Sprintf("%q", strings.ToLower(pool.Hex())))
+	}
+
+	query := fmt.Sprintf(`{
+		swaps(
+			first: 100
+			orderBy: timestamp
+			orderDirection: asc
+			where: { pool_in: [%s], amountUSD_gte: "%s", timestamp_gt: "%d" }
+		) {
+			transaction {
+				id
+			}
+			timestamp
+			amount0
+			amount1
+			amountUSD
+			pool {
+				id
+				feeTier
+			}
+			token0 {
+				id
+				symbol
+				decimals
+			}
+			token1 {
+				id
+				symbol
+				decimals
+			}
+		}
+	}`, strings.Join(ids, ", "), strconv.FormatFloat(minUSD, 'f', -1, 64), since.Unix())
+
+	resp, err := c.executeGraphQLQuery(ctx, c.subgraphURL(VersionV3), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute GraphQL query: %w", err)
+	}
+
+	var graphResp struct {
+		Data struct {
+			Swaps []struct {
+				Transaction struct {
+					ID string `json:"id"`
+				} `json:"transaction"`
+				Timestamp string `json:"timestamp"`
+				Amount0   string `json:"amount0"`
+				Amount1   string `json:"amount1"`
+				AmountUSD string `json:"amountUSD"`
+				Pool      struct {
+					ID      string `json:"id"`
+					FeeTier string `json:"feeTier"`
+				} `json:"pool"`
+				Token0 struct {
+					ID       string `json:"id"`
+					Symbol   string `json:"symbol"`
+					Decimals string `json:"decimals"`
+				} `json:"token0"`
+				Token1 struct {
+					ID       string `json:"id"`
+					Symbol   string `json:"symbol"`
+					Decimals string `json:"decimals"`
+				} `json:"token1"`
+			} `json:"swaps"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &graphResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	swaps := make([]Swap, 0, len(graphResp.Data.Swaps))
+	chainID := c.chainID()
+	for _, s := range graphResp.Data.Swaps {
+		timestamp, _ := strconv.ParseInt(s.Timestamp, 10, 64)
+		feeTier, _ := strconv.ParseUint(s.Pool.FeeTier, 10, 32)
+		token0Decimals, _ := strconv.ParseUint(s.Token0.Decimals, 10, 8)
+		token1Decimals, _ := strconv.ParseUint(s.Token1.Decimals, 10, 8)
+		amountUSD, _ := strconv.ParseFloat(s.AmountUSD, 64)
+
+		swaps = append(swaps, Swap{
+			TxHash:  common.HexToHash(s.Transaction.ID),
+			Version: VersionV3,
+			Pool:    common.HexToAddress(s.Pool.ID),
+			Token0: Token{
+				Address:  common.HexToAddress(s.Token0.ID),
+				Symbol:   s.Token0.Symbol,
+				Decimals: uint8(token0Decimals),
+				ChainID:  chainID,
+			},
+			Token1: Token{
+				Address:  common.HexToAddress(s.Token1.ID),
+				Symbol:   s.Token1.Symbol,
+				Decimals: uint8(token1Decimals),
+				ChainID:  chainID,
+			},
+			FeeTier:   uint32(feeTier),
+			Timestamp: time.Unix(timestamp, 0),
+			Amount0:   stringToBigFloat(s.Amount0),
+			Amount1:   stringToBigFloat(s.Amount1),
+			AmountUSD: amountUSD,
+		})
+	}
+
+	for i := range swaps {
+		c.tokens.resolve(ctx, &swaps[i].Token0, &swaps[i].Token1)
+	}
+	return swaps, nil
+}
@@ -0,0 +1,89 @@
+package uniswap
+
+import (
+	"fmt"
+)
+
+// MinTick and MaxTick bound the ticks of every pool, the prices 1.0001^MinTick to 1.0001^MaxTick
+const (
+	MinTick = -887272
+	MaxTick = 887272
+)
+
+// feeTierTickSpacings are the tick spacings the V3 factory enables for its fee tiers, which V4
+// pools usually use too
+var feeTierTickSpacings = map[uint32]int{
+	100:   1,
+	500:   10,
+	3000:  60,
+	10000: 200,
+}
+
+// TickSpacing returns the tick spacing of pools with a fee tier, in hundredths of a basis point.
+// Only ticks that are multiples of it can bound a position. It is false for fee tiers the V3
+// factory doesn't enable; V4 pools may pick any spacing, so theirs comes from their pool key.
+func TickSpacing(feeTier uint32) (int, bool) {
+	spacing, ok := feeTierTickSpacings[feeTier]
+	return spacing, ok
+}
+
+// ValidateTickRange checks that a pool with tick spacing could hold a position from tickLower to
+// tickUpper: the ticks must be multiples of the spacing between MinTick and MaxTick, with
+// tickLower below tickUpper. The error wraps ErrInvalidTickRange.
+func ValidateTickRange(tickLower, tickUpper, spacing int) error {
+	switch {
+	case spacing <= 0:
+		return fmt.Errorf("%w: tick spacing %d is not positive", ErrInvalidTickRange, spacing)
+	case tickLower >= tickUpper:
+		return fmt.Errorf("%w: lower tick %d is not below upper tick %d", ErrInvalidTickRange, tickLower, tickUpper)
+	case tickLower < MinTick:
+		return fmt.Errorf("%w: lower tick %d is below %d", ErrInvalidTickRange, tickLower, MinTick)
+	case tickUpper > MaxTick:
+		return fmt.Errorf("%w: upper tick %d is above %d", ErrInvalidTickRange, tickUpper, MaxTick)
+	case tickLower%spacing != 0 || tickUpper%spacing != 0:
+		return fmt.Errorf("%w: ticks %d and %d are not multiples of the tick spacing %d", ErrInvalidTickRange, tickLower, tickUpper, spacing)
+	}
+	return nil
+}
+
+// NormalizeTickRange widens a range to the nearest ticks a pool with tick spacing accepts,
+// rounding tickLower down and tickUpper up to multiples of the spacing and keeping them within
+// the usable ticks. A range narrower than one spacing becomes one spacing wide. It panics if
+// spacing isn't positive.
+func NormalizeTickRange(tickLower, tickUpper, spacing int) (int, int) {
+	if spacing <= 0 {
+		panic(fmt.Sprintf("tick spacing %d is not positive", spacing))
+	}
+	if tickLower > tickUpper {
+		tickLower, tickUpper = tickUpper, tickLower
+	}
+	minUsable, maxUsable := UsableTickBounds(spacing)
+
+	lower := min(max(floorTick(tickLower, spacing), minUsable), maxUsable-spacing)
+	upper := max(min(ceilTick(tickUpper, spacing), maxUsable), lower+spacing)
+	return lower, upper
+}
+
+// UsableTickBounds returns the lowest and the highest tick a pool with tick spacing accepts, the
+// multiples of the spacing closest to MinTick and MaxTick within them
+func UsableTickBounds(spacing int) (int, int) {
+	return ceilTick(MinTick, spacing), floorTick(MaxTick, spacing)
+}
+
+// floorTick rounds tick down to a multiple of spacing
+func floorTick(tick, spacing int) int {
+	rounded := tick / spacing * spacing
+	if rounded > tick {
+		rounded -= spacing
+	}
+	return rounded
+}
+
+// ceilTick rounds tick up to a multiple of spacing
+func ceilTick(tick, spacing int) int {
+	rounded := tick / spacing * spacing
+	if rounded < tick {
+		rounded += spacing
+	}
+	return rounded
+}
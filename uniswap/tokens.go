@@ -0,0 +1,138 @@
+package uniswap
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// ChainEthereum is the chain all positions currently live on
+const ChainEthereum = "ethereum"
+
+// TokenMetadata is what is known about a token beyond its address
+type TokenMetadata struct {
+	Address  common.Address
+	Chain    string
+	Symbol   string
+	Decimals uint8
+	LogoURI  string
+}
+
+// TokenCache persists token metadata across restarts
+type TokenCache interface {
+	LoadTokens(ctx context.Context) ([]TokenMetadata, error)
+	SaveTokens(ctx context.Context, tokens []TokenMetadata) error
+}
+
+// tokenRegistry remembers the metadata of every token seen, so tokens the subgraph returns
+// without symbol or decimals are still shown properly once they were resolved before. Known
+// tokens, those of a curated token list, are always shown as the list has them.
+type tokenRegistry struct {
+	logger *zap.SugaredLogger
+
+	mu     sync.Mutex
+	cache  TokenCache
+	tokens map[common.Address]TokenMetadata
+	known  map[common.Address]TokenMetadata
+}
+
+func newTokenRegistry(logger *zap.SugaredLogger) *tokenRegistry {
+	return &tokenRegistry{
+		logger: logger,
+		tokens: make(map[common.Address]TokenMetadata),
+	}
+}
+
+// setCache loads the tokens persisted in cache and persists newly resolved tokens there from now on
+func (r *tokenRegistry) setCache(ctx context.Context, cache TokenCache) error {
+	tokens, err := cache.LoadTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = cache
+	for _, token := range tokens {
+		r.tokens[token.Address] = token
+	}
+	return nil
+}
+
+// setKnown replaces the known tokens with tokens
+func (r *tokenRegistry) setKnown(tokens []TokenMetadata) {
+	known := make(map[common.Address]TokenMetadata, len(tokens))
+	for _, token := range tokens {
+		known[token.Address] = token
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.known = known
+}
+
+// resolve fills in the metadata of tokens the subgraph didn't resolve and remembers the tokens it
+// did. Logos only come from the token list or the cache, since the subgraphs don't have them.
+func (r *tokenRegistry) resolve(ctx context.Context, tokens ...*Token) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var resolved []TokenMetadata
+	for _, token := range tokens {
+		if known, ok := r.known[token.Address]; ok {
+			token.Symbol, token.Decimals, token.LogoURI = known.Symbol, known.Decimals, known.LogoURI
+			continue
+		}
+		known, ok := r.tokens[token.Address]
+		token.LogoURI = known.LogoURI
+		if token.Symbol == "" || token.Decimals == 0 {
+			if ok {
+				token.Symbol, token.Decimals = known.Symbol, known.Decimals
+			}
+			continue
+		}
+		if ok && known.Symbol == token.Symbol && known.Decimals == token.Decimals {
+			continue
+		}
+
+		known.Address, known.Chain, known.Symbol, known.Decimals = token.Address, ChainEthereum, token.Symbol, token.Decimals
+		r.tokens[token.Address] = known
+		resolved = append(resolved, known)
+	}
+
+	if len(resolved) > 0 && r.cache != nil {
+		if err := r.cache.SaveTokens(ctx, resolved); err != nil {
+			LoggerWithCorrelationID(ctx, r.logger).Warnw("Failed to persist token metadata", "tokens", len(resolved), "error", err)
+		}
+	}
+}
+
+// resolvePositions resolves the tokens of all positions
+func (r *tokenRegistry) resolvePositions(ctx context.Context, positions []Position) {
+	tokens := make([]*Token, 0, 2*len(positions))
+	for i := range positions {
+		tokens = append(tokens, &positions[i].Token0, &positions[i].Token1)
+	}
+	r.resolve(ctx, tokens...)
+}
+
+// SetTokenCache loads the token metadata persisted in cache and persists newly resolved tokens there
+func (c *APIClient) SetTokenCache(ctx context.Context, cache TokenCache) error {
+	return c.tokens.setCache(ctx, cache)
+}
+
+// IsKnownToken reports whether the token is on the curated token list
+func (c *APIClient) IsKnownToken(address common.Address) bool {
+	c.tokens.mu.Lock()
+	defer c.tokens.mu.Unlock()
+	_, ok := c.tokens.known[address]
+	return ok
+}
+
+// SetKnownTokens replaces the curated token list with tokens, whose symbols and decimals take
+// precedence over what the subgraphs return
+func (c *APIClient) SetKnownTokens(tokens []TokenMetadata) {
+	c.tokens.setKnown(tokens)
+}
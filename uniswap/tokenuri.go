@@ -0,0 +1,110 @@
+package uniswap
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap/contracts"
+)
+
+// PositionManagerV3Address is the V3 NonfungiblePositionManager on Ethereum, the contract whose
+// NFTs V3 positions are
+var PositionManagerV3Address = common.HexToAddress("0xC36442b4a4522E871399CD717aBDD847Ab11FE88")
+
+// PositionMetadata is the ERC-721 metadata of a position NFT, which the position manager renders
+// on-chain from the position's pool, range and owner
+type PositionMetadata struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Image is the NFT's picture, a data URI of an SVG image
+	Image string `json:"image"`
+}
+
+// SVG returns the NFT's picture as an SVG document
+func (m PositionMetadata) SVG() ([]byte, error) {
+	mediaType, data, err := decodeDataURI(m.Image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if mediaType != "image/svg+xml" {
+		return nil, fmt.Errorf("image is %s, not SVG", mediaType)
+	}
+	return data, nil
+}
+
+// PositionMetadataSource is implemented by clients that can read the metadata of position NFTs
+type PositionMetadataSource interface {
+	// GetPositionMetadata returns the metadata of a position's NFT, ErrPositionNotFound if
+	// there is no such NFT, e.g. because it was burnt
+	GetPositionMetadata(ctx context.Context, version PositionVersion, id *big.Int) (*PositionMetadata, error)
+}
+
+// GetPositionMetadata calls the position manager's tokenURI and decodes the data URI it returns.
+// Only V3 positions are supported.
+func (c *RPCClient) GetPositionMetadata(ctx context.Context, version PositionVersion, id *big.Int) (*PositionMetadata, error) {
+	if version != VersionV3 {
+		return nil, fmt.Errorf("unsupported version: %s", version)
+	}
+
+	manager, err := contracts.NewNonfungiblePositionManagerCaller(PositionManagerV3Address, c)
+	if err != nil {
+		return nil, err
+	}
+	uri, err := manager.TokenURI(&bind.CallOpts{Context: ctx}, id)
+	if errors.Is(err, ErrExecutionReverted) {
+		// tokenURI reverts for tokens that don't exist
+		return nil, ErrPositionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to call tokenURI: %w", err)
+	}
+
+	mediaType, document, err := decodeDataURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token URI: %w", err)
+	}
+	if mediaType != "application/json" {
+		return nil, fmt.Errorf("token URI is %s, not JSON", mediaType)
+	}
+
+	var metadata PositionMetadata
+	if err := json.Unmarshal(document, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+	return &metadata, nil
+}
+
+// decodeDataURI returns the media type and the data of a data: URI, e.g. "image/svg+xml" and the
+// SVG of "data:image/svg+xml;base64,PHN2Zy...". Parameters other than base64 are dropped.
+func decodeDataURI(uri string) (string, []byte, error) {
+	rest, ok := strings.CutPrefix(uri, "data:")
+	if !ok {
+		return "", nil, errors.New("not a data URI")
+	}
+	header, payload, ok := strings.Cut(rest, ",")
+	if !ok {
+		return "", nil, errors.New("data URI has no data")
+	}
+
+	params := strings.Split(header, ";")
+	mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+	if mediaType == "" {
+		mediaType = "text/plain"
+	}
+	for _, param := range params[1:] {
+		if param == "base64" {
+			data, err := base64.StdEncoding.DecodeString(payload)
+			return mediaType, data, err
+		}
+	}
+	data, err := url.PathUnescape(payload)
+	return mediaType, []byte(data), err
+}
@@ -22,6 +22,13 @@ type Token struct {
 	Address  common.Address `json:"address"`
 	Symbol   string         `json:"symbol"`
 	Decimals uint8          `json:"decimals"`
+	ChainID  ChainID        `json:"chainId,omitempty"`
+	// LogoURI is the token's logo from the token list or token cache, empty if none is known
+	LogoURI string `json:"logoURI,omitempty"`
+	// PriceUSD is the token's price set by ApplyTokenPrices, 0 if it wasn't priced
+	PriceUSD float64 `json:"priceUSD,omitempty"`
+	// Risk is why TokenScreener found the token suspicious, empty if it didn't or wasn't asked
+	Risk TokenRisk `json:"risk,omitempty"`
 }
 
 // Position represents a Uniswap position (either V3 or V4)
@@ -36,17 +43,26 @@ type Position struct {
 	Amount1   *big.Int        `json:"amount1"`
 	FeeTier   uint32          `json:"feeTier"`
 	CreatedAt time.Time       `json:"createdAt"`
+	// ChainID is the chain the position is on, zero in positions stored before it was recorded,
+	// which are all on Ethereum: see Chain
+	ChainID ChainID `json:"chainId,omitempty"`
 
 	// V3 specific fields
-	TickLower int      `json:"tickLower,omitempty"`
-	TickUpper int      `json:"tickUpper,omitempty"`
-	Liquidity *big.Int `json:"liquidity,omitempty"`
+	// PoolAddress is the contract address of the position's pool, V4 pools are identified by PoolID
+	PoolAddress common.Address `json:"poolAddress,omitempty"`
+	TickLower   int            `json:"tickLower,omitempty"`
+	TickUpper   int            `json:"tickUpper,omitempty"`
+	Liquidity   *big.Int       `json:"liquidity,omitempty"`
 
 	// Fee information
 	UnclaimedFees0 *big.Int `json:"unclaimedFees0"`
 	UnclaimedFees1 *big.Int `json:"unclaimedFees1"`
 
-	// Price range
+	// CurrentTick is the pool's current tick, nil if the data source didn't tell
+	CurrentTick *int `json:"currentTick,omitempty"`
+
+	// Price range and the pool's current price, all prices of token0 in token1 adjusted by the
+	// tokens' decimals, see SqrtPriceX96ToPrice
 	PriceLower   *big.Float `json:"priceLower"`
 	PriceUpper   *big.Float `json:"priceUpper"`
 	CurrentPrice *big.Float `json:"currentPrice"`
@@ -56,8 +72,16 @@ type Position struct {
 	WithdrawnToken0 *big.Int `json:"withdrawnToken0"`
 	WithdrawnToken1 *big.Int `json:"withdrawnToken1"`
 
-	// V4 specific fields (if any)
-	// Add V4 specific fields here as needed
+	// V4 specific fields
+	PoolID common.Hash `json:"poolId,omitempty"`
+}
+
+// Chain returns the chain the position is on, Ethereum if it wasn't recorded
+func (p Position) Chain() ChainID {
+	if p.ChainID == 0 {
+		return ChainIDEthereum
+	}
+	return p.ChainID
 }
 
 // PositionSummary provides a human-readable summary of a position
@@ -70,11 +94,55 @@ type PositionSummary struct {
 	UnclaimedFees string `json:"unclaimedFees"`
 	CreatedAt     string `json:"createdAt"`
 	InRange       bool   `json:"inRange"`
+
+	// Logos of the tokens, empty if not known
+	Token0LogoURI string `json:"token0LogoURI,omitempty"`
+	Token1LogoURI string `json:"token1LogoURI,omitempty"`
+
+	// Dollar figures at current prices, set by FormatPositionSummaryUSD and empty if the
+	// position's tokens can't be priced
+	ValueUSD    string `json:"valueUSD,omitempty"`
+	FeesUSD     string `json:"feesUSD,omitempty"`
+	PnLUSD      string `json:"pnlUSD,omitempty"`
+	TokenPrices string `json:"tokenPrices,omitempty"`
 }
 
-// PositionRequest represents a request to fetch positions for a wallet
+// PositionRequest represents a request to fetch positions for a wallet. Clients apply the
+// options after the versions with Apply.
 type PositionRequest struct {
 	WalletAddress common.Address
 	IncludeV3     bool
 	IncludeV4     bool
+	// IncludeV2 asks for V2 liquidity too. V2 liquidity is a balance of pool tokens rather than
+	// a position NFT, and no client fetches it yet, so they leave it out.
+	IncludeV2 bool
+	// IncludeClosed keeps the positions known to hold no liquidity anymore, see IsClosed
+	IncludeClosed bool
+	// MinLiquidity leaves out the positions with less liquidity, unless it is nil. Positions
+	// whose liquidity the data source doesn't report are kept.
+	MinLiquidity *big.Int
+	// Chains leaves out the positions on other chains, unless it is empty
+	Chains []ChainID
+	// SortBy orders the positions, which are in the order the data source returns them if nil
+	SortBy PositionOrder
+	// Offset skips that many positions, after sorting, and Limit keeps at most that many of
+	// the rest unless it is 0, to fetch the positions a page at a time
+	Offset int
+	Limit  int
+}
+
+// Swap is a single swap executed against a pool
+type Swap struct {
+	// TxHash is the hash of the transaction containing the swap
+	TxHash    common.Hash     `json:"txHash"`
+	Version   PositionVersion `json:"version"`
+	Pool      common.Address  `json:"pool"`
+	Token0    Token           `json:"token0"`
+	Token1    Token           `json:"token1"`
+	FeeTier   uint32          `json:"feeTier"`
+	Timestamp time.Time       `json:"timestamp"`
+	// Amount0 and Amount1 are decimal adjusted pool balance changes. A positive amount was paid into the pool.
+	Amount0   *big.Float `json:"amount0"`
+	Amount1   *big.Float `json:"amount1"`
+	AmountUSD float64    `json:"amountUSD"`
 }
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"go.uber.org/zap"
+)
+
+// usageFlushInterval is how often the usage counted in memory is added to the database
+const usageFlushInterval = time.Minute
+
+// usageGroup is the dispatcher group of the usage handler. It runs after all other groups, so it
+// sees every update the access guard lets through, whether another handler took it or not.
+const usageGroup = 2
+
+// Kinds of usage counters
+const (
+	usageKindCommand      = "command"
+	usageKindGraphRequest = "graph_request"
+)
+
+type usageKey struct {
+	day, kind, name string
+}
+
+// UsageTracker counts the daily active users, the commands they send and the requests made to The
+// Graph. It counts in memory and adds the counts to the database periodically, so the operator can
+// plan the Graph API quota without a write per update.
+type UsageTracker struct {
+	db     UsageStore
+	logger *zap.SugaredLogger
+
+	mu       sync.Mutex
+	users    map[string]map[int64]bool
+	counters map[usageKey]int64
+}
+
+func NewUsageTracker(db UsageStore, logger *zap.SugaredLogger) *UsageTracker {
+	return &UsageTracker{
+		db:       db,
+		logger:   logger,
+		users:    make(map[string]map[int64]bool),
+		counters: make(map[usageKey]int64),
+	}
+}
+
+// usageDay returns the day usage at t is counted on
+func usageDay(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+func (t *UsageTracker) recordUser(userID int64) {
+	day := usageDay(time.Now())
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.users[day] == nil {
+		t.users[day] = make(map[int64]bool)
+	}
+	t.users[day][userID] = true
+}
+
+func (t *UsageTracker) count(kind, name string) {
+	key := usageKey{day: usageDay(time.Now()), kind: kind, name: name}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.counters[key]++
+}
+
+// CountGraphRequest counts a request to one of The Graph's subgraphs. It is a uniswap.RequestObserver.
+func (t *UsageTracker) CountGraphRequest(subgraph string) {
+	t.count(usageKindGraphRequest, subgraph)
+}
+
+// Job returns the scheduler job flushing the counted usage to the database periodically, and once
+// more when the scheduler stops to keep what was counted since the last flush
+func (t *UsageTracker) Job() Job {
+	return Job{Name: "usage flush", Interval: usageFlushInterval, Run: t.Flush, Stop: t.Flush}
+}
+
+// Flush adds the usage counted since the last flush to the database. If that fails, the counts are
+// kept for the next flush.
+func (t *UsageTracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	users, counters := t.users, t.counters
+	t.users, t.counters = make(map[string]map[int64]bool), make(map[usageKey]int64)
+	t.mu.Unlock()
+
+	if len(users) == 0 && len(counters) == 0 {
+		return nil
+	}
+
+	activeUsers := make(map[string][]int64, len(users))
+	for day, ids := range users {
+		for id := range ids {
+			activeUsers[day] = append(activeUsers[day], id)
+		}
+	}
+	records := make([]UsageCounter, 0, len(counters))
+	for key, n := range counters {
+		records = append(records, UsageCounter{Day: key.day, Kind: key.kind, Name: key.name, Count: n})
+	}
+
+	if err := t.db.RecordUsage(ctx, activeUsers, records); err != nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		for day, ids := range users {
+			if t.users[day] == nil {
+				t.users[day] = make(map[int64]bool)
+			}
+			for id := range ids {
+				t.users[day][id] = true
+			}
+		}
+		for key, n := range counters {
+			t.counters[key] += n
+		}
+		return err
+	}
+	return nil
+}
+
+// Usage flushes the counted usage and returns the usage of the last days, today included, newest first
+func (t *UsageTracker) Usage(ctx context.Context, days int) ([]DailyUsage, error) {
+	if err := t.Flush(ctx); err != nil {
+		return nil, err
+	}
+	return t.db.GetUsage(ctx, usageDay(time.Now().AddDate(0, 0, -(days-1))))
+}
+
+// Handler returns the dispatcher handler counting the users and commands of every update. resolve maps
+// command names and aliases to the command they run; commands it doesn't know aren't counted.
+func (t *UsageTracker) Handler(resolve func(name string) (string, bool)) ext.Handler {
+	return &usageHandler{tracker: t, resolve: resolve}
+}
+
+type usageHandler struct {
+	tracker *UsageTracker
+	resolve func(name string) (string, bool)
+}
+
+func (u *usageHandler) Name() string {
+	return "usage"
+}
+
+func (u *usageHandler) CheckUpdate(b *gotgbot.Bot, ctx *ext.Context) bool {
+	return ctx.EffectiveUser != nil
+}
+
+func (u *usageHandler) HandleUpdate(b *gotgbot.Bot, ctx *ext.Context) error {
+	u.tracker.recordUser(ctx.EffectiveUser.Id)
+
+	msg := ctx.EffectiveMessage
+	if msg == nil || !isCommand(msg) {
+		return nil
+	}
+	name, mention := parseCommandName(msg.Text)
+	if mention != "" && !strings.EqualFold(mention, b.Username) {
+		return nil
+	}
+	if command, ok := u.resolve(name); ok {
+		u.tracker.count(usageKindCommand, command)
+	}
+	return nil
+}
+
+// metricsPath is where MetricsServer is served
+const metricsPath = "/metrics"
+
+// MetricsServer serves today's usage in the Prometheus text format to scrapers presenting the token
+type MetricsServer struct {
+	usage  *UsageTracker
+	token  string
+	logger *zap.SugaredLogger
+}
+
+func NewMetricsServer(usage *UsageTracker, token string, logger *zap.SugaredLogger) *MetricsServer {
+	return &MetricsServer{usage: usage, token: token, logger: logger}
+}
+
+func (s *MetricsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+s.token)) != 1 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	usage, err := s.usage.Usage(r.Context(), 1)
+	if err != nil {
+		s.logger.Errorw("Failed to get usage for metrics", "error", err)
+		http.Error(w, "Failed to get metrics", http.StatusInternalServerError)
+		return
+	}
+	today := DailyUsage{Day: usageDay(time.Now())}
+	if len(usage) > 0 && usage[0].Day == today.Day {
+		today = usage[0]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# HELP uniswapfetcher_active_users_today Users who used the bot today (UTC).\n")
+	sb.WriteString("# TYPE uniswapfetcher_active_users_today gauge\n")
+	sb.WriteString(fmt.Sprintf("uniswapfetcher_active_users_today %d\n", today.ActiveUsers))
+	for _, metric := range []struct{ kind, name, label, help string }{
+		{usageKindCommand, "uniswapfetcher_commands_today", "command", "Commands sent today (UTC)."},
+		{usageKindGraphRequest, "uniswapfetcher_graph_requests_today", "subgraph", "Requests made to The Graph today (UTC)."},
+	} {
+		sb.WriteString(fmt.Sprintf("# HELP %s %s\n", metric.name, metric.help))
+		sb.WriteString(fmt.Sprintf("# TYPE %s gauge\n", metric.name))
+		for _, c := range today.Counters {
+			if c.Kind == metric.kind {
+				sb.WriteString(fmt.Sprintf("%s{%s=%q} %d\n", metric.name, metric.label, c.Name, c.Count))
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(sb.String()))
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/korjavin/uniswapfetcher/uniswap"
+	"go.uber.org/zap"
+)
+
+// URL paths of the Mini App dashboard and its JSON API, relative to PUBLIC_URL
+const (
+	webAppPath          = "/app"
+	webAppPositionsPath = "/api/positions"
+)
+
+// webAppInitDataMaxAge is how long the init data Telegram hands the Mini App stays valid
+const webAppInitDataMaxAge = 24 * time.Hour
+
+//go:embed webapp/index.html
+var webAppPage []byte
+
+func (h *BotHandlers) handleDashboard(b *gotgbot.Bot, ctx *ext.Context) error {
+	h.log(ctx).Infow("Received dashboard command", "user_id", ctx.EffectiveUser.Id, "chat_id", ctx.EffectiveChat.Id)
+
+	if h.publicURL == "" {
+		_, err := ctx.EffectiveMessage.Reply(b, "The dashboard is not enabled on this bot.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	// Telegram only allows Mini App buttons in private chats
+	if ctx.EffectiveChat.Type != gotgbot.ChatTypePrivate {
+		_, err := ctx.EffectiveMessage.Reply(b, "The dashboard shows your own wallets, please open it in a private chat with me.", &gotgbot.SendMessageOpts{})
+		return err
+	}
+
+	_, err := ctx.EffectiveMessage.Reply(b, "Open the dashboard to browse, filter and chart your positions.", &gotgbot.SendMessageOpts{
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{
+			InlineKeyboard: [][]gotgbot.InlineKeyboardButton{{
+				{Text: "Open dashboard", WebApp: &gotgbot.WebAppInfo{Url: strings.TrimSuffix(h.publicURL, "/") + webAppPath}},
+			}},
+		},
+	})
+	return err
+}
+
+// WebAppServer serves the Mini App dashboard and the JSON API behind it. API requests are
+// authenticated with the init data Telegram passes to the Mini App, so they can only ever
+// return the data of the private chat of the user who opened it.
+type WebAppServer struct {
+	botTokens     []string
+	db            Store
+	uniswapClient uniswap.Client
+	logger        *zap.SugaredLogger
+}
+
+// NewWebAppServer creates the server of the Mini App of the bots with botTokens
+func NewWebAppServer(botTokens []string, db Store, uniswapClient uniswap.Client, logger *zap.SugaredLogger) *WebAppServer {
+	return &WebAppServer{
+		botTokens:     botTokens,
+		db:            db,
+		uniswapClient: uniswapClient,
+		logger:        logger,
+	}
+}
+
+// Register mounts the dashboard and its API on mux
+func (s *WebAppServer) Register(mux *http.ServeMux) {
+	mux.HandleFunc(webAppPath, s.handlePage)
+	mux.HandleFunc(webAppPositionsPath, s.handlePositions)
+}
+
+func (s *WebAppServer) handlePage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(webAppPage)
+}
+
+// webAppPosition is a position as returned by the dashboard API
+type webAppPosition struct {
+	ID         string   `json:"id"`
+	Version    string   `json:"version"`
+	Wallet     string   `json:"wallet,omitempty"`
+	Pair       string   `json:"pair"`
+	FeeTier    string   `json:"feeTier"`
+	Amounts    string   `json:"amounts"`
+	PriceRange string   `json:"priceRange"`
+	Fees       string   `json:"fees"`
+	InRange    bool     `json:"inRange"`
+	Active     bool     `json:"active"`
+	CreatedAt  string   `json:"createdAt"`
+	ValueUSD   *float64 `json:"valueUSD,omitempty"`
+	FeesUSD    *float64 `json:"feesUSD,omitempty"`
+
+	Token0 webAppToken `json:"token0"`
+	Token1 webAppToken `json:"token1"`
+}
+
+// webAppToken is what the dashboard shows about a token of a position
+type webAppToken struct {
+	Symbol   string  `json:"symbol"`
+	LogoURI  string  `json:"logoURI,omitempty"`
+	PriceUSD float64 `json:"priceUSD,omitempty"`
+}
+
+func newWebAppToken(t uniswap.Token) webAppToken {
+	return webAppToken{Symbol: t.String(), LogoURI: t.LogoURI, PriceUSD: t.PriceUSD}
+}
+
+type webAppPositionsResponse struct {
+	Positions []webAppPosition `json:"positions"`
+	// Failed counts the wallets and tracked positions that could not be fetched
+	Failed int `json:"failed"`
+}
+
+func (s *WebAppServer) handlePositions(w http.ResponseWriter, r *http.Request) {
+	// The Mini App may have been opened from any of the bots, which sign its init data with their tokens
+	var userID int64
+	var err error
+	for _, token := range s.botTokens {
+		if userID, err = validateWebAppInitData(r.Header.Get("X-Telegram-Init-Data"), token, time.Now()); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		s.logger.Debugw("Rejected Mini App request", "error", err)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// The Mini App is only offered in private chats, whose ID is the user's ID
+	chatID := userID
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+	defer cancel()
+
+	positions, failed, err := fetchChatPositions(ctx, s.db, s.uniswapClient, s.logger, chatID)
+	if err != nil {
+		s.logger.Errorw("Failed to fetch Mini App positions", "chat_id", chatID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	uniswap.ApplyTokenPrices(positions, priceTokens(ctx, s.uniswapClient, positions, s.logger))
+
+	resp := webAppPositionsResponse{Positions: []webAppPosition{}, Failed: failed}
+	for _, pos := range positions {
+		summary := uniswap.FormatPositionSummary(pos)
+		item := webAppPosition{
+			ID:         summary.ID,
+			Version:    summary.Version,
+			Pair:       summary.TokenPair,
+			FeeTier:    uniswap.FormatFeeTier(pos.FeeTier),
+			Amounts:    summary.Amounts,
+			PriceRange: summary.PriceRange,
+			Fees:       summary.UnclaimedFees,
+			InRange:    summary.InRange,
+			Active:     uniswap.HasLiquidity(pos),
+			CreatedAt:  pos.CreatedAt.UTC().Format(time.RFC3339),
+			Token0:     newWebAppToken(pos.Token0),
+			Token1:     newWebAppToken(pos.Token1),
+		}
+		if pos.Owner != (common.Address{}) {
+			item.Wallet = pos.Owner.Hex()
+		}
+
+		if values, ok := uniswap.PositionValuesUSD(pos, nil); ok {
+			item.ValueUSD, item.FeesUSD = &values.Value, &values.Fees
+		}
+		resp.Positions = append(resp.Positions, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.logger.Warnw("Failed to write Mini App response", "error", err)
+	}
+}
+
+// validateWebAppInitData checks the signature Telegram puts on Mini App init data and returns
+// the ID of the user who opened the Mini App, see
+// https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app
+func validateWebAppInitData(initData, botToken string, now time.Time) (int64, error) {
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return 0, fmt.Errorf("invalid init data: %w", err)
+	}
+
+	hash := values.Get("hash")
+	if hash == "" {
+		return 0, errors.New("init data is not signed")
+	}
+
+	pairs := make([]string, 0, len(values))
+	for key := range values {
+		if key != "hash" {
+			pairs = append(pairs, key+"="+values.Get(key))
+		}
+	}
+	sort.Strings(pairs)
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(botToken))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(strings.Join(pairs, "\n")))
+
+	expected, err := hex.DecodeString(hash)
+	if err != nil || !hmac.Equal(mac.Sum(nil), expected) {
+		return 0, errors.New("invalid init data signature")
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil || now.Sub(time.Unix(authDate, 0)) > webAppInitDataMaxAge {
+		return 0, errors.New("init data expired")
+	}
+
+	var user struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(values.Get("user")), &user); err != nil || user.ID == 0 {
+		return 0, errors.New("init data has no user")
+	}
+	return user.ID, nil
+}
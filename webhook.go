@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// webhookPath is the URL path Telegram posts updates to, relative to WEBHOOK_URL.
+const webhookPath = "telegram/webhook"
+
+// Telegram only accepts 1-256 characters of A-Z, a-z, 0-9, _ and - as a secret token.
+var webhookSecretPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// WebhookConfig configures receiving updates through a Telegram webhook instead of long polling.
+type WebhookConfig struct {
+	// URL is the public base URL Telegram should call, e.g. https://bot.example.com
+	URL string
+	// Secret is sent by Telegram in the X-Telegram-Bot-Api-Secret-Token header of every request
+	Secret string
+}
+
+func (c WebhookConfig) validate() error {
+	if !strings.HasPrefix(c.URL, "https://") {
+		return fmt.Errorf("WEBHOOK_URL must be an https:// URL")
+	}
+	if !webhookSecretPattern.MatchString(c.Secret) {
+		return fmt.Errorf("WEBHOOK_SECRET is required in webhook mode and may only contain A-Z, a-z, 0-9, _ and - (max 256 characters)")
+	}
+	return nil
+}
+
+// startWebhook mounts the webhook handler on the bot's HTTP server and registers its URL with Telegram.
+// The primary bot's updates are posted to webhookPath, those of other bots to webhookPath/<bot ID>.
+func startWebhook(updater *ext.Updater, bots *botSet, cfg WebhookConfig, mux *http.ServeMux) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	for _, bot := range bots.bots {
+		path := webhookPath
+		if bot != bots.Primary() {
+			path += "/" + strconv.FormatInt(bot.Id, 10)
+		}
+		err := updater.AddWebhook(bot, path, &ext.AddWebhookOpts{SecretToken: cfg.Secret})
+		if err != nil {
+			return fmt.Errorf("failed to add webhook of %s: %w", bot.Username, err)
+		}
+		mux.Handle("/"+path, updater.GetHandlerFunc("/"))
+	}
+
+	err := updater.SetAllBotWebhooks(cfg.URL, &gotgbot.SetWebhookOpts{
+		DropPendingUpdates: true,
+		SecretToken:        cfg.Secret,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultFetchConcurrency is how many wallets and positions are fetched at the same time
+const defaultFetchConcurrency = 4
+
+// forEachConcurrently calls fn for each item on at most workers goroutines and returns once all calls
+// have returned. Items not started yet are skipped once ctx is cancelled. fn gets the index of its
+// item, so results can be stored in a slice without locking and keep the order of items. If fn
+// panics, the panic is raised again on the calling goroutine, where the handler or job can recover
+// from it, rather than taking the bot down.
+func forEachConcurrently[T any](ctx context.Context, workers int, items []T, fn func(i int, item T)) {
+	if workers < 1 {
+		workers = 1
+	}
+	workers = min(workers, len(items))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	var panicOnce sync.Once
+	var panicked *goroutinePanic
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					panicOnce.Do(func() { panicked = &goroutinePanic{value: r, stack: panicStack(r)} })
+					// Keep taking items, so the loop below isn't left waiting for a worker
+					for range next {
+					}
+				}
+			}()
+			for i := range next {
+				fn(i, items[i])
+			}
+		}()
+	}
+
+	for i := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	if panicked != nil {
+		panic(panicked)
+	}
+}